@@ -0,0 +1,16 @@
+//Package database contains the SQL migrations of the database schema and the fixtures of demo and test databases,
+//they are embedded in the service binary
+package database
+
+import "embed"
+
+//Migrations contains the migration files. A file is named by its version and a description, e.g. 0001_initial.sql,
+//and has an Up section applying the migration and a Down section reverting it, each started by a "-- +migrate" line
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+//Fixtures contains the named fixture sets, each a directory of JSON or YAML archives like those of the export command
+//
+//go:embed fixtures
+var Fixtures embed.FS
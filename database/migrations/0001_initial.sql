@@ -0,0 +1,28 @@
+-- The tables may already exist in databases restored from a dump taken before migrations were recorded, migrations 1 to 3
+-- only create what is missing so they apply to tables left by any of them.
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS mongodbs (
+  domain  text PRIMARY KEY,
+  mongodb boolean,
+  host    text,
+  port    text
+);
+
+CREATE TABLE IF NOT EXISTS tempconfigs (
+  rest_api_root   text PRIMARY KEY,
+  host            text,
+  port            text,
+  remoting        text,
+  legasy_explorer boolean
+);
+
+CREATE TABLE IF NOT EXISTS tsconfigs (
+  module     text PRIMARY KEY,
+  target     text,
+  source_map boolean,
+  excluding  integer
+);
+
+-- +migrate Down
+DROP TABLE mongodbs, tempconfigs, tsconfigs;
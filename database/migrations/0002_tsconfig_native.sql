@@ -0,0 +1,15 @@
+-- +migrate Up
+ALTER TABLE tsconfigs
+  ADD COLUMN IF NOT EXISTS extends          text,
+  ADD COLUMN IF NOT EXISTS compiler_options text,
+  ADD COLUMN IF NOT EXISTS files            text,
+  ADD COLUMN IF NOT EXISTS include          text,
+  ADD COLUMN IF NOT EXISTS exclude          text;
+
+-- +migrate Down
+ALTER TABLE tsconfigs
+  DROP COLUMN extends,
+  DROP COLUMN compiler_options,
+  DROP COLUMN files,
+  DROP COLUMN include,
+  DROP COLUMN exclude;
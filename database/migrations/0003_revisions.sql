@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE mongodbs ADD COLUMN IF NOT EXISTS revision bigint NOT NULL DEFAULT 1;
+ALTER TABLE tsconfigs ADD COLUMN IF NOT EXISTS revision bigint NOT NULL DEFAULT 1;
+ALTER TABLE tempconfigs ADD COLUMN IF NOT EXISTS revision bigint NOT NULL DEFAULT 1;
+
+-- +migrate Down
+ALTER TABLE mongodbs DROP COLUMN revision;
+ALTER TABLE tsconfigs DROP COLUMN revision;
+ALTER TABLE tempconfigs DROP COLUMN revision;
@@ -0,0 +1,66 @@
+-- Every config gets a surrogate id and its name becomes unique within its namespace, the type being given by the table.
+-- Databases created from the former SQL files or the pg_dump backup have no keys and may hold configs with empty or
+-- duplicate names. Of configs sharing a name the one with the highest revision, then the oldest one, is kept; the others
+-- and those with empty names are moved to duplicate_configs, where they can be reviewed and restored by hand.
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS duplicate_configs (
+  config_type text NOT NULL,
+  config_name text NOT NULL,
+  config      jsonb NOT NULL,
+  reason      text NOT NULL,
+  moved_at    timestamp with time zone NOT NULL DEFAULT now()
+);
+
+DO $$
+DECLARE
+  config record;
+  moved  bigint;
+BEGIN
+  FOR config IN SELECT * FROM (VALUES
+    ('mongodbs', 'mongodb', 'domain'),
+    ('tempconfigs', 'tempconfig', 'rest_api_root'),
+    ('tsconfigs', 'tsconfig', 'module')
+  ) AS c (config_table, config_type, name_column) LOOP
+    EXECUTE format('ALTER TABLE %1$I DROP CONSTRAINT IF EXISTS %2$I', config.config_table, config.config_table || '_pkey');
+    EXECUTE format('ALTER TABLE %1$I ADD COLUMN id bigserial PRIMARY KEY, ADD COLUMN namespace text NOT NULL DEFAULT ''default''', config.config_table);
+    EXECUTE format($query$
+      WITH moved AS (
+        DELETE FROM %1$I c WHERE c.%2$I = '' OR EXISTS (
+          SELECT 1 FROM %1$I k WHERE k.namespace = c.namespace AND k.%2$I = c.%2$I
+            AND (k.revision > c.revision OR k.revision = c.revision AND k.id < c.id))
+        RETURNING c.*
+      )
+      INSERT INTO duplicate_configs (config_type, config_name, config, reason)
+      SELECT %3$L, %2$I, to_jsonb(moved) - 'id', CASE WHEN %2$I = '' THEN 'empty name' ELSE 'duplicate name' END FROM moved
+    $query$, config.config_table, config.name_column, config.config_type);
+    GET DIAGNOSTICS moved = ROW_COUNT;
+    IF moved > 0 THEN
+      RAISE WARNING '% % config(s) with empty or duplicate names have been moved to duplicate_configs', moved, config.config_type;
+    END IF;
+    EXECUTE format('ALTER TABLE %1$I ADD CONSTRAINT %2$I UNIQUE (namespace, %3$I), ADD CONSTRAINT %4$I CHECK (%3$I <> '''')',
+      config.config_table, config.config_table || '_namespace_name_key', config.name_column, config.config_table || '_name_check');
+  END LOOP;
+END
+$$;
+
+-- +migrate Down
+-- duplicate_configs is kept, so configs moved there are not lost by reverting
+ALTER TABLE mongodbs
+  DROP CONSTRAINT mongodbs_namespace_name_key,
+  DROP CONSTRAINT mongodbs_name_check,
+  DROP COLUMN id,
+  DROP COLUMN namespace,
+  ADD PRIMARY KEY (domain);
+ALTER TABLE tempconfigs
+  DROP CONSTRAINT tempconfigs_namespace_name_key,
+  DROP CONSTRAINT tempconfigs_name_check,
+  DROP COLUMN id,
+  DROP COLUMN namespace,
+  ADD PRIMARY KEY (rest_api_root);
+ALTER TABLE tsconfigs
+  DROP CONSTRAINT tsconfigs_namespace_name_key,
+  DROP CONSTRAINT tsconfigs_name_check,
+  DROP COLUMN id,
+  DROP COLUMN namespace,
+  ADD PRIMARY KEY (module);
@@ -0,0 +1,51 @@
+-- MongoDB configs describe a whole connection: replica set members, database, authentication, TLS and read preference.
+-- Hosts stored as a seed list like 'a:27017,b:27018' are split into the first member, kept in host and port, and the
+-- others, moved to members; a member without port gets the default port 27017. Hosts which are not a valid seed list are
+-- left as they are for the check command to report. The meaningless mongodb column is dropped.
+
+-- +migrate Up
+ALTER TABLE mongodbs
+  ADD COLUMN members         text,
+  ADD COLUMN replica_set     text,
+  ADD COLUMN database        text,
+  ADD COLUMN auth_source     text,
+  ADD COLUMN username        text,
+  ADD COLUMN password_secret text,
+  ADD COLUMN tls             boolean NOT NULL DEFAULT false,
+  ADD COLUMN read_preference text;
+
+UPDATE mongodbs m SET
+  host = split_part(s.seeds[1], ':', 1),
+  port = COALESCE(NULLIF(split_part(s.seeds[1], ':', 2), ''), m.port),
+  members = (
+    SELECT json_agg(json_build_object(
+      'host', split_part(seed, ':', 1),
+      'port', COALESCE(NULLIF(split_part(seed, ':', 2), ''), '27017')::integer
+    ) ORDER BY n)::text
+    FROM unnest(s.seeds[2:]) WITH ORDINALITY AS member (seed, n)
+  )
+FROM (SELECT id, string_to_array(replace(host, ' ', ''), ',') AS seeds FROM mongodbs WHERE replace(host, ' ', '') ~ '^[^:,]+(:[0-9]{1,5})?(,[^:,]+(:[0-9]{1,5})?)+$') s
+WHERE m.id = s.id;
+
+ALTER TABLE mongodbs DROP COLUMN mongodb;
+
+-- +migrate Down
+-- members are joined into a seed list in host again, the other new fields are lost; a first member without port is
+-- written without it
+ALTER TABLE mongodbs ADD COLUMN mongodb boolean DEFAULT true;
+
+UPDATE mongodbs SET host = concat_ws(':', host, port) || COALESCE((
+  SELECT string_agg(',' || (member ->> 'host') || ':' || (member ->> 'port'), '' ORDER BY n)
+  FROM json_array_elements(members::json) WITH ORDINALITY AS m (member, n)
+), '')
+WHERE members IS NOT NULL;
+
+ALTER TABLE mongodbs
+  DROP COLUMN members,
+  DROP COLUMN replica_set,
+  DROP COLUMN database,
+  DROP COLUMN auth_source,
+  DROP COLUMN username,
+  DROP COLUMN password_secret,
+  DROP COLUMN tls,
+  DROP COLUMN read_preference;
@@ -0,0 +1,36 @@
+-- Ports of MongoDB configs and Tempconfigs become integers from 1 to 65535. Blanks and leading zeros are dropped and
+-- empty ports become NULL. Other ports can not be converted safely, the migration then fails with the number of
+-- such configs; they are listed by the check command and have to be corrected first.
+
+-- +migrate Up
+DO $$
+DECLARE
+  config  record;
+  invalid bigint;
+BEGIN
+  FOR config IN SELECT * FROM (VALUES ('mongodbs', 'mongodb'), ('tempconfigs', 'tempconfig')) AS c (config_table, config_type) LOOP
+    EXECUTE format($query$
+      SELECT count(*) FROM %I
+      WHERE trim(port) <> '' AND NOT CASE WHEN trim(port) ~ '^[0-9]{1,5}$' THEN trim(port)::integer BETWEEN 1 AND 65535 ELSE false END
+    $query$, config.config_table) INTO invalid;
+    IF invalid > 0 THEN
+      RAISE EXCEPTION '% % config(s) have ports which are not numbers from 1 to 65535, run the check command to list them', invalid, config.config_type;
+    END IF;
+  END LOOP;
+END
+$$;
+
+ALTER TABLE mongodbs
+  ALTER COLUMN port TYPE integer USING NULLIF(trim(port), '')::integer,
+  ADD CONSTRAINT mongodbs_port_check CHECK (port BETWEEN 1 AND 65535);
+ALTER TABLE tempconfigs
+  ALTER COLUMN port TYPE integer USING NULLIF(trim(port), '')::integer,
+  ADD CONSTRAINT tempconfigs_port_check CHECK (port BETWEEN 1 AND 65535);
+
+-- +migrate Down
+ALTER TABLE mongodbs
+  DROP CONSTRAINT mongodbs_port_check,
+  ALTER COLUMN port TYPE text USING port::text;
+ALTER TABLE tempconfigs
+  DROP CONSTRAINT tempconfigs_port_check,
+  ALTER COLUMN port TYPE text USING port::text;
@@ -0,0 +1,32 @@
+-- Tempconfigs model the whole config.json of a LoopBack server: remoting holds its options as a JSON object, and
+-- legasy_explorer is renamed to legacy_explorer. Former versions stored remoting as free text, which has no meaning as
+-- options; such configs are copied to duplicate_configs for review and their remoting is cleared.
+
+-- +migrate Up
+DO $$
+DECLARE
+  config  record;
+  cleared bigint := 0;
+BEGIN
+  FOR config IN SELECT * FROM tempconfigs WHERE remoting IS NOT NULL LOOP
+    BEGIN
+      CONTINUE WHEN jsonb_typeof(config.remoting::jsonb) = 'object';
+    EXCEPTION WHEN invalid_text_representation THEN
+      -- not JSON at all
+    END;
+    INSERT INTO duplicate_configs (config_type, config_name, config, reason)
+    VALUES ('tempconfig', config.rest_api_root, to_jsonb(config) - 'id', 'remoting is not a JSON object');
+    UPDATE tempconfigs SET remoting = NULL WHERE id = config.id;
+    cleared := cleared + 1;
+  END LOOP;
+  IF cleared > 0 THEN
+    RAISE WARNING '% tempconfig(s) had a remoting which is not a JSON object, it has been cleared and the configs have been copied to duplicate_configs', cleared;
+  END IF;
+END
+$$;
+
+ALTER TABLE tempconfigs RENAME COLUMN legasy_explorer TO legacy_explorer;
+
+-- +migrate Down
+-- remoting stays JSON text, which former versions read as free text
+ALTER TABLE tempconfigs RENAME COLUMN legacy_explorer TO legasy_explorer;
@@ -0,0 +1,21 @@
+-- Feature flags are a config type named by their key. variants holds a JSON object of the values the flag can serve,
+-- rollout and rules hold JSON arrays, as written by the service.
+
+-- +migrate Up
+CREATE TABLE featureflags (
+  id              bigserial PRIMARY KEY,
+  namespace       text NOT NULL DEFAULT 'default',
+  key             text NOT NULL,
+  enabled         boolean NOT NULL DEFAULT false,
+  variants        text,
+  off_variant     text,
+  default_variant text,
+  rollout         text,
+  rules           text,
+  revision        bigint NOT NULL DEFAULT 1,
+  CONSTRAINT featureflags_namespace_name_key UNIQUE (namespace, key),
+  CONSTRAINT featureflags_name_check CHECK (key <> '')
+);
+
+-- +migrate Down
+DROP TABLE featureflags;
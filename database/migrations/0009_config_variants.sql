@@ -0,0 +1,13 @@
+-- MongoDB configs, tempconfigs and tsconfigs can hold variants served to clients with matching labels, stored as a JSON
+-- array of variants with their name, the labels to match and a merge patch of the config.
+
+-- +migrate Up
+ALTER TABLE mongodbs ADD COLUMN variants text;
+ALTER TABLE tempconfigs ADD COLUMN variants text;
+ALTER TABLE tsconfigs ADD COLUMN variants text;
+
+-- +migrate Down
+-- the variants are lost
+ALTER TABLE mongodbs DROP COLUMN variants;
+ALTER TABLE tempconfigs DROP COLUMN variants;
+ALTER TABLE tsconfigs DROP COLUMN variants;
@@ -0,0 +1,21 @@
+-- Every config can carry labels, key/value pairs queried by label selectors, and annotations, free-text notes like its
+-- owner, description or ticket. Labels are stored as jsonb with a GIN index, which serves the containment (@>) and key
+-- existence (?) tests label selectors are translated to.
+
+-- +migrate Up
+ALTER TABLE mongodbs ADD COLUMN labels jsonb, ADD COLUMN annotations text;
+ALTER TABLE tempconfigs ADD COLUMN labels jsonb, ADD COLUMN annotations text;
+ALTER TABLE tsconfigs ADD COLUMN labels jsonb, ADD COLUMN annotations text;
+ALTER TABLE featureflags ADD COLUMN labels jsonb, ADD COLUMN annotations text;
+
+CREATE INDEX mongodbs_labels_idx ON mongodbs USING gin (labels);
+CREATE INDEX tempconfigs_labels_idx ON tempconfigs USING gin (labels);
+CREATE INDEX tsconfigs_labels_idx ON tsconfigs USING gin (labels);
+CREATE INDEX featureflags_labels_idx ON featureflags USING gin (labels);
+
+-- +migrate Down
+-- the labels and annotations are lost, dropping the columns drops their indexes
+ALTER TABLE mongodbs DROP COLUMN labels, DROP COLUMN annotations;
+ALTER TABLE tempconfigs DROP COLUMN labels, DROP COLUMN annotations;
+ALTER TABLE tsconfigs DROP COLUMN labels, DROP COLUMN annotations;
+ALTER TABLE featureflags DROP COLUMN labels, DROP COLUMN annotations;
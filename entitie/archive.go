@@ -0,0 +1,9 @@
+package entitie
+
+//Archive contains configs of several types and is used during bulk export and import
+type Archive struct {
+	Mongodbs     []Mongodb     `json:"mongodbs,omitempty" yaml:"mongodbs,omitempty" toml:"mongodbs,omitempty"`
+	Tempconfigs  []Tempconfig  `json:"tempconfigs,omitempty" yaml:"tempconfigs,omitempty" toml:"tempconfigs,omitempty"`
+	Tsconfigs    []Tsconfig    `json:"tsconfigs,omitempty" yaml:"tsconfigs,omitempty" toml:"tsconfigs,omitempty"`
+	Featureflags []Featureflag `json:"featureflags,omitempty" yaml:"featureflags,omitempty" toml:"featureflags,omitempty"`
+}
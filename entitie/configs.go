@@ -1,29 +1,60 @@
 // Package entitie contains database entities
 package entitie
 
-//Mongodb is an random config example
+//Mongodb is the config of a connection to a MongoDB deployment stored under the name given by Domain.
+//Host and Port address its first member, the other members of a replica set are listed in Members.
+//The password is not stored, PasswordSecret refers to it in the secret store of the clients. ID is the surrogate key of its row
 type Mongodb struct {
-	Domain  string `json:"domain"`
-	Mongodb bool   `json:"mongodb"`
-	Host    string `json:"host"`
-	Port    string `json:"port"`
+	ID             int64          `json:"-" yaml:"-" toml:"-"`
+	Domain         string         `json:"domain" yaml:"domain" toml:"domain"`
+	Host           string         `json:"host" yaml:"host" toml:"host"`
+	Port           Port           `json:"port" yaml:"port" toml:"port"`
+	Members        MongodbMembers `json:"members,omitempty" yaml:"members,omitempty" toml:"members,omitempty" gorm:"type:text"`
+	ReplicaSet     string         `json:"replicaSet,omitempty" yaml:"replicaSet,omitempty" toml:"replicaSet,omitempty"`
+	Database       string         `json:"database,omitempty" yaml:"database,omitempty" toml:"database,omitempty"`
+	AuthSource     string         `json:"authSource,omitempty" yaml:"authSource,omitempty" toml:"authSource,omitempty"`
+	Username       string         `json:"username,omitempty" yaml:"username,omitempty" toml:"username,omitempty"`
+	PasswordSecret string         `json:"passwordSecret,omitempty" yaml:"passwordSecret,omitempty" toml:"passwordSecret,omitempty"`
+	TLS            bool           `json:"tls,omitempty" yaml:"tls,omitempty" toml:"tls,omitempty"`
+	ReadPreference string         `json:"readPreference,omitempty" yaml:"readPreference,omitempty" toml:"readPreference,omitempty"`
+	Variants       ConfigVariants `json:"variants,omitempty" yaml:"variants,omitempty" toml:"variants,omitempty" gorm:"type:text"`
+	Labels         Labels         `json:"labels,omitempty" yaml:"labels,omitempty" toml:"labels,omitempty" gorm:"type:jsonb"`
+	Annotations    Annotations    `json:"annotations,omitempty" yaml:"annotations,omitempty" toml:"annotations,omitempty" gorm:"type:text"`
+	Revision       int64          `json:"revision" yaml:"revision" toml:"revision"`
 }
 
-//Tsconfig is an random config example
+//Tsconfig is a TypeScript compiler config stored under the name given by Module.
+//Target and SourceMap mirror the same compiler options, Extends refers to a parent tsconfig. ID is the surrogate key of its row
 type Tsconfig struct {
-	Module    string `json:"module"`
-	Target    string `json:"target"`
-	SourceMap bool   `json:"sourceMap"`
-	Excluding int    `json:"excluding"`
+	ID              int64          `json:"-" yaml:"-" toml:"-"`
+	Module          string         `json:"module" yaml:"module" toml:"module"`
+	Target          string         `json:"target" yaml:"target" toml:"target"`
+	SourceMap       bool           `json:"sourceMap" yaml:"sourceMap" toml:"sourceMap"`
+	Excluding       int            `json:"excluding" yaml:"excluding" toml:"excluding"`
+	Extends         string         `json:"extends,omitempty" yaml:"extends,omitempty" toml:"extends,omitempty"`
+	CompilerOptions JSONMap        `json:"compilerOptions,omitempty" yaml:"compilerOptions,omitempty" toml:"compilerOptions,omitempty" gorm:"type:text"`
+	Files           StringList     `json:"files,omitempty" yaml:"files,omitempty" toml:"files,omitempty" gorm:"type:text"`
+	Include         StringList     `json:"include,omitempty" yaml:"include,omitempty" toml:"include,omitempty" gorm:"type:text"`
+	Exclude         StringList     `json:"exclude,omitempty" yaml:"exclude,omitempty" toml:"exclude,omitempty" gorm:"type:text"`
+	Variants        ConfigVariants `json:"variants,omitempty" yaml:"variants,omitempty" toml:"variants,omitempty" gorm:"type:text"`
+	Labels          Labels         `json:"labels,omitempty" yaml:"labels,omitempty" toml:"labels,omitempty" gorm:"type:jsonb"`
+	Annotations     Annotations    `json:"annotations,omitempty" yaml:"annotations,omitempty" toml:"annotations,omitempty" gorm:"type:text"`
+	Revision        int64          `json:"revision" yaml:"revision" toml:"revision"`
 }
 
-//Tempconfig is an random config example
+//Tempconfig is the server config of a LoopBack application, as in its config.json, stored under the name given by RestApiRoot.
+//LegacyExplorer is also read from legasyExplorer, as former versions misspelled it. ID is the surrogate key of its row
 type Tempconfig struct {
-	RestApiRoot    string `json:"restApiRoot"`
-	Host           string `json:"host"`
-	Port           string `json:"port"`
-	Remoting       string `json:"remoting"`
-	LegasyExplorer bool   `json:"legasyExplorer"`
+	ID             int64            `json:"-" yaml:"-" toml:"-"`
+	RestApiRoot    string           `json:"restApiRoot" yaml:"restApiRoot" toml:"restApiRoot"`
+	Host           string           `json:"host" yaml:"host" toml:"host"`
+	Port           Port             `json:"port" yaml:"port" toml:"port"`
+	Remoting       LoopbackRemoting `json:"remoting" yaml:"remoting" toml:"remoting" gorm:"type:text"`
+	LegacyExplorer bool             `json:"legacyExplorer" yaml:"legacyExplorer" toml:"legacyExplorer"`
+	Variants       ConfigVariants   `json:"variants,omitempty" yaml:"variants,omitempty" toml:"variants,omitempty" gorm:"type:text"`
+	Labels         Labels           `json:"labels,omitempty" yaml:"labels,omitempty" toml:"labels,omitempty" gorm:"type:jsonb"`
+	Annotations    Annotations      `json:"annotations,omitempty" yaml:"annotations,omitempty" toml:"annotations,omitempty" gorm:"type:text"`
+	Revision       int64            `json:"revision" yaml:"revision" toml:"revision"`
 }
 
 //ConfigInterface is an interface for all config structures
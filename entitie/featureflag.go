@@ -0,0 +1,86 @@
+package entitie
+
+import "database/sql/driver"
+
+//operators of flag conditions, comparing an attribute of a client with the values of the condition
+const (
+	FlagOperatorIn         = "in"
+	FlagOperatorNotIn      = "notIn"
+	FlagOperatorStartsWith = "startsWith"
+	FlagOperatorEndsWith   = "endsWith"
+	FlagOperatorContains   = "contains"
+)
+
+//FlagOperators are the operators of flag conditions
+var FlagOperators = []string{FlagOperatorIn, FlagOperatorNotIn, FlagOperatorStartsWith, FlagOperatorEndsWith, FlagOperatorContains}
+
+//Featureflag is a feature flag stored under the name given by Key. Variants maps the names of its variants to their JSON values.
+//A disabled flag serves OffVariant. An enabled flag serves the variant of the first rule matching a client,
+//otherwise its Rollout splits clients among variants, or DefaultVariant is served if there is no rollout. ID is the surrogate key of its row
+type Featureflag struct {
+	ID             int64       `json:"-" yaml:"-" toml:"-"`
+	Key            string      `json:"key" yaml:"key" toml:"key"`
+	Enabled        bool        `json:"enabled" yaml:"enabled" toml:"enabled"`
+	Variants       JSONMap     `json:"variants" yaml:"variants" toml:"variants" gorm:"type:text"`
+	OffVariant     string      `json:"offVariant" yaml:"offVariant" toml:"offVariant"`
+	DefaultVariant string      `json:"defaultVariant" yaml:"defaultVariant" toml:"defaultVariant"`
+	Rollout        FlagRollout `json:"rollout,omitempty" yaml:"rollout,omitempty" toml:"rollout,omitempty" gorm:"type:text"`
+	Rules          FlagRules   `json:"rules,omitempty" yaml:"rules,omitempty" toml:"rules,omitempty" gorm:"type:text"`
+	Labels         Labels      `json:"labels,omitempty" yaml:"labels,omitempty" toml:"labels,omitempty" gorm:"type:jsonb"`
+	Annotations    Annotations `json:"annotations,omitempty" yaml:"annotations,omitempty" toml:"annotations,omitempty" gorm:"type:text"`
+	Revision       int64       `json:"revision" yaml:"revision" toml:"revision"`
+}
+
+//FlagRule targets the clients matching all of its Conditions, serving them Variant or splitting them by Rollout
+type FlagRule struct {
+	Conditions []FlagCondition `json:"conditions" yaml:"conditions" toml:"conditions"`
+	Variant    string          `json:"variant,omitempty" yaml:"variant,omitempty" toml:"variant,omitempty"`
+	Rollout    FlagRollout     `json:"rollout,omitempty" yaml:"rollout,omitempty" toml:"rollout,omitempty"`
+}
+
+//FlagCondition compares the attribute of a client named by Attribute with Values, using one of FlagOperators
+type FlagCondition struct {
+	Attribute string     `json:"attribute" yaml:"attribute" toml:"attribute"`
+	Operator  string     `json:"operator" yaml:"operator" toml:"operator"`
+	Values    StringList `json:"values" yaml:"values" toml:"values"`
+}
+
+//FlagWeight is the percentage of clients a rollout serves Variant
+type FlagWeight struct {
+	Variant string `json:"variant" yaml:"variant" toml:"variant"`
+	Percent int    `json:"percent" yaml:"percent" toml:"percent"`
+}
+
+//FlagRollout splits clients among variants by percentages adding up to 100, it is stored in a text column as a JSON array
+type FlagRollout []FlagWeight
+
+//FlagRules are the targeting rules of a flag in the order they are tried, stored in a text column as a JSON array
+type FlagRules []FlagRule
+
+//Value implements driver.Valuer, an empty rollout is stored as NULL
+func (r FlagRollout) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	return marshalColumn([]FlagWeight(r))
+}
+
+//Scan implements sql.Scanner
+func (r *FlagRollout) Scan(src interface{}) error {
+	*r = nil
+	return unmarshalColumn(src, r)
+}
+
+//Value implements driver.Valuer, an empty list is stored as NULL
+func (r FlagRules) Value() (driver.Value, error) {
+	if len(r) == 0 {
+		return nil, nil
+	}
+	return marshalColumn([]FlagRule(r))
+}
+
+//Scan implements sql.Scanner
+func (r *FlagRules) Scan(src interface{}) error {
+	*r = nil
+	return unmarshalColumn(src, r)
+}
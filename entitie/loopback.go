@@ -0,0 +1,130 @@
+package entitie
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+//LoopbackRemoting are the remoting options of a LoopBack server, configuring its REST API, as in the remoting object of its config.json
+type LoopbackRemoting struct {
+	Context      bool                      `json:"context" yaml:"context" toml:"context"`
+	Rest         *LoopbackRest             `json:"rest,omitempty" yaml:"rest,omitempty" toml:"rest,omitempty"`
+	JSON         *LoopbackJSONParser       `json:"json,omitempty" yaml:"json,omitempty" toml:"json,omitempty"`
+	URLEncoded   *LoopbackURLEncodedParser `json:"urlencoded,omitempty" yaml:"urlencoded,omitempty" toml:"urlencoded,omitempty"`
+	CORS         *LoopbackCORS             `json:"cors,omitempty" yaml:"cors,omitempty" toml:"cors,omitempty"`
+	ErrorHandler *LoopbackErrorHandler     `json:"errorHandler,omitempty" yaml:"errorHandler,omitempty" toml:"errorHandler,omitempty"`
+}
+
+//LoopbackRest are the options of the REST adapter. LoopBack defaults missing options differently, so all of them are written
+type LoopbackRest struct {
+	HandleErrors      bool `json:"handleErrors" yaml:"handleErrors" toml:"handleErrors"`
+	NormalizeHttpPath bool `json:"normalizeHttpPath" yaml:"normalizeHttpPath" toml:"normalizeHttpPath"`
+	XML               bool `json:"xml" yaml:"xml" toml:"xml"`
+}
+
+//LoopbackJSONParser are the options of the JSON body parser, Limit is a size like "100kb"
+type LoopbackJSONParser struct {
+	Strict bool   `json:"strict" yaml:"strict" toml:"strict"`
+	Limit  string `json:"limit,omitempty" yaml:"limit,omitempty" toml:"limit,omitempty"`
+}
+
+//LoopbackURLEncodedParser are the options of the URL-encoded body parser, Limit is a size like "100kb"
+type LoopbackURLEncodedParser struct {
+	Extended bool   `json:"extended" yaml:"extended" toml:"extended"`
+	Limit    string `json:"limit,omitempty" yaml:"limit,omitempty" toml:"limit,omitempty"`
+}
+
+//LoopbackCORS are the CORS options of the REST API. Origin is true to allow the origin of each request, an origin or a list of origins.
+//Switched off CORS is written as false in JSON and as disabled in YAML and TOML, which can not hold either a flag or a table
+type LoopbackCORS struct {
+	Disabled    bool        `json:"disabled,omitempty" yaml:"disabled,omitempty" toml:"disabled,omitempty"`
+	Origin      interface{} `json:"origin,omitempty" yaml:"origin,omitempty" toml:"origin,omitempty"`
+	Credentials bool        `json:"credentials,omitempty" yaml:"credentials,omitempty" toml:"credentials,omitempty"`
+	MaxAge      int         `json:"maxAge,omitempty" yaml:"maxAge,omitempty" toml:"maxAge,omitempty"`
+}
+
+//LoopbackErrorHandler are the options of the error handler of the REST API
+type LoopbackErrorHandler struct {
+	DisableStackTrace bool       `json:"disableStackTrace,omitempty" yaml:"disableStackTrace,omitempty" toml:"disableStackTrace,omitempty"`
+	Debug             bool       `json:"debug,omitempty" yaml:"debug,omitempty" toml:"debug,omitempty"`
+	SafeFields        StringList `json:"safeFields,omitempty" yaml:"safeFields,omitempty" toml:"safeFields,omitempty"`
+}
+
+//loopbackCORS has the fields of LoopbackCORS without its methods
+type loopbackCORS LoopbackCORS
+
+//MarshalJSON writes switched off CORS as false
+func (c LoopbackCORS) MarshalJSON() ([]byte, error) {
+	if c.Disabled {
+		return []byte("false"), nil
+	}
+	return json.Marshal(loopbackCORS(c))
+}
+
+//UnmarshalJSON reads CORS options given as an object, or as false to switch CORS off and true to use the defaults
+func (c *LoopbackCORS) UnmarshalJSON(data []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err == nil {
+		*c = LoopbackCORS{Disabled: !enabled}
+		return nil
+	}
+	return json.Unmarshal(data, (*loopbackCORS)(c))
+}
+
+//Value implements driver.Valuer, remoting without options is stored as NULL
+func (r LoopbackRemoting) Value() (driver.Value, error) {
+	if r == (LoopbackRemoting{}) {
+		return nil, nil
+	}
+	return marshalColumn(r)
+}
+
+//Scan implements sql.Scanner
+func (r *LoopbackRemoting) Scan(src interface{}) error {
+	*r = LoopbackRemoting{}
+	return unmarshalColumn(src, r)
+}
+
+//tempconfig has the fields of Tempconfig without its methods
+type tempconfig Tempconfig
+
+//UnmarshalJSON reads a Tempconfig. Unless legacyExplorer is given, it is read from legasyExplorer, as misspelled by former versions
+func (t *Tempconfig) UnmarshalJSON(data []byte) error {
+	fields := struct {
+		*tempconfig
+		LegacyExplorer *bool `json:"legacyExplorer"`
+		LegasyExplorer *bool `json:"legasyExplorer"`
+	}{tempconfig: (*tempconfig)(t)}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	switch {
+	case fields.LegacyExplorer != nil:
+		t.LegacyExplorer = *fields.LegacyExplorer
+	case fields.LegasyExplorer != nil:
+		t.LegacyExplorer = *fields.LegasyExplorer
+	}
+	return nil
+}
+
+//UnmarshalYAML reads a Tempconfig through its JSON form, so the same aliases are accepted
+func (t *Tempconfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	return t.unmarshalDecoded(normalizeYAML(raw))
+}
+
+//UnmarshalTOML reads a Tempconfig through its JSON form, so the same aliases are accepted
+func (t *Tempconfig) UnmarshalTOML(data interface{}) error {
+	return t.unmarshalDecoded(data)
+}
+
+func (t *Tempconfig) unmarshalDecoded(decoded interface{}) error {
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return err
+	}
+	return t.UnmarshalJSON(data)
+}
@@ -0,0 +1,82 @@
+package entitie
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestTempconfigLegacyField(t *testing.T) {
+	for _, data := range []string{`{"legasyExplorer": true}`, `{"legacyExplorer": true}`, `{"legacyExplorer": true, "legasyExplorer": false}`} {
+		var config Tempconfig
+		if assert.NoError(t, json.Unmarshal([]byte(data), &config), data) {
+			assert.True(t, config.LegacyExplorer, data)
+		}
+	}
+	var archive Archive
+	if assert.NoError(t, yaml.Unmarshal([]byte("tempconfigs:\n- restApiRoot: /api\n  port: \"3000\"\n  legasyExplorer: true\n"), &archive)) {
+		assert.Equal(t, []Tempconfig{{RestApiRoot: "/api", Port: 3000, LegacyExplorer: true}}, archive.Tempconfigs)
+	}
+	archive = Archive{}
+	if _, err := toml.Decode("[[tempconfigs]]\nrestApiRoot = \"/api\"\nport = 3000\nlegasyExplorer = true\n", &archive); assert.NoError(t, err) {
+		assert.Equal(t, []Tempconfig{{RestApiRoot: "/api", Port: 3000, LegacyExplorer: true}}, archive.Tempconfigs)
+	}
+}
+
+func TestLoopbackCORS(t *testing.T) {
+	for data, expected := range map[string]LoopbackCORS{
+		`false`: {Disabled: true},
+		`true`:  {},
+		`{"origin": ["https://example.com"], "credentials": true}`: {Origin: []interface{}{"https://example.com"}, Credentials: true},
+	} {
+		var cors LoopbackCORS
+		if assert.NoError(t, json.Unmarshal([]byte(data), &cors), data) {
+			assert.Equal(t, expected, cors, data)
+		}
+	}
+	data, err := json.Marshal(LoopbackRemoting{CORS: &LoopbackCORS{Disabled: true}})
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"context": false, "cors": false}`, string(data))
+	}
+}
+
+func TestTempconfigArchiveFormats(t *testing.T) {
+	archive := Archive{Tempconfigs: []Tempconfig{{RestApiRoot: "/api", Host: "localhost", Port: 3000, Remoting: LoopbackRemoting{
+		Rest:         &LoopbackRest{HandleErrors: true},
+		JSON:         &LoopbackJSONParser{Strict: true, Limit: "1mb"},
+		CORS:         &LoopbackCORS{Disabled: true},
+		ErrorHandler: &LoopbackErrorHandler{SafeFields: StringList{"code"}},
+	}, LegacyExplorer: true, Revision: 2}}}
+
+	data, err := yaml.Marshal(archive)
+	if assert.NoError(t, err) {
+		var decoded Archive
+		if assert.NoError(t, yaml.Unmarshal(data, &decoded)) {
+			assert.Equal(t, archive, decoded)
+		}
+	}
+	var buf bytes.Buffer
+	if assert.NoError(t, toml.NewEncoder(&buf).Encode(archive)) {
+		var decoded Archive
+		if _, err = toml.Decode(buf.String(), &decoded); assert.NoError(t, err) {
+			assert.Equal(t, archive, decoded)
+		}
+	}
+}
+
+func TestLoopbackRemotingColumn(t *testing.T) {
+	value, err := LoopbackRemoting{}.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value, "remoting without options is stored as NULL")
+
+	var remoting LoopbackRemoting
+	if assert.NoError(t, remoting.Scan([]byte(`{"context": true, "cors": false}`))) {
+		assert.Equal(t, LoopbackRemoting{Context: true, CORS: &LoopbackCORS{Disabled: true}}, remoting)
+	}
+	assert.NoError(t, remoting.Scan(nil))
+	assert.Equal(t, LoopbackRemoting{}, remoting)
+}
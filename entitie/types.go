@@ -0,0 +1,286 @@
+package entitie
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//JSONMap is a free-form JSON object stored in a text column
+type JSONMap map[string]interface{}
+
+//StringList is a list of strings stored in a text column as a JSON array
+type StringList []string
+
+//Port is a TCP port stored as an integer. It is written as a number, but also read from strings like "8080",
+//which configs used to hold, so archives and clients of that time keep working
+type Port int
+
+//Labels are key/value pairs describing a config or a client, like its team, region or version, stored as a JSON object.
+//Numbers and booleans are read as their text, so version: 2 needs no quotes
+type Labels map[string]string
+
+//Annotations are free-text notes on a config, like its owner, description or ticket, stored in a text column as a JSON object.
+//Like labels, numbers and booleans are read as their text
+type Annotations map[string]string
+
+//MongodbMember is a member of a MongoDB replica set
+type MongodbMember struct {
+	Host string `json:"host" yaml:"host" toml:"host"`
+	Port Port   `json:"port" yaml:"port" toml:"port"`
+}
+
+//MongodbMembers is a list of replica set members stored in a text column as a JSON array
+type MongodbMembers []MongodbMember
+
+//Value implements driver.Valuer, an empty map is stored as NULL
+func (m JSONMap) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return marshalColumn(map[string]interface{}(m))
+}
+
+//Scan implements sql.Scanner
+func (m *JSONMap) Scan(src interface{}) error {
+	*m = nil
+	return unmarshalColumn(src, m)
+}
+
+//UnmarshalYAML converts the nested maps produced by yaml into JSON compatible ones
+func (m *JSONMap) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	*m = normalizeYAML(raw).(map[string]interface{})
+	return nil
+}
+
+//Value implements driver.Valuer, an empty list is stored as NULL
+func (l StringList) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	return marshalColumn([]string(l))
+}
+
+//Scan implements sql.Scanner
+func (l *StringList) Scan(src interface{}) error {
+	*l = nil
+	return unmarshalColumn(src, l)
+}
+
+//Value implements driver.Valuer, an empty list is stored as NULL
+func (m MongodbMembers) Value() (driver.Value, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	return marshalColumn([]MongodbMember(m))
+}
+
+//Scan implements sql.Scanner
+func (m *MongodbMembers) Scan(src interface{}) error {
+	*m = nil
+	return unmarshalColumn(src, m)
+}
+
+//String returns the decimal port
+func (p Port) String() string {
+	return strconv.Itoa(int(p))
+}
+
+//UnmarshalJSON reads a port given as a number or as a string
+func (p *Port) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return p.UnmarshalText([]byte(text))
+	}
+	var number int
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("port must be a number, got %s", data)
+	}
+	*p = Port(number)
+	return nil
+}
+
+//UnmarshalYAML reads a port given as a number or as a string
+func (p *Port) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err != nil {
+		return err
+	}
+	return p.UnmarshalText([]byte(text))
+}
+
+//UnmarshalText reads a port from its decimal text, an empty text is the zero port. TOML numbers are read this way as well
+func (p *Port) UnmarshalText(text []byte) error {
+	trimmed := strings.TrimSpace(string(text))
+	if trimmed == "" {
+		*p = 0
+		return nil
+	}
+	number, err := strconv.Atoi(trimmed)
+	if err != nil {
+		return fmt.Errorf("port must be a number, got %q", text)
+	}
+	*p = Port(number)
+	return nil
+}
+
+//Value implements driver.Valuer, the zero port is stored as NULL
+func (p Port) Value() (driver.Value, error) {
+	if p == 0 {
+		return nil, nil
+	}
+	return int64(p), nil
+}
+
+//Scan implements sql.Scanner. Text which is not a number is scanned as the zero port,
+//so databases with the text ports of former versions can be read and checked before they are migrated
+func (p *Port) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case nil:
+		*p = 0
+	case int64:
+		*p = Port(value)
+	case []byte:
+		number, _ := strconv.Atoi(strings.TrimSpace(string(value)))
+		*p = Port(number)
+	case string:
+		number, _ := strconv.Atoi(strings.TrimSpace(value))
+		*p = Port(number)
+	default:
+		return fmt.Errorf("can not scan %T into %T", src, p)
+	}
+	return nil
+}
+
+func marshalColumn(v interface{}) (driver.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func unmarshalColumn(src interface{}, v interface{}) error {
+	switch data := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	default:
+		return fmt.Errorf("can not scan %T into %T", src, v)
+	}
+}
+
+func normalizeYAML(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			normalized[fmt.Sprint(key)] = normalizeYAML(item)
+		}
+		return normalized
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = normalizeYAML(item)
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = normalizeYAML(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+//Value implements driver.Valuer, an empty map is stored as NULL
+func (l Labels) Value() (driver.Value, error) {
+	if len(l) == 0 {
+		return nil, nil
+	}
+	return marshalColumn(map[string]string(l))
+}
+
+//Scan implements sql.Scanner
+func (l *Labels) Scan(src interface{}) error {
+	*l = nil
+	return unmarshalColumn(src, l)
+}
+
+//UnmarshalJSON reads labels, scalar values of any type are read as text
+func (l *Labels) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return l.fromDecoded(raw)
+}
+
+//UnmarshalYAML reads labels, scalar values of any type are read as text
+func (l *Labels) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw map[string]interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	return l.fromDecoded(raw)
+}
+
+//UnmarshalTOML reads labels, scalar values of any type are read as text
+func (l *Labels) UnmarshalTOML(data interface{}) error {
+	raw, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("labels must be a table, got %T", data)
+	}
+	return l.fromDecoded(raw)
+}
+
+func (l *Labels) fromDecoded(raw map[string]interface{}) error {
+	if raw == nil {
+		*l = nil
+		return nil
+	}
+	labels := make(Labels, len(raw))
+	for key, value := range raw {
+		switch value.(type) {
+		case map[string]interface{}, map[interface{}]interface{}, []interface{}, nil:
+			return fmt.Errorf("label %s must be a text, got %v", key, value)
+		}
+		labels[key] = fmt.Sprint(value)
+	}
+	*l = labels
+	return nil
+}
+
+//Value implements driver.Valuer, an empty map is stored as NULL
+func (a Annotations) Value() (driver.Value, error) {
+	return Labels(a).Value()
+}
+
+//Scan implements sql.Scanner
+func (a *Annotations) Scan(src interface{}) error {
+	return (*Labels)(a).Scan(src)
+}
+
+//UnmarshalJSON reads annotations, scalar values of any type are read as text
+func (a *Annotations) UnmarshalJSON(data []byte) error {
+	return (*Labels)(a).UnmarshalJSON(data)
+}
+
+//UnmarshalYAML reads annotations, scalar values of any type are read as text
+func (a *Annotations) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	return (*Labels)(a).UnmarshalYAML(unmarshal)
+}
+
+//UnmarshalTOML reads annotations, scalar values of any type are read as text
+func (a *Annotations) UnmarshalTOML(data interface{}) error {
+	return (*Labels)(a).UnmarshalTOML(data)
+}
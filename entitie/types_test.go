@@ -0,0 +1,78 @@
+package entitie
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestPortCompatibility(t *testing.T) {
+	for _, data := range []string{`{"port": 8080}`, `{"port": "8080"}`, `{"port": " 8080 "}`} {
+		var config Tempconfig
+		if assert.NoError(t, json.Unmarshal([]byte(data), &config), data) {
+			assert.Equal(t, Port(8080), config.Port, data)
+		}
+	}
+	var config Tempconfig
+	assert.Error(t, json.Unmarshal([]byte(`{"port": "http"}`), &config))
+	assert.Error(t, json.Unmarshal([]byte(`{"port": true}`), &config))
+
+	data, err := json.Marshal(Tempconfig{Port: 8080})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(data), `"port":8080`, "ports are written as numbers")
+	}
+
+	for _, data := range []string{"port: 27017", `port: "27017"`} {
+		var config Mongodb
+		if assert.NoError(t, yaml.Unmarshal([]byte(data), &config), data) {
+			assert.Equal(t, Port(27017), config.Port, data)
+		}
+	}
+	for _, data := range []string{"port = 27017", `port = "27017"`} {
+		var config Mongodb
+		if _, err := toml.Decode(data, &config); assert.NoError(t, err, data) {
+			assert.Equal(t, Port(27017), config.Port, data)
+		}
+	}
+}
+
+func TestPortColumn(t *testing.T) {
+	value, err := Port(0).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value, "the zero port is stored as NULL")
+	value, err = Port(8080).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(8080), value)
+
+	for src, expected := range map[interface{}]Port{nil: 0, int64(8080): 8080, "8080": 8080, "padded ": 0} {
+		var port Port
+		if assert.NoError(t, port.Scan(src), "%v", src) {
+			assert.Equal(t, expected, port, "%v", src)
+		}
+	}
+	var port Port
+	assert.NoError(t, port.Scan([]byte(" 8080")))
+	assert.Equal(t, Port(8080), port)
+	assert.Error(t, port.Scan(8080.0))
+}
+
+func TestAnnotations(t *testing.T) {
+	var config Mongodb
+	if assert.NoError(t, yaml.Unmarshal([]byte("domain: asia\nlabels: {team: search}\nannotations: {ticket: 118, owner: search-team@example.com}\n"), &config)) {
+		assert.Equal(t, Labels{"team": "search"}, config.Labels)
+		assert.Equal(t, Annotations{"ticket": "118", "owner": "search-team@example.com"}, config.Annotations)
+	}
+	value, err := config.Annotations.Value()
+	if assert.NoError(t, err) {
+		var scanned Annotations
+		if assert.NoError(t, scanned.Scan(value)) {
+			assert.Equal(t, config.Annotations, scanned)
+		}
+	}
+	value, err = Annotations(nil).Value()
+	assert.NoError(t, err)
+	assert.Nil(t, value, "no annotations are stored as NULL")
+}
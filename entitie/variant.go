@@ -0,0 +1,102 @@
+package entitie
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+)
+
+//ConfigVariant is a variant of a config served to clients whose labels include all labels of Match.
+//Config is a JSON merge patch of the fields which differ from the config, null clears a field
+type ConfigVariant struct {
+	Name   string  `json:"name" yaml:"name" toml:"name"`
+	Match  Labels  `json:"match" yaml:"match" toml:"match"`
+	Config JSONMap `json:"config" yaml:"config" toml:"config"`
+}
+
+//ConfigVariants are the variants of a config, stored in a text column as a JSON array
+type ConfigVariants []ConfigVariant
+
+//Value implements driver.Valuer, an empty list is stored as NULL
+func (v ConfigVariants) Value() (driver.Value, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	return marshalColumn([]ConfigVariant(v))
+}
+
+//Scan implements sql.Scanner
+func (v *ConfigVariants) Scan(src interface{}) error {
+	*v = nil
+	return unmarshalColumn(src, v)
+}
+
+//Matches reports whether labels include all labels of Match, a variant without labels to match matches no client
+func (v *ConfigVariant) Matches(labels map[string]string) bool {
+	if len(v.Match) == 0 {
+		return false
+	}
+	for key, value := range v.Match {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+//Select returns the most specific variant matching labels, which is the one matching the most labels,
+//or the first one listed of those matching as many. It returns nil when no variant matches
+func (v ConfigVariants) Select(labels map[string]string) *ConfigVariant {
+	var selected *ConfigVariant
+	for i := range v {
+		if v[i].Matches(labels) && (selected == nil || len(v[i].Match) > len(selected.Match)) {
+			selected = &v[i]
+		}
+	}
+	return selected
+}
+
+//ApplyVariant returns a new config of the same type as config, which has to be a pointer to a config struct, with the config
+//of variant merged into it and without variants. A nil variant returns the config itself without variants
+func ApplyVariant(config ConfigInterface, variant *ConfigVariant) (ConfigInterface, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]interface{}
+	if err = json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	delete(document, "variants")
+	if variant != nil {
+		document = MergePatch(document, map[string]interface{}(variant.Config)).(map[string]interface{})
+	}
+	if data, err = json.Marshal(document); err != nil {
+		return nil, err
+	}
+	resolved := reflect.New(reflect.TypeOf(config).Elem()).Interface()
+	if err = json.Unmarshal(data, resolved); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+//MergePatch applies an RFC 7396 merge patch to target: objects are merged recursively, null removes a member and any other value replaces the target
+func MergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = MergePatch(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
@@ -0,0 +1,95 @@
+package entitie
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestMergePatch(t *testing.T) {
+	target := map[string]interface{}{"a": "b", "c": map[string]interface{}{"d": "e", "f": "g"}}
+	patch := map[string]interface{}{"a": "z", "c": map[string]interface{}{"f": nil}, "h": []interface{}{"i"}}
+	assert.Equal(t, map[string]interface{}{"a": "z", "c": map[string]interface{}{"d": "e"}, "h": []interface{}{"i"}}, MergePatch(target, patch))
+	assert.Equal(t, map[string]interface{}{"a": "b"}, MergePatch("notAnObject", map[string]interface{}{"a": "b", "c": nil}))
+	assert.Equal(t, "value", MergePatch(target, "value"))
+}
+
+func TestSelectVariant(t *testing.T) {
+	variants := ConfigVariants{
+		{Name: "eu", Match: Labels{"region": "eu"}},
+		{Name: "eu-v2", Match: Labels{"region": "eu", "version": "2"}},
+		{Name: "v2", Match: Labels{"version": "2"}},
+		{Name: "everyone"},
+	}
+	for _, test := range []struct {
+		labels   map[string]string
+		expected string
+	}{
+		{map[string]string{"region": "eu"}, "eu"},
+		{map[string]string{"region": "eu", "version": "2"}, "eu-v2"},
+		{map[string]string{"region": "us", "version": "2"}, "v2"},
+		{map[string]string{"region": "eu", "host": "db-1"}, "eu"},
+		{map[string]string{"region": "EU", "version": "2.0"}, ""},
+		{map[string]string{"region": "us"}, ""},
+		{nil, ""},
+	} {
+		selected := variants.Select(test.labels)
+		if test.expected == "" {
+			assert.Nil(t, selected, "%v", test.labels)
+		} else if assert.NotNil(t, selected, "%v", test.labels) {
+			assert.Equal(t, test.expected, selected.Name, "%v", test.labels)
+		}
+	}
+
+	tie := ConfigVariants{{Name: "first", Match: Labels{"region": "eu"}}, {Name: "second", Match: Labels{"host": "db-1"}}}
+	assert.Equal(t, "first", tie.Select(map[string]string{"region": "eu", "host": "db-1"}).Name, "of equally specific variants the first one is selected")
+}
+
+func TestApplyVariant(t *testing.T) {
+	config := &Mongodb{Domain: "orders", Host: "mongo-0", Port: 27017, ReplicaSet: "rs0", Revision: 3,
+		Variants: ConfigVariants{{Name: "eu", Match: Labels{"region": "eu"}, Config: JSONMap{"host": "mongo-eu", "replicaSet": nil}}}}
+	resolved, err := ApplyVariant(config, &config.Variants[0])
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Mongodb{Domain: "orders", Host: "mongo-eu", Port: 27017, Revision: 3}, resolved)
+	}
+	resolved, err = ApplyVariant(config, nil)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &Mongodb{Domain: "orders", Host: "mongo-0", Port: 27017, ReplicaSet: "rs0", Revision: 3}, resolved)
+	}
+	assert.Len(t, config.Variants, 1, "the config itself is left unchanged")
+
+	_, err = ApplyVariant(config, &ConfigVariant{Config: JSONMap{"port": "http"}})
+	assert.Error(t, err)
+}
+
+func TestLabelsDecoding(t *testing.T) {
+	expected := ConfigVariant{Name: "v2", Match: Labels{"version": "2", "canary": "true", "region": "eu"}, Config: JSONMap{"host": "mongo-v2"}}
+
+	var fromYAML ConfigVariant
+	if assert.NoError(t, yaml.Unmarshal([]byte("name: v2\nmatch:\n  version: 2\n  canary: true\n  region: eu\nconfig:\n  host: mongo-v2\n"), &fromYAML)) {
+		assert.Equal(t, expected, fromYAML)
+	}
+	var fromTOML ConfigVariant
+	if _, err := toml.Decode("name = \"v2\"\n[match]\nversion = 2\ncanary = true\nregion = \"eu\"\n[config]\nhost = \"mongo-v2\"\n", &fromTOML); assert.NoError(t, err) {
+		assert.Equal(t, expected, fromTOML)
+	}
+	var invalid ConfigVariant
+	assert.Error(t, yaml.Unmarshal([]byte("match:\n  region: [eu]\n"), &invalid))
+
+	value, err := ConfigVariants{expected}.Value()
+	if assert.NoError(t, err) {
+		var scanned ConfigVariants
+		if assert.NoError(t, scanned.Scan(value)) {
+			assert.Equal(t, ConfigVariants{expected}, scanned)
+		}
+	}
+}
+
+func TestTempconfigVariantsFromTOML(t *testing.T) {
+	var config Tempconfig
+	if _, err := toml.Decode("restApiRoot = \"/api\"\n[[variants]]\nname = \"v2\"\n[variants.match]\nversion = 2\n[variants.config]\nport = 3001\n", &config); assert.NoError(t, err) {
+		assert.Equal(t, ConfigVariants{{Name: "v2", Match: Labels{"version": "2"}, Config: JSONMap{"port": 3001.0}}}, config.Variants)
+	}
+}
@@ -0,0 +1,132 @@
+// Package flags evaluates feature flags for a client, the result depends only on the flag and the client
+package flags
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"slices"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+)
+
+//reasons why a variant is served
+const (
+	//ReasonDisabled is given when the flag is switched off and serves its off variant
+	ReasonDisabled = "disabled"
+	//ReasonTargetingMatch is given when a rule matches the client
+	ReasonTargetingMatch = "targeting_match"
+	//ReasonSplit is given when a rollout chose the variant by the targeting key
+	ReasonSplit = "split"
+	//ReasonDefault is given when the flag serves its default variant
+	ReasonDefault = "default"
+	//ReasonError is given when the flag can not be evaluated
+	ReasonError = "error"
+)
+
+//TargetingKeyAttribute names the targeting key in conditions of rules
+const TargetingKeyAttribute = "targetingKey"
+
+//buckets is the number of buckets clients are hashed into. Percentages are integers, so rollouts are split with a precision of 1%, 100 buckets each
+const buckets = 10000
+
+//Context describes the client a flag is evaluated for. TargetingKey identifies the client, e.g. a user id, and keeps its rollout variant stable
+type Context struct {
+	TargetingKey string
+	Attributes   map[string]string
+}
+
+//Evaluation is the variant of a flag served to a client, with its value and the reason it is served
+type Evaluation struct {
+	Variant string
+	Value   interface{}
+	Reason  string
+}
+
+//Evaluate returns the variant of flag served to the client described by ctx. A disabled flag serves its off variant.
+//An enabled flag serves the variant of the first rule whose conditions all match, otherwise it splits clients by its rollout
+//or serves its default variant. A rollout needs a targeting key, without one the default variant is served
+func Evaluate(flag *entitie.Featureflag, ctx Context) Evaluation {
+	if !flag.Enabled {
+		return evaluation(flag, flag.OffVariant, ReasonDisabled)
+	}
+	for _, rule := range flag.Rules {
+		if !matches(rule.Conditions, ctx) {
+			continue
+		}
+		if rule.Variant != "" {
+			return evaluation(flag, rule.Variant, ReasonTargetingMatch)
+		}
+		if variant, ok := split(flag.Key, rule.Rollout, ctx.TargetingKey); ok {
+			return evaluation(flag, variant, ReasonTargetingMatch)
+		}
+		return evaluation(flag, flag.DefaultVariant, ReasonDefault)
+	}
+	if variant, ok := split(flag.Key, flag.Rollout, ctx.TargetingKey); ok {
+		return evaluation(flag, variant, ReasonSplit)
+	}
+	return evaluation(flag, flag.DefaultVariant, ReasonDefault)
+}
+
+//Bucket returns the bucket from 0 to 9999 of a client in the rollouts of a flag. It is the first 8 bytes of the SHA-256 hash
+//of the flag key, a slash and the targeting key, read as a big endian number, modulo 10000
+func Bucket(flagKey, targetingKey string) int {
+	sum := sha256.Sum256([]byte(flagKey + "/" + targetingKey))
+	return int(binary.BigEndian.Uint64(sum[:8]) % buckets)
+}
+
+//evaluation serves variant, a variant which is not one of the variants of flag is an error
+func evaluation(flag *entitie.Featureflag, variant, reason string) Evaluation {
+	value, ok := flag.Variants[variant]
+	if !ok {
+		return Evaluation{Variant: variant, Reason: ReasonError}
+	}
+	return Evaluation{Variant: variant, Value: value, Reason: reason}
+}
+
+//split returns the variant of rollout for the bucket of targetingKey, buckets are given to variants in the order of the rollout
+func split(flagKey string, rollout entitie.FlagRollout, targetingKey string) (string, bool) {
+	if len(rollout) == 0 || targetingKey == "" {
+		return "", false
+	}
+	bucket := Bucket(flagKey, targetingKey)
+	upper := 0
+	for _, weight := range rollout {
+		upper += weight.Percent * buckets / 100
+		if bucket < upper {
+			return weight.Variant, true
+		}
+	}
+	return "", false
+}
+
+//matches reports whether the client matches all conditions, a missing attribute matches no condition
+func matches(conditions []entitie.FlagCondition, ctx Context) bool {
+	for _, condition := range conditions {
+		value, ok := ctx.Attributes[condition.Attribute]
+		if condition.Attribute == TargetingKeyAttribute {
+			value, ok = ctx.TargetingKey, ctx.TargetingKey != ""
+		}
+		if !ok || !match(condition, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func match(condition entitie.FlagCondition, value string) bool {
+	switch condition.Operator {
+	case entitie.FlagOperatorIn:
+		return slices.Contains(condition.Values, value)
+	case entitie.FlagOperatorNotIn:
+		return !slices.Contains(condition.Values, value)
+	case entitie.FlagOperatorStartsWith:
+		return slices.ContainsFunc(condition.Values, func(prefix string) bool { return strings.HasPrefix(value, prefix) })
+	case entitie.FlagOperatorEndsWith:
+		return slices.ContainsFunc(condition.Values, func(suffix string) bool { return strings.HasSuffix(value, suffix) })
+	case entitie.FlagOperatorContains:
+		return slices.ContainsFunc(condition.Values, func(part string) bool { return strings.Contains(value, part) })
+	default:
+		return false
+	}
+}
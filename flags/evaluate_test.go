@@ -0,0 +1,103 @@
+package flags
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlag() *entitie.Featureflag {
+	return &entitie.Featureflag{
+		Key:            "new-checkout",
+		Enabled:        true,
+		Variants:       entitie.JSONMap{"on": true, "off": false, "beta": "v2"},
+		OffVariant:     "off",
+		DefaultVariant: "off",
+		Rollout:        entitie.FlagRollout{{Variant: "on", Percent: 50}, {Variant: "off", Percent: 50}},
+		Rules: entitie.FlagRules{
+			{Conditions: []entitie.FlagCondition{{Attribute: "email", Operator: entitie.FlagOperatorEndsWith, Values: entitie.StringList{"@example.com"}}}, Variant: "beta"},
+			{Conditions: []entitie.FlagCondition{
+				{Attribute: "country", Operator: entitie.FlagOperatorIn, Values: entitie.StringList{"UA", "PL"}},
+				{Attribute: "plan", Operator: entitie.FlagOperatorNotIn, Values: entitie.StringList{"free"}},
+			}, Rollout: entitie.FlagRollout{{Variant: "beta", Percent: 100}}},
+		},
+	}
+}
+
+func TestBucket(t *testing.T) {
+	assert.Equal(t, 5337, Bucket("new-checkout", "user-1"))
+	assert.Equal(t, 7620, Bucket("new-checkout", "user-2"))
+	assert.Equal(t, 3633, Bucket("new-checkout", "user-3"))
+	assert.Equal(t, Bucket("new-checkout", "user-1"), Bucket("new-checkout", "user-1"), "buckets are stable")
+}
+
+func TestEvaluate(t *testing.T) {
+	for name, test := range map[string]struct {
+		change   func(flag *entitie.Featureflag)
+		ctx      Context
+		expected Evaluation
+	}{
+		"disabled": {
+			change:   func(flag *entitie.Featureflag) { flag.Enabled = false },
+			ctx:      Context{TargetingKey: "user-3", Attributes: map[string]string{"email": "ann@example.com"}},
+			expected: Evaluation{Variant: "off", Value: false, Reason: ReasonDisabled},
+		},
+		"first matching rule": {
+			ctx:      Context{TargetingKey: "user-3", Attributes: map[string]string{"email": "ann@example.com", "country": "UA"}},
+			expected: Evaluation{Variant: "beta", Value: "v2", Reason: ReasonTargetingMatch},
+		},
+		"rule rollout": {
+			ctx:      Context{TargetingKey: "user-2", Attributes: map[string]string{"country": "PL", "plan": "pro"}},
+			expected: Evaluation{Variant: "beta", Value: "v2", Reason: ReasonTargetingMatch},
+		},
+		"all conditions must match": {
+			ctx:      Context{TargetingKey: "user-3", Attributes: map[string]string{"country": "PL", "plan": "free"}},
+			expected: Evaluation{Variant: "on", Value: true, Reason: ReasonSplit},
+		},
+		"missing attribute matches no condition": {
+			ctx:      Context{TargetingKey: "user-2", Attributes: map[string]string{"country": "PL"}},
+			expected: Evaluation{Variant: "off", Value: false, Reason: ReasonSplit},
+		},
+		"targeting key attribute": {
+			change: func(flag *entitie.Featureflag) {
+				flag.Rules[0].Conditions[0] = entitie.FlagCondition{Attribute: TargetingKeyAttribute, Operator: entitie.FlagOperatorStartsWith, Values: entitie.StringList{"admin-"}}
+			},
+			ctx:      Context{TargetingKey: "admin-1"},
+			expected: Evaluation{Variant: "beta", Value: "v2", Reason: ReasonTargetingMatch},
+		},
+		"rollout without targeting key": {
+			expected: Evaluation{Variant: "off", Value: false, Reason: ReasonDefault},
+		},
+		"no rollout": {
+			change:   func(flag *entitie.Featureflag) { flag.Rollout, flag.DefaultVariant = nil, "on" },
+			ctx:      Context{TargetingKey: "user-2"},
+			expected: Evaluation{Variant: "on", Value: true, Reason: ReasonDefault},
+		},
+		"unknown variant": {
+			change:   func(flag *entitie.Featureflag) { flag.DefaultVariant = "maybe" },
+			expected: Evaluation{Variant: "maybe", Reason: ReasonError},
+		},
+	} {
+		flag := newFlag()
+		if test.change != nil {
+			test.change(flag)
+		}
+		assert.Equal(t, test.expected, Evaluate(flag, test.ctx), name)
+	}
+}
+
+func TestEvaluateSplit(t *testing.T) {
+	flag := newFlag()
+	flag.Rules = nil
+	flag.Rollout = entitie.FlagRollout{{Variant: "on", Percent: 20}, {Variant: "off", Percent: 80}}
+	served := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		evaluation := Evaluate(flag, Context{TargetingKey: fmt.Sprintf("user-%d", i)})
+		served[evaluation.Variant]++
+		assert.Equal(t, evaluation, Evaluate(flag, Context{TargetingKey: fmt.Sprintf("user-%d", i)}), "evaluation is deterministic")
+	}
+	assert.InDelta(t, 2000, served["on"], 200)
+	assert.InDelta(t, 8000, served["off"], 200)
+}
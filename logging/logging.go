@@ -0,0 +1,82 @@
+//Package logging configures structured JSON logging with a level changeable at runtime. Request IDs carried by contexts are added to every log line
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+)
+
+var level = new(slog.LevelVar)
+
+//Init makes JSON lines written to w the output of the default slog logger and of the standard log package, the level is info until it is changed by SetLevel
+func Init(w io.Writer) {
+	slog.SetDefault(slog.New(&contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})}))
+}
+
+//SetLevel changes the level of logged messages to one of debug, info, warn and error and returns the previous level
+func SetLevel(name string) (string, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return "", err
+	}
+	previous := Level()
+	level.Set(l)
+	return previous, nil
+}
+
+//Level returns the name of the current level
+func Level() string {
+	return level.Level().String()
+}
+
+//Fatal logs an error and exits
+func Fatal(msg string, args ...interface{}) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type requestIDContextKey struct{}
+
+//WithRequestID returns a context whose log lines carry the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+//RequestID returns the request ID of ctx, or an empty string if there is none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+//NewRequestID generates a random request ID
+func NewRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		slog.Error("error during generating request ID", "error", err)
+	}
+	return hex.EncodeToString(id)
+}
+
+//contextHandler adds the request ID of the context to log records
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
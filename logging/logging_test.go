@@ -0,0 +1,57 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	Init(&buf)
+	ctx := WithRequestID(context.Background(), "testID")
+
+	slog.InfoContext(ctx, "test message", "config", "testName")
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "test message", line["msg"])
+	assert.Equal(t, "testName", line["config"])
+	assert.Equal(t, "testID", line["request_id"])
+
+	buf.Reset()
+	slog.With("component", "test").InfoContext(ctx, "with attributes")
+	assert.Contains(t, buf.String(), `"request_id":"testID"`, "loggers with attributes keep adding request IDs")
+
+	previous, err := SetLevel("warn")
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Equal(t, "INFO", previous)
+	assert.Equal(t, "WARN", Level())
+	buf.Reset()
+	slog.InfoContext(ctx, "hidden")
+	log.Print("hidden too")
+	assert.Empty(t, buf.String())
+	slog.WarnContext(ctx, "shown")
+	assert.Contains(t, buf.String(), "shown")
+
+	_, err = SetLevel("verbose")
+	assert.Error(t, err)
+	assert.Equal(t, "WARN", Level())
+	SetLevel("info")
+}
+
+func TestRequestID(t *testing.T) {
+	assert.Equal(t, "", RequestID(context.Background()))
+	first, second := NewRequestID(), NewRequestID()
+	assert.Len(t, first, 32)
+	assert.NotEqual(t, first, second)
+}
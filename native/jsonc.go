@@ -0,0 +1,65 @@
+// Package native converts configs from and to the file formats of the tools they configure
+package native
+
+import "bytes"
+
+//stripJSONC turns JSON with comments, as accepted by tsconfig.json, into plain JSON.
+//Line and block comments outside of strings are removed, trailing commas before a closing bracket are dropped
+func stripJSONC(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if inString {
+			out = append(out, c)
+			switch c {
+			case '\\':
+				if i+1 < len(data) {
+					i++
+					out = append(out, data[i])
+				}
+			case '"':
+				inString = false
+			}
+			continue
+		}
+		switch {
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			if i < len(data) {
+				out = append(out, '\n')
+			}
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+			out = append(out, ' ')
+		case c == ']' || c == '}':
+			out = dropTrailingComma(out)
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func dropTrailingComma(out []byte) []byte {
+	for i := len(out) - 1; i >= 0; i-- {
+		switch out[i] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case ',':
+			return append(out[:i], out[i+1:]...)
+		}
+		break
+	}
+	return out
+}
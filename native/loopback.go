@@ -0,0 +1,45 @@
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+)
+
+//loopbackConfigFile is the layout of the config.json file of a LoopBack server
+type loopbackConfigFile struct {
+	RestApiRoot    string                   `json:"restApiRoot"`
+	Host           string                   `json:"host"`
+	Port           entitie.Port             `json:"port"`
+	Remoting       entitie.LoopbackRemoting `json:"remoting"`
+	LegacyExplorer bool                     `json:"legacyExplorer"`
+}
+
+//ParseLoopbackConfig parses the config.json file of a LoopBack server. Its restApiRoot names the config,
+//name is used when the file has none and has to match it otherwise
+func ParseLoopbackConfig(name string, data []byte) (*entitie.Tempconfig, error) {
+	config := new(entitie.Tempconfig)
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("invalid config.json: %v", err)
+	}
+	config.Revision = 0
+	switch {
+	case config.RestApiRoot == "":
+		config.RestApiRoot = name
+	case name != "" && name != config.RestApiRoot:
+		return nil, fmt.Errorf("config.json has restApiRoot %q instead of %q", config.RestApiRoot, name)
+	}
+	return config, nil
+}
+
+//RenderLoopbackConfig returns config as the config.json file of a LoopBack server
+func RenderLoopbackConfig(config *entitie.Tempconfig) ([]byte, error) {
+	return json.MarshalIndent(loopbackConfigFile{
+		RestApiRoot:    config.RestApiRoot,
+		Host:           config.Host,
+		Port:           config.Port,
+		Remoting:       config.Remoting,
+		LegacyExplorer: config.LegacyExplorer,
+	}, "", "  ")
+}
@@ -0,0 +1,54 @@
+package native
+
+import (
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLoopbackConfig(t *testing.T) {
+	data := []byte(`{
+  "restApiRoot": "/api",
+  "host": "0.0.0.0",
+  "port": 3000,
+  "remoting": {
+    "context": false,
+    "rest": {"handleErrors": false, "normalizeHttpPath": false, "xml": false},
+    "json": {"strict": false, "limit": "100kb"},
+    "urlencoded": {"extended": true, "limit": "100kb"},
+    "cors": false
+  },
+  "legacyExplorer": false
+}`)
+	config, err := ParseLoopbackConfig("", data)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Equal(t, &entitie.Tempconfig{
+		RestApiRoot: "/api",
+		Host:        "0.0.0.0",
+		Port:        3000,
+		Remoting: entitie.LoopbackRemoting{
+			Rest:       &entitie.LoopbackRest{},
+			JSON:       &entitie.LoopbackJSONParser{Limit: "100kb"},
+			URLEncoded: &entitie.LoopbackURLEncodedParser{Extended: true, Limit: "100kb"},
+			CORS:       &entitie.LoopbackCORS{Disabled: true},
+		},
+	}, config)
+
+	rendered, err := RenderLoopbackConfig(config)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, string(data), string(rendered), "config.json is rendered as it was parsed")
+	}
+
+	config, err = ParseLoopbackConfig("/api", []byte(`{"port": "3000", "legasyExplorer": true, "revision": 4}`))
+	if assert.NoError(t, err) {
+		assert.Equal(t, &entitie.Tempconfig{RestApiRoot: "/api", Port: 3000, LegacyExplorer: true}, config)
+	}
+
+	_, err = ParseLoopbackConfig("/other", data)
+	assert.Error(t, err)
+	_, err = ParseLoopbackConfig("/api", []byte(`{"remoting": "rem"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package native
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+)
+
+//RenderMongodbURI returns the mongodb:// connection string of a MongoDB config.
+//The password is never stored, so the string holds only the username and clients add the password referred to by PasswordSecret
+func RenderMongodbURI(config *entitie.Mongodb) []byte {
+	hosts := []string{net.JoinHostPort(config.Host, config.Port.String())}
+	for _, member := range config.Members {
+		hosts = append(hosts, net.JoinHostPort(member.Host, member.Port.String()))
+	}
+	options := url.Values{}
+	if config.ReplicaSet != "" {
+		options.Set("replicaSet", config.ReplicaSet)
+	}
+	if config.AuthSource != "" {
+		options.Set("authSource", config.AuthSource)
+	}
+	if config.TLS {
+		options.Set("tls", "true")
+	}
+	if config.ReadPreference != "" {
+		options.Set("readPreference", config.ReadPreference)
+	}
+
+	var uri strings.Builder
+	uri.WriteString("mongodb://")
+	if config.Username != "" {
+		uri.WriteString(url.User(config.Username).String() + "@")
+	}
+	uri.WriteString(strings.Join(hosts, ",") + "/" + url.PathEscape(config.Database))
+	if len(options) > 0 {
+		uri.WriteString("?" + options.Encode())
+	}
+	return []byte(uri.String())
+}
@@ -0,0 +1,31 @@
+package native
+
+import (
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMongodbURI(t *testing.T) {
+	assert.Equal(t, "mongodb://localhost:27017/", string(RenderMongodbURI(&entitie.Mongodb{Domain: "local", Host: "localhost", Port: 27017})))
+
+	config := &entitie.Mongodb{
+		Domain: "orders",
+		Host:   "mongo-0.orders.svc",
+		Port:   27017,
+		Members: entitie.MongodbMembers{
+			{Host: "mongo-1.orders.svc", Port: 27017},
+			{Host: "2001:db8::1", Port: 27018},
+		},
+		ReplicaSet:     "rs0",
+		Database:       "orders",
+		AuthSource:     "admin",
+		Username:       "orders@shop",
+		PasswordSecret: "orders-mongodb/password",
+		TLS:            true,
+		ReadPreference: "secondaryPreferred",
+	}
+	assert.Equal(t, "mongodb://orders%40shop@mongo-0.orders.svc:27017,mongo-1.orders.svc:27017,[2001:db8::1]:27018/orders"+
+		"?authSource=admin&readPreference=secondaryPreferred&replicaSet=rs0&tls=true", string(RenderMongodbURI(config)))
+}
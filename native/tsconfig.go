@@ -0,0 +1,145 @@
+package native
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+)
+
+//maxExtendsDepth limits the length of an extends chain
+const maxExtendsDepth = 16
+
+//tsconfigFile is the layout of a tsconfig.json file
+type tsconfigFile struct {
+	Extends         string                 `json:"extends,omitempty"`
+	CompilerOptions map[string]interface{} `json:"compilerOptions,omitempty"`
+	Files           []string               `json:"files,omitempty"`
+	Include         []string               `json:"include,omitempty"`
+	Exclude         []string               `json:"exclude,omitempty"`
+}
+
+//ParseTsconfig parses a tsconfig.json file, comments and trailing commas are allowed
+func ParseTsconfig(name string, data []byte) (*entitie.Tsconfig, error) {
+	if name == "" {
+		return nil, fmt.Errorf("tsconfig name is empty")
+	}
+	var file tsconfigFile
+	if err := json.Unmarshal(stripJSONC(data), &file); err != nil {
+		return nil, fmt.Errorf("invalid tsconfig.json: %v", err)
+	}
+	config := &entitie.Tsconfig{
+		Module:          name,
+		Extends:         file.Extends,
+		CompilerOptions: file.CompilerOptions,
+		Files:           file.Files,
+		Include:         file.Include,
+		Exclude:         file.Exclude,
+	}
+	mirrorCompilerOptions(config)
+	return config, nil
+}
+
+//RenderTsconfig returns config as a tsconfig.json file
+func RenderTsconfig(config *entitie.Tsconfig) ([]byte, error) {
+	file := tsconfigFile{
+		Extends:         config.Extends,
+		CompilerOptions: make(map[string]interface{}, len(config.CompilerOptions)+2),
+		Files:           config.Files,
+		Include:         config.Include,
+		Exclude:         config.Exclude,
+	}
+	if config.Target != "" {
+		file.CompilerOptions["target"] = config.Target
+	}
+	if config.SourceMap {
+		file.CompilerOptions["sourceMap"] = true
+	}
+	for option, value := range config.CompilerOptions {
+		file.CompilerOptions[option] = value
+	}
+	return json.MarshalIndent(file, "", "  ")
+}
+
+//ResolveTsconfig merges config with the chain of stored tsconfigs it extends, the way tsc does.
+//Compiler options of the config override inherited ones, files, include and exclude are inherited only if the config has none.
+//Extends values like "base" or "./base.json" refer to stored tsconfigs and are loaded with find, others (e.g. npm packages) are kept as they are
+func ResolveTsconfig(config *entitie.Tsconfig, find func(name string) (*entitie.Tsconfig, error)) (*entitie.Tsconfig, error) {
+	chain := []*entitie.Tsconfig{config}
+	visited := map[string]bool{config.Module: true}
+	for current := config; ; {
+		parentName, stored := storedParentName(current.Extends)
+		if !stored {
+			break
+		}
+		if visited[parentName] {
+			return nil, fmt.Errorf("tsconfig %q has a cyclic extends chain", config.Module)
+		}
+		if len(chain) > maxExtendsDepth {
+			return nil, fmt.Errorf("tsconfig %q extends chain is longer than %d", config.Module, maxExtendsDepth)
+		}
+		parent, err := find(parentName)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve extends %q of tsconfig %q: %v", current.Extends, current.Module, err)
+		}
+		visited[parentName] = true
+		chain = append(chain, parent)
+		current = parent
+	}
+
+	root := chain[len(chain)-1]
+	resolved := &entitie.Tsconfig{
+		Module:          config.Module,
+		Excluding:       config.Excluding,
+		CompilerOptions: entitie.JSONMap{},
+	}
+	if _, stored := storedParentName(root.Extends); !stored {
+		resolved.Extends = root.Extends
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		current := chain[i]
+		for option, value := range current.CompilerOptions {
+			resolved.CompilerOptions[option] = value
+		}
+		if current.Target != "" {
+			resolved.CompilerOptions["target"] = current.Target
+		}
+		if current.SourceMap {
+			resolved.CompilerOptions["sourceMap"] = true
+		}
+		if len(current.Files) > 0 {
+			resolved.Files = current.Files
+		}
+		if len(current.Include) > 0 {
+			resolved.Include = current.Include
+		}
+		if len(current.Exclude) > 0 {
+			resolved.Exclude = current.Exclude
+		}
+	}
+	mirrorCompilerOptions(resolved)
+	return resolved, nil
+}
+
+//storedParentName returns the name of a stored tsconfig an extends value refers to
+func storedParentName(extends string) (string, bool) {
+	if extends == "" || strings.HasPrefix(extends, "@") {
+		return "", false
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(extends, "./"), ".json")
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	return name, true
+}
+
+//mirrorCompilerOptions copies target and sourceMap compiler options to the fields of the same name
+func mirrorCompilerOptions(config *entitie.Tsconfig) {
+	if target, ok := config.CompilerOptions["target"].(string); ok {
+		config.Target = target
+	}
+	if sourceMap, ok := config.CompilerOptions["sourceMap"].(bool); ok {
+		config.SourceMap = sourceMap
+	}
+}
@@ -0,0 +1,88 @@
+package native
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTsconfigJSON = `{
+	// base options are shared by all packages
+	"extends": "./base.json",
+	"compilerOptions": {
+		"target": "es2017", /* overrides base */
+		"outDir": "dist",
+		"paths": {"@app/*": ["src/app/*"]},
+	},
+	"include": ["src/**/*", "url//not-a-comment"],
+}`
+
+func TestParseTsconfig(t *testing.T) {
+	config, err := ParseTsconfig("app", []byte(testTsconfigJSON))
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, "app", config.Module)
+	assert.Equal(t, "es2017", config.Target)
+	assert.Equal(t, "./base.json", config.Extends)
+	assert.Equal(t, "dist", config.CompilerOptions["outDir"])
+	assert.Equal(t, entitie.StringList{"src/**/*", "url//not-a-comment"}, config.Include)
+
+	_, err = ParseTsconfig("", []byte(testTsconfigJSON))
+	assert.Error(t, err)
+	_, err = ParseTsconfig("app", []byte(`{"compilerOptions": }`))
+	assert.Error(t, err)
+}
+
+func TestResolveTsconfig(t *testing.T) {
+	stored := map[string]*entitie.Tsconfig{
+		"base": {Module: "base", Extends: "@tsconfig/node10/tsconfig.json", Target: "es5", SourceMap: true,
+			CompilerOptions: entitie.JSONMap{"target": "es5", "sourceMap": true, "strict": true}, Exclude: entitie.StringList{"node_modules"}},
+		"loopA": {Module: "loopA", Extends: "loopB"},
+		"loopB": {Module: "loopB", Extends: "loopA"},
+	}
+	find := func(name string) (*entitie.Tsconfig, error) {
+		if config, ok := stored[name]; ok {
+			return config, nil
+		}
+		return nil, errors.New("record not found")
+	}
+	config, err := ParseTsconfig("app", []byte(testTsconfigJSON))
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+
+	resolved, err := ResolveTsconfig(config, find)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, "@tsconfig/node10/tsconfig.json", resolved.Extends)
+	assert.Equal(t, "es2017", resolved.Target)
+	assert.True(t, resolved.SourceMap)
+	assert.Equal(t, true, resolved.CompilerOptions["strict"])
+	assert.Equal(t, config.Include, resolved.Include)
+	assert.Equal(t, entitie.StringList{"node_modules"}, resolved.Exclude)
+
+	_, err = ResolveTsconfig(stored["loopA"], find)
+	assert.Error(t, err)
+	_, err = ResolveTsconfig(&entitie.Tsconfig{Module: "orphan", Extends: "missing"}, find)
+	assert.Error(t, err)
+}
+
+func TestRenderTsconfig(t *testing.T) {
+	data, err := RenderTsconfig(&entitie.Tsconfig{Module: "legacy", Target: "es6", SourceMap: true, Excluding: 1, Include: entitie.StringList{"src"}})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	var file map[string]interface{}
+	if err = json.Unmarshal(data, &file); err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"compilerOptions": map[string]interface{}{"target": "es6", "sourceMap": true},
+		"include":         []interface{}{"src"},
+	}, file)
+}
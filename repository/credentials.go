@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+//invalidAuthorization is the postgres error class of rejected credentials
+const invalidAuthorization = "28"
+
+//Credentials are the user and the password the service connects to the database with
+type Credentials struct {
+	User     string
+	Password string
+}
+
+//CredentialsProvider returns the credentials of the database. It is asked for every new connection, so rotated credentials are used without a restart
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+//expirer is implemented by providers caching credentials, Expire is called when the database rejects them
+type expirer interface {
+	Expire()
+}
+
+//StaticCredentials are credentials which do not change while the service runs, such as those of env. variables or of DATABASE_URL
+type StaticCredentials Credentials
+
+//Credentials returns c
+func (c StaticCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	return Credentials(c), nil
+}
+
+//FileCredentials reads the password from a file, e.g. a mounted secret. The file is read for every new connection
+type FileCredentials struct {
+	User string
+	Path string
+}
+
+//Credentials returns the user and the content of the file without trailing line breaks
+func (c FileCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	data, err := ioutil.ReadFile(c.Path)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("password file: %v", err)
+	}
+	password := strings.TrimRight(string(data), "\r\n")
+	if password == "" {
+		return Credentials{}, fmt.Errorf("password file %s is empty", c.Path)
+	}
+	return Credentials{User: c.User, Password: password}, nil
+}
+
+//CommandCredentials runs a shell command printing the password, e.g. the CLI of a secrets manager.
+//The password is kept for Refresh and until the database rejects it
+type CommandCredentials struct {
+	User    string
+	Command string
+	Refresh time.Duration
+
+	mu       sync.Mutex
+	password string
+	expires  time.Time
+}
+
+//Credentials returns the user and the kept password, the command is run again when there is none
+func (c *CommandCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.password != "" && time.Now().Before(c.expires) {
+		return Credentials{User: c.User, Password: c.password}, nil
+	}
+	output, err := exec.CommandContext(ctx, "sh", "-c", c.Command).Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return Credentials{}, fmt.Errorf("password command: %v: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return Credentials{}, fmt.Errorf("password command: %v", err)
+	}
+	password := strings.TrimRight(string(output), "\r\n")
+	if password == "" {
+		return Credentials{}, errors.New("password command printed no password")
+	}
+	c.password, c.expires = password, time.Now().Add(c.Refresh)
+	return Credentials{User: c.User, Password: c.password}, nil
+}
+
+//Expire makes the next call of Credentials run the command again
+func (c *CommandCredentials) Expire() {
+	c.mu.Lock()
+	c.password = ""
+	c.mu.Unlock()
+}
+
+//CredentialsProvider returns the provider of the password source configured by c. Validate checks that exactly one is configured
+func (c PostgresConfig) CredentialsProvider() CredentialsProvider {
+	user := c.User
+	var urlPassword string
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err == nil && u.User != nil {
+			if user == "" {
+				user = u.User.Username()
+			}
+			urlPassword, _ = u.User.Password()
+		}
+	}
+	switch {
+	case c.PasswordFile != "":
+		return FileCredentials{User: user, Path: c.PasswordFile}
+	case c.PasswordCommand != "":
+		return &CommandCredentials{User: user, Command: c.PasswordCommand, Refresh: time.Minute * time.Duration(c.PasswordCommandRefreshMinutes)}
+	case c.Password != "":
+		return StaticCredentials{User: user, Password: c.Password}
+	}
+	return StaticCredentials{User: user, Password: urlPassword}
+}
+
+//validateCredentials returns the errors of a missing user or of not exactly one password source
+func (c PostgresConfig) validateCredentials() []error {
+	var errs []error
+	var urlUser, urlPassword string
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err == nil && u.User != nil {
+			urlUser = u.User.Username()
+			urlPassword, _ = u.User.Password()
+		}
+	}
+	if c.User == "" && urlUser == "" {
+		errs = append(errs, errors.New("user: must be set, either itself or in url"))
+	}
+	var sources []string
+	for _, source := range []struct{ key, value string }{
+		{"password", c.Password},
+		{"passwordFile", c.PasswordFile},
+		{"passwordCommand", c.PasswordCommand},
+		{"url", urlPassword},
+	} {
+		if source.value != "" {
+			sources = append(sources, source.key)
+		}
+	}
+	switch {
+	case len(sources) == 0:
+		errs = append(errs, errors.New("password: must be set, either itself, by passwordFile, by passwordCommand or in url"))
+	case len(sources) > 1:
+		errs = append(errs, fmt.Errorf("password: only one source may be set, got %s", strings.Join(sources, ", ")))
+	}
+	if c.PasswordCommandRefreshMinutes < 0 {
+		errs = append(errs, errors.New("passwordCommandRefreshMinutes: must not be negative"))
+	}
+	return errs
+}
+
+//dataSource returns the URL of the database with the given credentials. The URL setting is used when it is set, otherwise it is built from the other settings
+func (c PostgresConfig) dataSource(credentials Credentials) string {
+	dbInf := &url.URL{Scheme: c.Scheme, Host: c.Host + ":" + c.Port, Path: c.Name}
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err == nil {
+			dbInf = u
+		}
+	}
+	dbInf.User = url.UserPassword(credentials.User, credentials.Password)
+	return dbInf.String()
+}
+
+//credentialsConnector opens connections with the current credentials of its provider
+type credentialsConnector struct {
+	config      PostgresConfig
+	credentials CredentialsProvider
+}
+
+//Connect opens a connection, credentials rejected by the database are expired in their provider
+func (c credentialsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	credentials, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "error during reading database credentials", "error", err)
+		return nil, err
+	}
+	connector, err := pq.NewConnector(c.config.dataSource(credentials))
+	if err != nil {
+		return nil, err
+	}
+	conn, err := pq.ConnectorWithNoticeHandler(connector, logNotice).Connect(ctx)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Class() == invalidAuthorization {
+		slog.WarnContext(ctx, "database rejected the credentials", "user", credentials.User, "error", err)
+		if e, ok := c.credentials.(expirer); ok {
+			e.Expire()
+		}
+	}
+	return conn, err
+}
+
+//logNotice logs the notices sent by the database, warnings like those raised by migrations are logged as warnings
+func logNotice(notice *pq.Error) {
+	level := slog.LevelInfo
+	if notice.Severity == "WARNING" {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "database notice", "severity", notice.Severity, "message", notice.Message)
+}
+
+//Driver returns the postgres driver
+func (c credentialsConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
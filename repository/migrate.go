@@ -0,0 +1,371 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/database"
+)
+
+//migrationsLockKey identifies the advisory lock held while migrations run, so replicas starting together apply them once
+const migrationsLockKey int64 = 0x4765744d65436f6e
+
+//migrationsTable stores the versions of applied migrations
+const migrationsTable = "schema_migrations"
+
+//gormigrateVersions map the migrations applied by gormigrate, which was used before SQL migrations, to their versions
+var gormigrateVersions = map[string]int64{
+	"Initial":        1,
+	"TsconfigNative": 2,
+	"Revisions":      3,
+}
+
+//migrationFile matches names of migration files like 0001_initial.sql
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+//Migration is a versioned change of the database schema
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+//MigrationStatus tells if and when a migration has been applied, AppliedAt is zero for pending migrations
+type MigrationStatus struct {
+	Migration
+	AppliedAt time.Time
+}
+
+//Migrator applies and reverts the migrations embedded in the binary
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+//NewMigrator returns a Migrator of the embedded migrations
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(database.Migrations)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+//LoadMigrations reads the migration files of the migrations directory of fsys ordered by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	versions := make(map[int64]string)
+	for _, file := range files {
+		match := migrationFile.FindStringSubmatch(file.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name is not like 0001_description.sql", file.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %v", file.Name(), err)
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("migrations %s and %s have the same version", other, file.Name())
+		}
+		versions[version] = file.Name()
+		data, err := fs.ReadFile(fsys, path.Join("migrations", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration := Migration{Version: version, Name: match[2]}
+		if migration.Up, migration.Down, err = migrationSections(string(data)); err != nil {
+			return nil, fmt.Errorf("migration %s: %v", file.Name(), err)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+//migrationSections splits a migration file into its Up and Down sections
+func migrationSections(data string) (up, down string, err error) {
+	var current *string
+	sections := map[string]*string{"Up": &up, "Down": &down}
+	for _, line := range strings.SplitAfter(data, "\n") {
+		if directive := strings.TrimSpace(line); strings.HasPrefix(directive, "-- +migrate ") {
+			section, ok := sections[strings.TrimSpace(strings.TrimPrefix(directive, "-- +migrate "))]
+			if !ok {
+				return "", "", fmt.Errorf("unexpected directive %q", directive)
+			}
+			current = section
+			continue
+		}
+		if current == nil {
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(strings.TrimSpace(line), "--") {
+				return "", "", fmt.Errorf("statement before the Up section: %q", strings.TrimSpace(line))
+			}
+			continue
+		}
+		*current += line
+	}
+	up, down = strings.TrimSpace(up), strings.TrimSpace(down)
+	if up == "" || down == "" {
+		return "", "", fmt.Errorf("both Up and Down sections are needed")
+	}
+	return up, down, nil
+}
+
+//Up applies all pending migrations and returns them
+func (m *Migrator) Up(ctx context.Context) (applied []Migration, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn, versions map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+//Down reverts the last n applied migrations and returns them in the order they were reverted
+func (m *Migrator) Down(ctx context.Context, n int) (reverted []Migration, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn, versions map[int64]time.Time) error {
+		last, err := m.lastApplied(versions, n)
+		if err != nil {
+			return err
+		}
+		for _, migration := range last {
+			if err := m.apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+//Redo reverts the last applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) (redone *Migration, err error) {
+	err = m.locked(ctx, func(conn *sql.Conn, versions map[int64]time.Time) error {
+		last, err := m.lastApplied(versions, 1)
+		if err != nil {
+			return err
+		}
+		if len(last) == 0 {
+			return nil
+		}
+		if err := m.apply(ctx, conn, last[0], false); err != nil {
+			return err
+		}
+		if err := m.apply(ctx, conn, last[0], true); err != nil {
+			return err
+		}
+		redone = &last[0]
+		return nil
+	})
+	return redone, err
+}
+
+//Status returns all migrations with the times they were applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(conn *sql.Conn, versions map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			statuses = append(statuses, MigrationStatus{Migration: migration, AppliedAt: versions[migration.Version]})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+//Pending returns the number of migrations which have not been applied, it neither takes the lock nor creates the migrations table
+func (m *Migrator) Pending(ctx context.Context) (int, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT version FROM "+migrationsTable)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			return 0, err
+		}
+		applied[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	pending := 0
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending++
+		}
+	}
+	return pending, nil
+}
+
+//lastApplied returns the last n applied migrations, newest first
+func (m *Migrator) lastApplied(versions map[int64]time.Time, n int) ([]Migration, error) {
+	var applied []int64
+	for version := range versions {
+		applied = append(applied, version)
+	}
+	sort.Slice(applied, func(i, j int) bool {
+		return applied[i] > applied[j]
+	})
+	var last []Migration
+	for _, version := range applied {
+		if len(last) == n {
+			break
+		}
+		migration, ok := m.find(version)
+		if !ok {
+			return nil, fmt.Errorf("applied migration %d is unknown to this version of the service", version)
+		}
+		last = append(last, migration)
+	}
+	return last, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+//locked runs fn on a connection holding the migrations lock with the applied versions of migrations.
+//The migrations table is created first and filled from the table of gormigrate if that was used before
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, versions map[int64]time.Time) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationsLockKey); err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockKey); err != nil {
+			slog.Error("error during releasing the migrations lock", "error", err)
+		}
+	}()
+	if _, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+migrationsTable+" (version bigint PRIMARY KEY, name text NOT NULL, applied_at timestamp with time zone NOT NULL DEFAULT now())"); err != nil {
+		return err
+	}
+	if err = m.baseline(ctx, conn); err != nil {
+		return err
+	}
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM "+migrationsTable)
+	if err != nil {
+		return err
+	}
+	versions := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		versions[version] = appliedAt
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	return fn(conn, versions)
+}
+
+//baseline records the migrations applied by gormigrate when the migrations table is still empty. Config tables without the
+//table of gormigrate, as restored from a dump, are left to migrations 1 to 3, which create only what is missing
+func (m *Migrator) baseline(ctx context.Context, conn *sql.Conn) error {
+	var recorded int
+	if err := conn.QueryRowContext(ctx, "SELECT count(*) FROM "+migrationsTable).Scan(&recorded); err != nil {
+		return err
+	}
+	var gormigrated bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('migrations') IS NOT NULL").Scan(&gormigrated); err != nil {
+		return err
+	}
+	if recorded > 0 || !gormigrated {
+		return nil
+	}
+	rows, err := conn.QueryContext(ctx, "SELECT id FROM migrations")
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		version, ok := gormigrateVersions[id]
+		if !ok {
+			continue
+		}
+		migration, _ := m.find(version)
+		if _, err = conn.ExecContext(ctx, "INSERT INTO "+migrationsTable+" (version, name) VALUES ($1, $2)", version, migration.Name); err != nil {
+			return err
+		}
+		slog.Info("migration applied by gormigrate has been recorded", "id", id, "version", version)
+	}
+	return nil
+}
+
+//apply runs the Up or Down section of a migration and records it in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	statements, record, args := migration.Up, "INSERT INTO "+migrationsTable+" (version, name) VALUES ($1, $2)", []interface{}{migration.Version, migration.Name}
+	if !up {
+		statements, record, args = migration.Down, "DELETE FROM "+migrationsTable+" WHERE version = $1", []interface{}{migration.Version}
+	}
+	if _, err = tx.ExecContext(ctx, statements); err == nil {
+		_, err = tx.ExecContext(ctx, record, args...)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d_%s: %v", migration.Version, migration.Name, err)
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	if up {
+		slog.Info("migration has been applied", "version", migration.Version, "name", migration.Name)
+	} else {
+		slog.Info("migration has been reverted", "version", migration.Version, "name", migration.Name)
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/database"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(database.Migrations)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version, "embedded migrations have consecutive versions")
+	}
+	for _, version := range gormigrateVersions {
+		assert.True(t, version <= int64(len(migrations)), "migrations of gormigrate are embedded")
+	}
+
+	migrations, err = LoadMigrations(fstest.MapFS{
+		"migrations/0002_second.sql": {Data: []byte("-- +migrate Up\nCREATE TABLE b ();\n-- +migrate Down\nDROP TABLE b;\n")},
+		"migrations/0001_first.sql":  {Data: []byte("-- first table\n-- +migrate Up\nCREATE TABLE a ();\n\n-- +migrate Down\nDROP TABLE a;\n")},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []Migration{
+			{Version: 1, Name: "first", Up: "CREATE TABLE a ();", Down: "DROP TABLE a;"},
+			{Version: 2, Name: "second", Up: "CREATE TABLE b ();", Down: "DROP TABLE b;"},
+		}, migrations)
+	}
+
+	for name, data := range map[string]string{
+		"migrations/first.sql":      "-- +migrate Up\nCREATE TABLE a ();\n-- +migrate Down\nDROP TABLE a;\n",
+		"migrations/0001_first.sql": "-- +migrate Up\nCREATE TABLE a ();\n",
+		"migrations/0001_other.sql": "CREATE TABLE a ();\n-- +migrate Up\n-- +migrate Down\n",
+	} {
+		_, err = LoadMigrations(fstest.MapFS{name: {Data: []byte(data)}})
+		assert.Error(t, err, name)
+	}
+}
+
+func expectMigrationsLock(m sqlmock.Sqlmock, applied *sqlmock.Rows) {
+	m.ExpectExec(formatRequest("SELECT pg_advisory_lock($1)")).WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectQuery(formatRequest("SELECT count(*) FROM schema_migrations")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	m.ExpectQuery(formatRequest("SELECT to_regclass('migrations') IS NOT NULL")).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	m.ExpectQuery(formatRequest("SELECT version, applied_at FROM schema_migrations")).WillReturnRows(applied)
+}
+
+func TestMigrator(t *testing.T) {
+	db, m, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	migrator := &Migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "first", Up: "CREATE TABLE a ()", Down: "DROP TABLE a"},
+		{Version: 2, Name: "second", Up: "CREATE TABLE b ()", Down: "DROP TABLE b"},
+	}}
+	appliedAt := time.Date(2018, 3, 27, 0, 0, 0, 0, time.UTC)
+
+	expectMigrationsLock(m, sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+	m.ExpectBegin()
+	m.ExpectExec(formatRequest("CREATE TABLE b ()")).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec(formatRequest("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).WithArgs(2, "second").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectCommit()
+	m.ExpectExec(formatRequest("SELECT pg_advisory_unlock($1)")).WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	applied, err := migrator.Up(context.Background())
+	if assert.NoError(t, err) && assert.Len(t, applied, 1) {
+		assert.Equal(t, int64(2), applied[0].Version, "only pending migrations are applied")
+	}
+
+	expectMigrationsLock(m, sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt).AddRow(2, appliedAt))
+	m.ExpectBegin()
+	m.ExpectExec(formatRequest("DROP TABLE b")).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec(formatRequest("DELETE FROM schema_migrations WHERE version = $1")).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectCommit()
+	m.ExpectBegin()
+	m.ExpectExec(formatRequest("DROP TABLE a")).WillReturnError(errors.New("table is in use"))
+	m.ExpectRollback()
+	m.ExpectExec(formatRequest("SELECT pg_advisory_unlock($1)")).WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	reverted, err := migrator.Down(context.Background(), 2)
+	assert.Error(t, err)
+	if assert.Len(t, reverted, 1, "the newest migration is reverted first") {
+		assert.Equal(t, int64(2), reverted[0].Version)
+	}
+
+	expectMigrationsLock(m, sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+	m.ExpectExec(formatRequest("SELECT pg_advisory_unlock($1)")).WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	statuses, err := migrator.Status(context.Background())
+	if assert.NoError(t, err) && assert.Len(t, statuses, 2) {
+		assert.Equal(t, appliedAt, statuses[0].AppliedAt)
+		assert.True(t, statuses[1].AppliedAt.IsZero(), "pending migrations have no time")
+	}
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestMigratorBaseline(t *testing.T) {
+	db, m, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+
+	m.ExpectExec(formatRequest("SELECT pg_advisory_lock($1)")).WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectQuery(formatRequest("SELECT count(*) FROM schema_migrations")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	m.ExpectQuery(formatRequest("SELECT to_regclass('migrations') IS NOT NULL")).WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	m.ExpectQuery(formatRequest("SELECT id FROM migrations")).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("Initial").AddRow("TsconfigNative"))
+	m.ExpectExec(formatRequest("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).WithArgs(1, "initial").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectExec(formatRequest("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).WithArgs(2, "tsconfig_native").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectQuery(formatRequest("SELECT version, applied_at FROM schema_migrations")).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()).AddRow(2, time.Now()))
+	m.ExpectExec(formatRequest("SELECT pg_advisory_unlock($1)")).WithArgs(migrationsLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	statuses, err := migrator.Status(context.Background())
+	if assert.NoError(t, err) {
+		assert.False(t, statuses[1].AppliedAt.IsZero(), "migrations applied by gormigrate are recorded")
+		assert.True(t, statuses[2].AppliedAt.IsZero())
+	}
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+//testSchema opens the database given by PDB_TEST_URL, on a single connection working in a new schema, which is dropped
+//after the test. The test is skipped if PDB_TEST_URL is not set. exec runs a statement and fails the test on errors
+func testSchema(t *testing.T, schema string) (db *sql.DB, exec func(query string, args ...interface{})) {
+	url := os.Getenv("PDB_TEST_URL")
+	if url == "" {
+		t.Skip("PDB_TEST_URL is not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	//the schema is set for the session, so all statements have to use the same connection
+	db.SetMaxOpenConns(1)
+	exec = func(query string, args ...interface{}) {
+		if _, err := db.ExecContext(context.Background(), query, args...); err != nil {
+			t.Fatal("error during unit testing: ", query, ": ", err)
+		}
+	}
+	exec("DROP SCHEMA IF EXISTS " + schema + " CASCADE")
+	exec("CREATE SCHEMA " + schema)
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		db.Close()
+	})
+	exec("SET search_path TO " + schema)
+	return db, exec
+}
+
+//TestRestoredDumpMigration applies all migrations to the tables of a dump restored without any table of applied migrations,
+//taken after one of the migrations formerly applied by gormigrate. It needs a database given by PDB_TEST_URL
+func TestRestoredDumpMigration(t *testing.T) {
+	db, exec := testSchema(t, "restored_dump_test")
+	ctx := context.Background()
+	migrations, err := LoadMigrations(database.Migrations)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	for _, dumped := range []int{1, 3} {
+		exec("DROP TABLE IF EXISTS schema_migrations, duplicate_configs, mongodbs, tempconfigs, tsconfigs, featureflags")
+		if _, err = (&Migrator{db: db, migrations: migrations[:dumped]}).Up(ctx); err != nil {
+			t.Fatal("error during unit testing: ", err)
+		}
+		exec("DROP TABLE schema_migrations")
+		exec("INSERT INTO tsconfigs (module, target) VALUES ('web', 'es2017')")
+
+		applied, err := (&Migrator{db: db, migrations: migrations}).Up(ctx)
+		if !assert.NoError(t, err, "a dump taken after migration %d is migrated", dumped) {
+			continue
+		}
+		assert.Len(t, applied, len(migrations), "all migrations are recorded")
+		var target string
+		if assert.NoError(t, db.QueryRowContext(ctx, "SELECT target FROM tsconfigs WHERE module = 'web'").Scan(&target)) {
+			assert.Equal(t, "es2017", target, "restored configs are kept")
+		}
+	}
+}
+
+//TestSurrogateIDsMigration applies migration 4 to tables holding duplicate and empty names, like those created by the former SQL files.
+//It needs a database given by PDB_TEST_URL
+func TestSurrogateIDsMigration(t *testing.T) {
+	db, exec := testSchema(t, "surrogate_ids_test")
+	ctx := context.Background()
+
+	migrations, err := LoadMigrations(database.Migrations)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	if _, err = (&Migrator{db: db, migrations: migrations[:3]}).Up(ctx); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	exec("ALTER TABLE mongodbs DROP CONSTRAINT mongodbs_pkey")
+	exec("ALTER TABLE tempconfigs DROP CONSTRAINT tempconfigs_pkey")
+	exec("ALTER TABLE tsconfigs DROP CONSTRAINT tsconfigs_pkey")
+	exec("INSERT INTO mongodbs (domain, host) VALUES ('main', 'mongo-0')")
+	exec("INSERT INTO tempconfigs (rest_api_root, host, revision) VALUES ('/home', 'old', 1), ('/home', 'new', 2), ('', 'empty', 1), ('/api', 'api', 1)")
+	exec("INSERT INTO tsconfigs (module, target) VALUES ('admin', 'es5'), ('admin', 'es6')")
+
+	if _, err = (&Migrator{db: db, migrations: migrations[:4]}).Up(ctx); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	rows, err := db.QueryContext(ctx, "SELECT config_type, config_name, config->>'host', config->>'target', reason FROM duplicate_configs ORDER BY config_type, config_name")
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	var moved [][5]sql.NullString
+	for rows.Next() {
+		var row [5]sql.NullString
+		if err = rows.Scan(&row[0], &row[1], &row[2], &row[3], &row[4]); err != nil {
+			t.Fatal("error during unit testing: ", err)
+		}
+		moved = append(moved, row)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	value := func(s string) sql.NullString { return sql.NullString{String: s, Valid: s != ""} }
+	assert.Equal(t, [][5]sql.NullString{
+		{value("tempconfig"), {Valid: true}, value("empty"), value(""), value("empty name")},
+		{value("tempconfig"), value("/home"), value("old"), value(""), value("duplicate name")},
+		{value("tsconfig"), value("admin"), value(""), value("es6"), value("duplicate name")},
+	}, moved, "empty names and all but the newest, then the oldest copy of a name are moved")
+
+	var host, target string
+	if assert.NoError(t, db.QueryRowContext(ctx, "SELECT host FROM tempconfigs WHERE rest_api_root = '/home'").Scan(&host)) {
+		assert.Equal(t, "new", host, "the copy with the highest revision is kept")
+	}
+	if assert.NoError(t, db.QueryRowContext(ctx, "SELECT target FROM tsconfigs WHERE module = 'admin'").Scan(&target)) {
+		assert.Equal(t, "es5", target, "the oldest copy is kept if revisions are equal")
+	}
+	var ids int
+	if assert.NoError(t, db.QueryRowContext(ctx, "SELECT count(DISTINCT id) FROM tempconfigs").Scan(&ids)) {
+		assert.Equal(t, 2, ids, "every row gets an id")
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO tempconfigs (rest_api_root) VALUES ('/api')")
+	if pqErr, ok := err.(*pq.Error); assert.True(t, ok, "duplicate names are rejected") {
+		assert.Equal(t, uniqueViolation, string(pqErr.Code))
+		assert.Equal(t, "tempconfigs_namespace_name_key", pqErr.Constraint)
+	}
+	_, err = db.ExecContext(ctx, "INSERT INTO tempconfigs (rest_api_root, namespace) VALUES ('/api', 'other')")
+	assert.NoError(t, err, "names are unique within a namespace")
+	_, err = db.ExecContext(ctx, "INSERT INTO mongodbs (domain) VALUES ('')")
+	if pqErr, ok := err.(*pq.Error); assert.True(t, ok, "empty names are rejected") {
+		assert.Equal(t, "mongodbs_name_check", pqErr.Constraint)
+	}
+}
+
+//TestMongodbConnectionMigration applies migration 5 to seed lists and reverts it. It needs a database given by PDB_TEST_URL
+func TestMongodbConnectionMigration(t *testing.T) {
+	db, exec := testSchema(t, "mongodb_connection_test")
+	ctx := context.Background()
+	migrations, err := LoadMigrations(database.Migrations)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	migrator := &Migrator{db: db, migrations: migrations[:5]}
+	if _, err = (&Migrator{db: db, migrations: migrations[:4]}).Up(ctx); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	exec("INSERT INTO mongodbs (domain, host, port) VALUES ('seeds', 'mongo-0:27017, mongo-1', NULL), ('single', 'mongo-0', '27017'), ('portless', 'mongo-0', NULL)")
+	if _, err = migrator.Up(ctx); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	hosts := func() map[string]string {
+		rows, err := db.QueryContext(ctx, "SELECT domain, host, coalesce(port, '') FROM mongodbs")
+		if err != nil {
+			t.Fatal("error during unit testing: ", err)
+		}
+		defer rows.Close()
+		hosts := make(map[string]string)
+		for rows.Next() {
+			var domain, host, port string
+			if err = rows.Scan(&domain, &host, &port); err != nil {
+				t.Fatal("error during unit testing: ", err)
+			}
+			hosts[domain] = host + " " + port
+		}
+		return hosts
+	}
+	assert.Equal(t, map[string]string{"seeds": "mongo-0 27017", "single": "mongo-0 27017", "portless": "mongo-0 "}, hosts(),
+		"the first member of a seed list is kept in host and port")
+
+	exec("UPDATE mongodbs SET port = NULL, members = '[{\"host\":\"mongo-1\",\"port\":27018}]' WHERE domain = 'portless'")
+	exec("UPDATE mongodbs SET members = '[]' WHERE domain = 'single'")
+	if _, err = migrator.Down(ctx, 1); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Equal(t, map[string]string{"seeds": "mongo-0:27017,mongo-1:27017 27017", "single": "mongo-0:27017 27017", "portless": "mongo-0,mongo-1:27018 "}, hosts(),
+		"members are joined into host, a missing port or no members do not lose it")
+}
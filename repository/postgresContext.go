@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/YAWAL/GetMeConf/repository")
+
+//contextCommon is implemented by both sql.DB and sql.Tx
+type contextCommon interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+//contextDB binds the statements gorm runs on a database or a transaction to a context, so they are cancelled together with the request
+//and traced as children of its span. It can not begin transactions, so gorm runs every statement through it instead of an implicit transaction
+type contextDB struct {
+	ctx context.Context
+	db  contextCommon
+}
+
+func (c *contextDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startStatementSpan(c.ctx, query)
+	result, err := c.db.ExecContext(ctx, query, args...)
+	endStatementSpan(span, err)
+	return result, err
+}
+
+func (c *contextDB) Prepare(query string) (*sql.Stmt, error) {
+	return c.db.PrepareContext(c.ctx, query)
+}
+
+//Query traces the statement until the first rows are returned, not while they are read
+func (c *contextDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startStatementSpan(c.ctx, query)
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	endStatementSpan(span, err)
+	return rows, err
+}
+
+func (c *contextDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	ctx, span := startStatementSpan(c.ctx, query)
+	row := c.db.QueryRowContext(ctx, query, args...)
+	endStatementSpan(span, row.Err())
+	return row
+}
+
+//startStatementSpan starts a client span of a SQL statement named by its operation, arguments are not recorded as they may hold secrets
+func startStatementSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := strings.ToUpper(strings.SplitN(strings.TrimSpace(query), " ", 2)[0])
+	return tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperation(operation), semconv.DBStatement(query)))
+}
+
+func endStatementSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+//withContext returns a gorm database running its statements with ctx
+func withContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	common, ok := db.CommonDB().(contextCommon)
+	if !ok {
+		return db
+	}
+	//opening an existing connection pool or transaction neither connects nor pings
+	scoped, _ := gorm.Open("postgres", &contextDB{ctx: ctx, db: common})
+	scoped.SetLogger(gormLogger{})
+	return scoped
+}
+
+//gormLogger writes messages of gorm as structured log lines. The errors gorm logs are returned by the repositories too, so they are only debug messages
+type gormLogger struct{}
+
+func (gormLogger) Print(values ...interface{}) {
+	switch {
+	case len(values) >= 4 && values[0] == "sql":
+		slog.Debug("sql statement", "source", values[1], "duration", values[2], "statement", values[3])
+	case len(values) >= 2 && values[0] == "log":
+		slog.Debug("gorm message", "source", values[1], "message", fmt.Sprint(values[2:]...))
+	case len(values) == 2:
+		slog.Debug("gorm error", "source", values[0], "error", values[1])
+	default:
+		slog.Debug("gorm message", "message", fmt.Sprint(values...))
+	}
+}
+
+//contextError returns the error of ctx if it is done, as the failure of a statement is then caused by the cancellation. Other errors are converted by dbError
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return dbError(err)
+}
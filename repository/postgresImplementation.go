@@ -2,47 +2,119 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
+	"strings"
 
 	"time"
 
-	"os"
-
 	"errors"
 
+	"net"
 	"net/url"
+	"reflect"
 
 	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/selector"
 	"github.com/jinzhu/gorm"
-	_ "github.com/lib/pq"
-	"gopkg.in/gormigrate.v1"
+	"github.com/lib/pq"
 )
 
+//maxPageSize limits the number of configs iterated by Iterate
+const maxPageSize = 1000
+
+//uniqueViolation is the postgres error code of a violated unique constraint
+const uniqueViolation = "23505"
+
+//listColumn is a database column which configs can be filtered and ordered by
+type listColumn struct {
+	name string
+	text bool
+}
+
+//mongodbColumns, tempconfigColumns, tsconfigColumns and featureflagColumns map JSON field names to database columns
 var (
-	defaultDbScheme                 = "postgres"
-	defaultDbHost                   = "horton.elephantsql.com"
-	defaultDbPort                   = "5432"
-	defaultDbUser                   = "dlxifkbx"
-	defaultDbPassword               = "L7Cey-ucPY4L3T6VFlFdNykNE4jO0VjV"
-	defaultDbName                   = "dlxifkbx"
-	defaultMaxOpenedConnectionsToDb = 5
-	defaultMaxIdleConnectionsToDb   = 0
-	defaultmbConnMaxLifetimeMinutes = 30
+	mongodbColumns = map[string]listColumn{
+		"name":           {name: "domain", text: true},
+		"domain":         {name: "domain", text: true},
+		"host":           {name: "host", text: true},
+		"port":           {name: "port"},
+		"replicaSet":     {name: "replica_set", text: true},
+		"database":       {name: "database", text: true},
+		"authSource":     {name: "auth_source", text: true},
+		"username":       {name: "username", text: true},
+		"tls":            {name: "tls"},
+		"readPreference": {name: "read_preference", text: true},
+	}
+	tempconfigColumns = map[string]listColumn{
+		"name":           {name: "rest_api_root", text: true},
+		"restApiRoot":    {name: "rest_api_root", text: true},
+		"host":           {name: "host", text: true},
+		"port":           {name: "port"},
+		"legacyExplorer": {name: "legacy_explorer"},
+		"legasyExplorer": {name: "legacy_explorer"},
+	}
+	tsconfigColumns = map[string]listColumn{
+		"name":      {name: "module", text: true},
+		"module":    {name: "module", text: true},
+		"target":    {name: "target", text: true},
+		"sourceMap": {name: "source_map"},
+		"excluding": {name: "excluding"},
+		"extends":   {name: "extends", text: true},
+	}
+	featureflagColumns = map[string]listColumn{
+		"name":           {name: "key", text: true},
+		"key":            {name: "key", text: true},
+		"enabled":        {name: "enabled"},
+		"offVariant":     {name: "off_variant", text: true},
+		"defaultVariant": {name: "default_variant", text: true},
+	}
 )
 
-//serviceConfig structure contains the configuration information for the database
-type postgresConfig struct {
-	dbSchema                 string
-	dbHost                   string `yaml:"dbhost"`
-	dbPort                   string `yaml:"dbport"`
-	dbUser                   string `yaml:"dbUser"`
-	dbPassword               string `yaml:"dbPassword"`
-	dbName                   string `yaml:"dbName"`
-	maxOpenedConnectionsToDb int    `yaml:"maxOpenedConnectionsToDb"`
-	maxIdleConnectionsToDb   int    `yaml:"maxIdleConnectionsToDb"`
-	mbConnMaxLifetimeMinutes int    `yaml:"mbConnMaxLifetimeMinutes"`
+//PostgresConfig contains the connection settings of the database. The tags name its key in the settings file, its env. variable and its flag.
+//There are no default credentials, the user and exactly one source of the password must be configured
+type PostgresConfig struct {
+	URL                           string `yaml:"url" env:"DATABASE_URL" flag:"db-url" usage:"URL of the database, replacing scheme, host, port and name, and user and password if it has them" secret:"true"`
+	Scheme                        string `yaml:"scheme" env:"PDB_SCHEME" flag:"db-scheme" usage:"scheme of the database URL: postgres or postgresql"`
+	Host                          string `yaml:"host" env:"PDB_HOST" flag:"db-host" usage:"database host"`
+	Port                          string `yaml:"port" env:"PDB_PORT" flag:"db-port" usage:"database port"`
+	User                          string `yaml:"user" env:"PDB_USER" flag:"db-user" usage:"database user"`
+	Password                      string `yaml:"password" env:"PDB_PASSWORD" flag:"db-password" usage:"database password" secret:"true"`
+	PasswordFile                  string `yaml:"passwordFile" env:"PDB_PASSWORD_FILE" flag:"db-password-file" usage:"file containing the database password, read for every new connection"`
+	PasswordCommand               string `yaml:"passwordCommand" env:"PDB_PASSWORD_COMMAND" flag:"db-password-command" usage:"shell command printing the database password"`
+	PasswordCommandRefreshMinutes int    `yaml:"passwordCommandRefreshMinutes" env:"PDB_PASSWORD_COMMAND_REFRESH_MINUTES" flag:"db-password-command-refresh" usage:"minutes the printed password is used before the command runs again, it also runs again when the password is rejected"`
+	Name                          string `yaml:"name" env:"PDB_NAME" flag:"db-name" usage:"database name"`
+	MaxOpenConnections            int    `yaml:"maxOpenConnections" env:"MAX_OPENED_CONNECTIONS_TO_DB" flag:"db-max-open-connections" usage:"maximum number of open connections, 0 is unlimited"`
+	MaxIdleConnections            int    `yaml:"maxIdleConnections" env:"MAX_IDLE_CONNECTIONS_TO_DB" flag:"db-max-idle-connections" usage:"maximum number of idle connections"`
+	ConnMaxLifetimeMinutes        int    `yaml:"connMaxLifetimeMinutes" env:"MB_CONN_MAX_LIFETIME_MINUTES" flag:"db-conn-max-lifetime" usage:"minutes a connection is reused, 0 is forever"`
+	ConnectAttempts               int    `yaml:"connectAttempts" env:"PDB_CONNECT_ATTEMPTS" flag:"db-connect-attempts" usage:"attempts to connect at startup, 0 is until connected"`
+	ConnectBackoffMilliseconds    int    `yaml:"connectBackoffMilliseconds" env:"PDB_CONNECT_BACKOFF_MILLISECONDS" flag:"db-connect-backoff" usage:"delay before the second attempt to connect, doubled for every further attempt"`
+	ConnectMaxBackoffSeconds      int    `yaml:"connectMaxBackoffSeconds" env:"PDB_CONNECT_MAX_BACKOFF_SECONDS" flag:"db-connect-max-backoff" usage:"maximum delay between attempts to connect"`
+	MigrateOnStartup              bool   `yaml:"migrateOnStartup" env:"PDB_MIGRATE_ON_STARTUP" flag:"db-migrate-on-startup" usage:"apply pending migrations when the service starts, otherwise they are applied by the migrate command"`
+}
+
+//DefaultPostgresConfig returns the settings used for what is not configured
+func DefaultPostgresConfig() PostgresConfig {
+	return PostgresConfig{
+		Scheme:                        "postgres",
+		Host:                          "localhost",
+		Port:                          "5432",
+		Name:                          "postgres",
+		PasswordCommandRefreshMinutes: 5,
+		MaxOpenConnections:            5,
+		MaxIdleConnections:            0,
+		ConnMaxLifetimeMinutes:        30,
+		ConnectAttempts:               10,
+		ConnectBackoffMilliseconds:    500,
+		ConnectMaxBackoffSeconds:      30,
+		MigrateOnStartup:              true,
+	}
 }
 
 //MongoDBConfigRepoImpl represents an implementation of a MongoDB configs repository
@@ -60,6 +132,16 @@ type TempConfigRepoImpl struct {
 	DB *gorm.DB
 }
 
+//FeatureFlagRepoImpl represents an implementation of a feature flags repository
+type FeatureFlagRepoImpl struct {
+	DB *gorm.DB
+}
+
+//PostgresTransactor represents an implementation of a Transactor for a postgres database
+type PostgresTransactor struct {
+	DB *gorm.DB
+}
+
 //NewMongoDBConfigRepo returns a new MongoDB configs repository
 func NewMongoDBConfigRepo(db *gorm.DB) MongoDBConfigRepo {
 	return &MongoDBConfigRepoImpl{
@@ -81,301 +163,869 @@ func NewTsConfigRepo(db *gorm.DB) TsConfigRepo {
 	}
 }
 
-func (c *postgresConfig) validate() {
-	if c.dbSchema == "" {
-		log.Println("error during reading env. variable, default value is used")
-		c.dbSchema = defaultDbScheme
+//NewFeatureFlagRepo returns a new feature flags repository
+func NewFeatureFlagRepo(db *gorm.DB) FeatureFlagRepo {
+	return &FeatureFlagRepoImpl{
+		DB: db,
 	}
-	if c.dbHost == "" {
-		log.Println("error during reading env. variable, default value is used")
-		c.dbHost = defaultDbHost
+}
+
+//NewPostgresTransactor returns a new postgres Transactor
+func NewPostgresTransactor(db *gorm.DB) Transactor {
+	return &PostgresTransactor{
+		DB: db,
 	}
-	if c.dbPort == "" {
-		log.Println("error during reading env. variable, default value is used")
-		c.dbPort = defaultDbPort
+}
+
+//InTransaction begins a transaction, runs fn against repositories bound to it and commits if fn succeeds, otherwise the transaction is rolled back.
+//The transaction is also rolled back when ctx is done before it is committed
+func (t *PostgresTransactor) InTransaction(ctx context.Context, fn func(repos ConfigRepos) error) error {
+	sqlTx, err := t.DB.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return contextError(ctx, err)
 	}
-	if c.dbUser == "" {
-		log.Println("error during reading env. variable, default value is used")
-		c.dbUser = defaultDbUser
+	tx, err := gorm.Open("postgres", sqlTx)
+	if err != nil {
+		sqlTx.Rollback()
+		return err
 	}
-	if c.dbPassword == "" {
-		log.Println("error during reading env. variable, default value is used")
-		c.dbPassword = defaultDbPassword
+	repos := ConfigRepos{
+		MongoDB:     &MongoDBConfigRepoImpl{DB: tx},
+		TempConfig:  &TempConfigRepoImpl{DB: tx},
+		TsConfig:    &TsConfigRepoImpl{DB: tx},
+		FeatureFlag: &FeatureFlagRepoImpl{DB: tx},
 	}
-	if c.dbName == "" {
-		log.Println("error during reading env. variable, default value is used")
-		c.dbName = defaultDbName
+	if err := fn(repos); err != nil {
+		if rbErr := sqlTx.Rollback(); rbErr != nil {
+			slog.ErrorContext(ctx, "error during transaction rollback", "error", rbErr)
+		}
+		return err
 	}
-	if c.maxOpenedConnectionsToDb == 0 {
-		log.Printf("maxOpenedConnectionsToDb = 0, default value is used")
-		c.maxOpenedConnectionsToDb = defaultMaxOpenedConnectionsToDb
+	return contextError(ctx, sqlTx.Commit())
+}
+
+//Validate returns the errors of all invalid settings, the keys of the settings file name them
+func (c PostgresConfig) Validate() error {
+	var errs []error
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err != nil {
+			errs = append(errs, errors.New("url: is not a URL"))
+		} else if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			errs = append(errs, fmt.Errorf("url: scheme %q is neither postgres nor postgresql", u.Scheme))
+		}
+	} else {
+		if c.Scheme != "postgres" && c.Scheme != "postgresql" {
+			errs = append(errs, fmt.Errorf("scheme: %q is neither postgres nor postgresql", c.Scheme))
+		}
+		if c.Host == "" {
+			errs = append(errs, errors.New("host: must not be empty"))
+		}
+		if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("port: %q is not a port number", c.Port))
+		}
+		if c.Name == "" {
+			errs = append(errs, errors.New("name: must not be empty"))
+		}
+	}
+	errs = append(errs, c.validateCredentials()...)
+	if c.MaxOpenConnections < 0 {
+		errs = append(errs, errors.New("maxOpenConnections: must not be negative"))
+	}
+	if c.MaxIdleConnections < 0 {
+		errs = append(errs, errors.New("maxIdleConnections: must not be negative"))
 	}
-	if c.maxIdleConnectionsToDb == 0 {
-		log.Printf("maxIdleConnectionsToDb = 0, default value is used")
-		c.maxIdleConnectionsToDb = defaultMaxIdleConnectionsToDb
+	if c.ConnMaxLifetimeMinutes < 0 {
+		errs = append(errs, errors.New("connMaxLifetimeMinutes: must not be negative"))
 	}
-	if c.mbConnMaxLifetimeMinutes == 0 {
-		log.Printf("mbConnMaxLifetimeMinutes = 0, default value is used")
-		c.mbConnMaxLifetimeMinutes = defaultmbConnMaxLifetimeMinutes
+	if c.ConnectAttempts < 0 {
+		errs = append(errs, errors.New("connectAttempts: must not be negative"))
 	}
+	if c.ConnectBackoffMilliseconds <= 0 {
+		errs = append(errs, errors.New("connectBackoffMilliseconds: must be positive"))
+	}
+	if c.ConnectMaxBackoffSeconds <= 0 {
+		errs = append(errs, errors.New("connectMaxBackoffSeconds: must be positive"))
+	}
+	return errors.Join(errs...)
+}
+
+//InitPostgresDB connects to the database with the credentials of the configured source and applies pending migrations
+func InitPostgresDB(c PostgresConfig) (db *gorm.DB, err error) {
+	return InitPostgresDBWithCredentials(c, c.CredentialsProvider())
 }
 
-//InitPostgresDB initiates database connection using environmental variables
-func InitPostgresDB() (db *gorm.DB, err error) {
-	c := new(postgresConfig)
-	c.dbSchema = os.Getenv("PDB_SCHEME")
-	c.dbHost = os.Getenv("PDB_HOST")
-	c.dbPort = os.Getenv("PDB_PORT")
-	c.dbUser = os.Getenv("PDB_USER")
-	c.dbPassword = os.Getenv("PDB_PASSWORD")
-	c.dbName = os.Getenv("PDB_NAME")
-	c.maxOpenedConnectionsToDb, err = strconv.Atoi(os.Getenv("MAX_OPENED_CONNECTIONS_TO_DB"))
+//InitPostgresDBWithCredentials connects to the database with the credentials of the given provider and applies pending migrations,
+//unless they are not applied on startup
+func InitPostgresDBWithCredentials(c PostgresConfig, credentials CredentialsProvider) (*gorm.DB, error) {
+	db, err := OpenPostgresDB(c, credentials)
+	if err != nil || !c.MigrateOnStartup {
+		return db, err
+	}
+	migrator, err := NewMigrator(db.DB())
+	if err == nil {
+		_, err = migrator.Up(context.Background())
+	}
 	if err != nil {
-		log.Printf("error during reading env. variable: %v, default value is used", err)
-		c.maxOpenedConnectionsToDb = 0
+		slog.Error("error during migration", "error", err)
+		db.Close()
+		return nil, err
 	}
-	c.maxIdleConnectionsToDb, err = strconv.Atoi(os.Getenv("MAX_IDLE_CONNECTIONS_TO_DB"))
+	return db, nil
+}
+
+//OpenPostgresDB connects to the database with the credentials of the given provider, which is asked again for every new connection.
+//Failed connections are retried as configured by c
+func OpenPostgresDB(c PostgresConfig, credentials CredentialsProvider) (db *gorm.DB, err error) {
+	for attempt := 1; ; attempt++ {
+		db, err = gorm.Open("postgres", sql.OpenDB(credentialsConnector{config: c, credentials: credentials}))
+		if err == nil {
+			break
+		}
+		if c.ConnectAttempts > 0 && attempt >= c.ConnectAttempts {
+			slog.Error("error during connection to postgres database", "attempts", attempt, "error", err)
+			return nil, err
+		}
+		delay := c.Backoff(attempt)
+		slog.Warn("error during connection to postgres database, retrying", "attempt", attempt, "delay", delay, "error", err)
+		time.Sleep(delay)
+	}
+
+	db.SetLogger(gormLogger{})
+	db.DB().SetMaxOpenConns(c.MaxOpenConnections)
+	db.DB().SetMaxIdleConns(c.MaxIdleConnections)
+	db.DB().SetConnMaxLifetime(time.Minute * time.Duration(c.ConnMaxLifetimeMinutes))
+	slog.Info("connection to postgres database has been established")
+	return db, nil
+}
+
+//ErrMigrationsPending is returned by CheckPostgres when the database schema is older than this version of the service expects
+var ErrMigrationsPending = errors.New("database migrations are pending")
+
+//CheckPostgres pings the database and checks that all migrations have been applied
+func CheckPostgres(ctx context.Context, db *gorm.DB) error {
+	if err := db.DB().PingContext(ctx); err != nil {
+		slog.WarnContext(ctx, "error during database ping", "error", err)
+		return contextError(ctx, err)
+	}
+	migrator, err := NewMigrator(db.DB())
 	if err != nil {
-		log.Printf("error during reading env. variable: %v, default value is used", err)
-		c.maxIdleConnectionsToDb = 0
+		return err
 	}
-	c.mbConnMaxLifetimeMinutes, err = strconv.Atoi(os.Getenv("MB_CONN_MAX_LIFETIME_MINUTES"))
+	pending, err := migrator.Pending(ctx)
 	if err != nil {
-		log.Printf("error during reading env. variable: %v, default value is used", err)
-		c.mbConnMaxLifetimeMinutes = 0
+		return contextError(ctx, err)
+	}
+	if pending > 0 {
+		return ErrMigrationsPending
 	}
-	c.validate()
-	dbInf := url.URL{Scheme: c.dbSchema, User: url.UserPassword(c.dbUser, c.dbPassword), Host: c.dbHost + ":" + c.dbPort, Path: c.dbName}
-	db, err = gorm.Open("postgres", dbInf.String())
+	return nil
+}
 
+//dbError converts errors of the database driver into the errors of this package, other errors are returned unchanged
+func dbError(err error) error {
+	if err == gorm.ErrRecordNotFound {
+		return ErrNotFound
+	}
+	if err == driver.ErrBadConn || err == sql.ErrConnDone {
+		return ErrUnavailable
+	}
+	switch e := err.(type) {
+	case *pq.Error:
+		switch {
+		case e.Code == uniqueViolation:
+			return ErrAlreadyExists
+		case e.Code.Class() == "08", e.Code.Class() == "53", e.Code == "57P01", e.Code == "57P02", e.Code == "57P03":
+			//connection exceptions, insufficient resources and shutdowns of the server
+			return ErrUnavailable
+		}
+	case net.Error:
+		return ErrUnavailable
+	}
+	return err
+}
+
+//revisionCondition adds a check of the expected revision to a WHERE condition, zero expectedRevision leaves the condition unchanged
+func revisionCondition(condition string, expectedRevision int64, args ...interface{}) (string, []interface{}) {
+	if expectedRevision > 0 {
+		return condition + " AND revision = ?", append(args, expectedRevision)
+	}
+	return condition, args
+}
+
+//patchRecord writes the fields of config named by their JSON names into the record with the given key, if it has the expected revision.
+//Zero revision patches unconditionally, on success revision is set to the new revision of the record. The key field and the revision can not be patched
+func patchRecord(ctx context.Context, db *gorm.DB, table, keyColumn, key string, config interface{}, fields []string, revision *int64) error {
+	if len(fields) == 0 {
+		return &FieldError{Field: "fields", Description: "no fields to patch"}
+	}
+	value := reflect.Indirect(reflect.ValueOf(config))
+	columns := make(map[string]int, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		if jsonName := strings.Split(value.Type().Field(i).Tag.Get("json"), ",")[0]; jsonName != "-" {
+			columns[jsonName] = i
+		}
+	}
+	assignments := make([]string, 0, len(fields))
+	args := make([]interface{}, 0, len(fields)+2)
+	for _, field := range fields {
+		i, ok := columns[field]
+		if !ok {
+			return &FieldError{Field: field, Description: "unknown field"}
+		}
+		column := gorm.ToDBName(value.Type().Field(i).Name)
+		if column == keyColumn || column == "revision" {
+			return &FieldError{Field: field, Description: "field can not be patched"}
+		}
+		assignments = append(assignments, column+" = ?")
+		args = append(args, value.Field(i).Interface())
+	}
+	query, args := revisionCondition("UPDATE "+table+" SET "+strings.Join(assignments, ", ")+", revision = revision + 1 WHERE "+keyColumn+" = ?", *revision, append(args, key)...)
+	err := db.Raw(query+" RETURNING revision", args...).Row().Scan(revision)
+	switch {
+	case err == sql.ErrNoRows && *revision > 0:
+		return ErrRevisionMismatch
+	case err == sql.ErrNoRows:
+		return ErrNotFound
+	case err != nil:
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return contextError(ctx, err)
+	}
+	return nil
+}
+
+//listQuery adds filters, ordering and paging of options to a query. Configs are always ordered by their name last, so pages are stable
+func listQuery(db *gorm.DB, columns map[string]listColumn, options ListOptions) (*gorm.DB, int, error) {
+	offset, err := decodePageToken(options.PageToken)
 	if err != nil {
-		log.Printf("error during connection to postgres database has occurred: %v", err)
-		return nil, err
+		return nil, 0, err
 	}
+	for _, filter := range options.Filters {
+		column, ok := columns[filter.Field]
+		if !ok {
+			return nil, 0, &FieldError{Field: "filters", Description: "unknown filter field " + filter.Field}
+		}
+		switch {
+		case filter.Operator == OperatorEqual:
+			db = db.Where(column.name+" = ?", filter.Value)
+		case filter.Operator == OperatorPrefix && column.text:
+			db = db.Where(column.name+" LIKE ?", escapeLike(filter.Value)+"%")
+		default:
+			return nil, 0, &FieldError{Field: "filters", Description: fmt.Sprintf("unexpected operator %q for field %s", filter.Operator, filter.Field)}
+		}
+	}
+	db = labelQuery(db, options.Selector)
+	direction := " ASC"
+	if options.Descending {
+		direction = " DESC"
+	}
+	orderBy := columns["name"]
+	if options.OrderBy != "" {
+		column, ok := columns[options.OrderBy]
+		if !ok {
+			return nil, 0, &FieldError{Field: "orderBy", Description: "unknown order field " + options.OrderBy}
+		}
+		orderBy = column
+	}
+	db = db.Order(orderBy.name + direction)
+	if orderBy.name != columns["name"].name {
+		db = db.Order(columns["name"].name + direction)
+	}
+	if offset > 0 {
+		db = db.Offset(offset)
+	}
+	if pageSize := limitPageSize(options.PageSize); pageSize > 0 {
+		db = db.Limit(pageSize + 1)
+	}
+	return db, offset, nil
+}
 
-	db.DB().SetMaxOpenConns(c.maxOpenedConnectionsToDb)
-	db.DB().SetMaxIdleConns(c.maxIdleConnectionsToDb)
-	db.DB().SetConnMaxLifetime(time.Minute * time.Duration(c.mbConnMaxLifetimeMinutes))
-	log.Printf("connection to postgres database has been established")
+//labelQuery restricts a query to configs whose labels match selector. Equality and set requirements test whether labels contain
+//a label, which the GIN index on labels supports. Existence requirements test for the key with the ? operator of jsonb, so
+//the key is quoted into the condition instead of being a parameter; keys of a parsed selector are plain label keys anyway
+func labelQuery(db *gorm.DB, labelSelector selector.Selector) *gorm.DB {
+	for _, requirement := range labelSelector {
+		var conditions []string
+		var args []interface{}
+		for _, value := range requirement.Values {
+			label, _ := json.Marshal(map[string]string{requirement.Key: value})
+			conditions = append(conditions, "labels @> ?")
+			args = append(args, string(label))
+		}
+		switch requirement.Operator {
+		case selector.OperatorEquals, selector.OperatorIn:
+			db = db.Where(strings.Join(conditions, " OR "), args...)
+		case selector.OperatorNotEquals, selector.OperatorNotIn:
+			db = db.Where("NOT COALESCE("+strings.Join(conditions, " OR ")+", false)", args...)
+		case selector.OperatorExists:
+			db = db.Where("labels ? " + pq.QuoteLiteral(requirement.Key))
+		case selector.OperatorDoesNotExist:
+			db = db.Where("NOT COALESCE(labels ? " + pq.QuoteLiteral(requirement.Key) + ", false)")
+		}
+	}
+	return db
+}
 
-	if err = gormMigrate(db); err != nil {
-		log.Printf("error during migration: %v", err)
-		return nil, err
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
 	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, &FieldError{Field: "pageToken", Description: "invalid page token"}
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, &FieldError{Field: "pageToken", Description: "invalid page token"}
+	}
+	return offset, nil
+}
 
-	return db, nil
+func limitPageSize(pageSize int) int {
+	if pageSize > maxPageSize {
+		return maxPageSize
+	}
+	if pageSize < 0 {
+		return 0
+	}
+	return pageSize
 }
 
-func gormMigrate(db *gorm.DB) error {
-	m := gormigrate.New(db, gormigrate.DefaultOptions, []*gormigrate.Migration{
-		{
-			ID: "Initial",
-			Migrate: func(tx *gorm.DB) error {
-				type Mongodb struct {
-					//gorm.Model
-					Domain  string `gorm:"primary_key"`
-					Mongodb bool
-					Host    string
-					Port    string
-				}
-				type Tsconfig struct {
-					//gorm.Model
-					Module    string `gorm:"primary_key"`
-					Target    string
-					SourceMap bool
-					Excluding int
-				}
-				type Tempconfig struct {
-					//gorm.Model
-					RestApiRoot    string `gorm:"primary_key"`
-					Host           string
-					Port           string
-					Remoting       string
-					LegasyExplorer bool
-				}
-				return tx.AutoMigrate(&Mongodb{}, &Tsconfig{}, &Tempconfig{}).Error
-			},
-			Rollback: func(tx *gorm.DB) error {
-				return tx.DropTable("mongodbs", "tsconfigs", "tempconfigs").Error
-			},
-		},
-	})
-
-	err := m.Migrate()
-	if err != nil {
-		log.Fatalf("could not migrate: %v", err)
-	}
-	log.Printf("Migration did run successfully")
-	return err
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
 }
 
 //Find returns a config record from database using the unique name
-func (r *MongoDBConfigRepoImpl) Find(configName string) (*entitie.Mongodb, error) {
+func (r *MongoDBConfigRepoImpl) Find(ctx context.Context, configName string) (*entitie.Mongodb, error) {
+	db := withContext(ctx, r.DB)
 	result := entitie.Mongodb{}
-	err := r.DB.Where("domain = ?", configName).Find(&result).Error
+	err := db.Where("domain = ?", configName).Find(&result).Error
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
 	}
 	return &result, nil
 }
 
 //FindAll returns all config record of one type from database
-func (r *MongoDBConfigRepoImpl) FindAll() ([]entitie.Mongodb, error) {
+func (r *MongoDBConfigRepoImpl) FindAll(ctx context.Context) ([]entitie.Mongodb, error) {
+	db := withContext(ctx, r.DB)
 	var confSlice []entitie.Mongodb
-	err := r.DB.Find(&confSlice).Error
+	err := db.Find(&confSlice).Error
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
 	}
 	return confSlice, nil
 }
 
-//Save saves new config record to the database
-func (r *MongoDBConfigRepoImpl) Save(config *entitie.Mongodb) (string, error) {
-	err := r.DB.Create(config).Error
+//Iterate calls fn for every config record matching the filters of options while reading them from a database cursor, so memory use does not grow with the number of records.
+//It returns a token for the next page, which is empty on the last page. Iteration stops at the first error returned by fn
+func (r *MongoDBConfigRepoImpl) Iterate(ctx context.Context, options ListOptions, fn func(config *entitie.Mongodb) error) (string, error) {
+	db := withContext(ctx, r.DB)
+	query, offset, err := listQuery(db, mongodbColumns, options)
 	if err != nil {
-		log.Printf("error during saving to database: %v", err)
 		return "", err
 	}
+	rows, err := query.Model(&entitie.Mongodb{}).Rows()
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	defer rows.Close()
+	pageSize := limitPageSize(options.PageSize)
+	for count := 0; rows.Next(); count++ {
+		if count == pageSize && pageSize > 0 {
+			return encodePageToken(offset + pageSize), nil
+		}
+		var config entitie.Mongodb
+		if err = db.ScanRows(rows, &config); err != nil {
+			return "", contextError(ctx, err)
+		}
+		if err = fn(&config); err != nil {
+			return "", err
+		}
+	}
+	return "", contextError(ctx, rows.Err())
+}
+
+//Count returns the number of config records in the database
+func (r *MongoDBConfigRepoImpl) Count(ctx context.Context) (int, error) {
+	db := withContext(ctx, r.DB)
+	var count int
+	if err := db.Model(&entitie.Mongodb{}).Count(&count).Error; err != nil {
+		return 0, contextError(ctx, err)
+	}
+	return count, nil
+}
+
+//Save saves new config record to the database, the record starts at revision 1
+func (r *MongoDBConfigRepoImpl) Save(ctx context.Context, config *entitie.Mongodb) (string, error) {
+	if err := ValidateMongodb(config); err != nil {
+		return "", err
+	}
+	db := withContext(ctx, r.DB)
+	config.Revision = 1
+	err := db.Create(config).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return "", contextError(ctx, err)
+	}
 	return "OK", nil
 }
 
-//Delete removes config record from database
-func (r *MongoDBConfigRepoImpl) Delete(configName string) (string, error) {
-	rowsAffected := r.DB.Delete(entitie.Mongodb{}, "domain = ?", configName).RowsAffected
+//Delete removes config record from database if it has the expected revision, zero expectedRevision deletes unconditionally
+func (r *MongoDBConfigRepoImpl) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
+	db := withContext(ctx, r.DB)
+	condition, args := revisionCondition("domain = ?", expectedRevision, configName)
+	result := db.Delete(entitie.Mongodb{}, append([]interface{}{condition}, args...)...)
+	if result.Error != nil {
+		return "", contextError(ctx, result.Error)
+	}
+	rowsAffected := result.RowsAffected
 	if rowsAffected < 1 {
-		return "", errors.New("could not delete from database")
+		if expectedRevision > 0 && !db.Where("domain = ?", configName).Find(&entitie.Mongodb{}).RecordNotFound() {
+			return "", ErrRevisionMismatch
+		}
+		return "", ErrNotFound
 	}
 	return fmt.Sprintf("deleted %d row(s)", rowsAffected), nil
 }
 
-//Update updates a record in database, rewriting the fields if string fields are not empty
-func (r *MongoDBConfigRepoImpl) Update(newConfig *entitie.Mongodb) (string, error) {
+//Update rewrites all fields of a record in database, if newConfig follows the rules of MongoDB configs.
+//The Revision of newConfig is the expected revision of the record (zero updates unconditionally), on success it is set to the new revision
+func (r *MongoDBConfigRepoImpl) Update(ctx context.Context, newConfig *entitie.Mongodb) (string, error) {
+	db := withContext(ctx, r.DB)
 	var persistedConfig entitie.Mongodb
-	err := r.DB.Where("domain = ?", newConfig.Domain).Find(&persistedConfig).Error
+	err := db.Where("domain = ?", newConfig.Domain).Find(&persistedConfig).Error
 	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	if newConfig.Revision > 0 && newConfig.Revision != persistedConfig.Revision {
+		return "", ErrRevisionMismatch
+	}
+	if err = ValidateMongodb(newConfig); err != nil {
 		return "", err
 	}
-	if newConfig.Host != "" && newConfig.Port != "" {
-		err = r.DB.Exec("UPDATE mongodbs SET mongodb = ?, port = ?, host = ? WHERE domain = ?", strconv.FormatBool(newConfig.Mongodb), newConfig.Port, newConfig.Host, persistedConfig.Domain).Error
-		if err != nil {
-			log.Printf("error during saving to database: %v", err)
-			return "", err
-		}
-		return "OK", nil
+	query, args := revisionCondition("UPDATE mongodbs SET port = ?, host = ?, members = ?, replica_set = ?, database = ?, auth_source = ?, username = ?, "+
+		"password_secret = ?, tls = ?, read_preference = ?, variants = ?, labels = ?, annotations = ?, revision = revision + 1 WHERE domain = ?", newConfig.Revision,
+		newConfig.Port, newConfig.Host, newConfig.Members, newConfig.ReplicaSet, newConfig.Database, newConfig.AuthSource, newConfig.Username,
+		newConfig.PasswordSecret, strconv.FormatBool(newConfig.TLS), newConfig.ReadPreference, newConfig.Variants, newConfig.Labels, newConfig.Annotations, persistedConfig.Domain)
+	err = db.Raw(query+" RETURNING revision", args...).Row().Scan(&newConfig.Revision)
+	if err == sql.ErrNoRows {
+		return "", ErrRevisionMismatch
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return "", contextError(ctx, err)
+	}
+	return "OK", nil
+}
+
+//Patch writes only the named fields of config, given by their JSON names, leaving the other fields of the record unchanged.
+//The Revision of config is the expected revision of the record (zero patches unconditionally), on success it is set to the new revision
+func (r *MongoDBConfigRepoImpl) Patch(ctx context.Context, config *entitie.Mongodb, fields []string) (string, error) {
+	db := withContext(ctx, r.DB)
+	if err := patchRecord(ctx, db, "mongodbs", "domain", config.Domain, config, fields, &config.Revision); err != nil {
+		return "", err
 	}
-	return "", errors.New("fields are empty")
+	return "OK", nil
 }
 
 //Find returns a config record from database using the unique name
-func (r *TempConfigRepoImpl) Find(configName string) (*entitie.Tempconfig, error) {
+func (r *TempConfigRepoImpl) Find(ctx context.Context, configName string) (*entitie.Tempconfig, error) {
+	db := withContext(ctx, r.DB)
 	result := entitie.Tempconfig{}
-	err := r.DB.Where("rest_api_root = ?", configName).Find(&result).Error
+	err := db.Where("rest_api_root = ?", configName).Find(&result).Error
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
 	}
 	return &result, nil
 }
 
 //FindAll returns all config record of one type from database
-func (r *TempConfigRepoImpl) FindAll() ([]entitie.Tempconfig, error) {
+func (r *TempConfigRepoImpl) FindAll(ctx context.Context) ([]entitie.Tempconfig, error) {
+	db := withContext(ctx, r.DB)
 	var confSlice []entitie.Tempconfig
-	err := r.DB.Find(&confSlice).Error
+	err := db.Find(&confSlice).Error
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
 	}
 	return confSlice, nil
 }
 
-//Save saves new config record to the database
-func (r *TempConfigRepoImpl) Save(config *entitie.Tempconfig) (string, error) {
-	err := r.DB.Create(config).Error
+//Iterate calls fn for every config record matching the filters of options while reading them from a database cursor, so memory use does not grow with the number of records.
+//It returns a token for the next page, which is empty on the last page. Iteration stops at the first error returned by fn
+func (r *TempConfigRepoImpl) Iterate(ctx context.Context, options ListOptions, fn func(config *entitie.Tempconfig) error) (string, error) {
+	db := withContext(ctx, r.DB)
+	query, offset, err := listQuery(db, tempconfigColumns, options)
 	if err != nil {
-		log.Printf("error during saving to database: %v", err)
 		return "", err
 	}
+	rows, err := query.Model(&entitie.Tempconfig{}).Rows()
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	defer rows.Close()
+	pageSize := limitPageSize(options.PageSize)
+	for count := 0; rows.Next(); count++ {
+		if count == pageSize && pageSize > 0 {
+			return encodePageToken(offset + pageSize), nil
+		}
+		var config entitie.Tempconfig
+		if err = db.ScanRows(rows, &config); err != nil {
+			return "", contextError(ctx, err)
+		}
+		if err = fn(&config); err != nil {
+			return "", err
+		}
+	}
+	return "", contextError(ctx, rows.Err())
+}
+
+//Count returns the number of config records in the database
+func (r *TempConfigRepoImpl) Count(ctx context.Context) (int, error) {
+	db := withContext(ctx, r.DB)
+	var count int
+	if err := db.Model(&entitie.Tempconfig{}).Count(&count).Error; err != nil {
+		return 0, contextError(ctx, err)
+	}
+	return count, nil
+}
+
+//Save saves new config record to the database, the record starts at revision 1
+func (r *TempConfigRepoImpl) Save(ctx context.Context, config *entitie.Tempconfig) (string, error) {
+	if err := ValidateTempconfig(config); err != nil {
+		return "", err
+	}
+	db := withContext(ctx, r.DB)
+	config.Revision = 1
+	err := db.Create(config).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return "", contextError(ctx, err)
+	}
 	return "OK", nil
 }
 
-//Delete removes config record from database
-func (r *TempConfigRepoImpl) Delete(configName string) (string, error) {
-	rowsAffected := r.DB.Delete(entitie.Tempconfig{}, "rest_api_root = ?", configName).RowsAffected
+//Delete removes config record from database if it has the expected revision, zero expectedRevision deletes unconditionally
+func (r *TempConfigRepoImpl) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
+	db := withContext(ctx, r.DB)
+	condition, args := revisionCondition("rest_api_root = ?", expectedRevision, configName)
+	result := db.Delete(entitie.Tempconfig{}, append([]interface{}{condition}, args...)...)
+	if result.Error != nil {
+		return "", contextError(ctx, result.Error)
+	}
+	rowsAffected := result.RowsAffected
 	if rowsAffected < 1 {
-		return "", errors.New("could not delete from database")
+		if expectedRevision > 0 && !db.Where("rest_api_root = ?", configName).Find(&entitie.Tempconfig{}).RecordNotFound() {
+			return "", ErrRevisionMismatch
+		}
+		return "", ErrNotFound
 	}
 	return fmt.Sprintf("deleted %d row(s)", rowsAffected), nil
 }
 
-//Update updates a record in database, rewriting the fields if string fields are not empty
-func (r *TempConfigRepoImpl) Update(newConfig *entitie.Tempconfig) (string, error) {
+//Update rewrites all fields of a record in database, if newConfig follows the rules of Tempconfigs.
+//The Revision of newConfig is the expected revision of the record (zero updates unconditionally), on success it is set to the new revision
+func (r *TempConfigRepoImpl) Update(ctx context.Context, newConfig *entitie.Tempconfig) (string, error) {
+	db := withContext(ctx, r.DB)
 	var persistedConfig entitie.Tempconfig
-	err := r.DB.Where("rest_api_root = ?", newConfig.RestApiRoot).Find(&persistedConfig).Error
+	err := db.Where("rest_api_root = ?", newConfig.RestApiRoot).Find(&persistedConfig).Error
 	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	if newConfig.Revision > 0 && newConfig.Revision != persistedConfig.Revision {
+		return "", ErrRevisionMismatch
+	}
+	if err = ValidateTempconfig(newConfig); err != nil {
 		return "", err
 	}
-	if newConfig.Host != "" && newConfig.Port != "" && newConfig.Remoting != "" {
-		err = r.DB.Exec("UPDATE tempconfigs SET remoting = ?, port = ?, host = ?, legasy_explorer = ? WHERE rest_api_root = ?", newConfig.Remoting, newConfig.Port, newConfig.Host, strconv.FormatBool(newConfig.LegasyExplorer), persistedConfig.RestApiRoot).Error
-		if err != nil {
-			log.Printf("error during saving to database: %v", err)
-			return "", err
-		}
-		return "OK", nil
+	query, args := revisionCondition("UPDATE tempconfigs SET remoting = ?, port = ?, host = ?, legacy_explorer = ?, variants = ?, labels = ?, annotations = ?, revision = revision + 1 WHERE rest_api_root = ?", newConfig.Revision,
+		newConfig.Remoting, newConfig.Port, newConfig.Host, strconv.FormatBool(newConfig.LegacyExplorer), newConfig.Variants, newConfig.Labels, newConfig.Annotations, persistedConfig.RestApiRoot)
+	err = db.Raw(query+" RETURNING revision", args...).Row().Scan(&newConfig.Revision)
+	if err == sql.ErrNoRows {
+		return "", ErrRevisionMismatch
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return "", contextError(ctx, err)
+	}
+	return "OK", nil
+}
+
+//Patch writes only the named fields of config, given by their JSON names, leaving the other fields of the record unchanged.
+//The Revision of config is the expected revision of the record (zero patches unconditionally), on success it is set to the new revision
+func (r *TempConfigRepoImpl) Patch(ctx context.Context, config *entitie.Tempconfig, fields []string) (string, error) {
+	db := withContext(ctx, r.DB)
+	if err := patchRecord(ctx, db, "tempconfigs", "rest_api_root", config.RestApiRoot, config, fields, &config.Revision); err != nil {
+		return "", err
 	}
-	return "", errors.New("fields are empty")
+	return "OK", nil
 }
 
 //Find returns a config record from database using the unique name
-func (r *TsConfigRepoImpl) Find(configName string) (*entitie.Tsconfig, error) {
+func (r *TsConfigRepoImpl) Find(ctx context.Context, configName string) (*entitie.Tsconfig, error) {
+	db := withContext(ctx, r.DB)
 	result := entitie.Tsconfig{}
-	err := r.DB.Where("module = ?", configName).Find(&result).Error
+	err := db.Where("module = ?", configName).Find(&result).Error
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
 	}
 	return &result, nil
 }
 
 //FindAll returns all config record of one type from database
-func (r *TsConfigRepoImpl) FindAll() ([]entitie.Tsconfig, error) {
+func (r *TsConfigRepoImpl) FindAll(ctx context.Context) ([]entitie.Tsconfig, error) {
+	db := withContext(ctx, r.DB)
 	var confSlice []entitie.Tsconfig
-	err := r.DB.Find(&confSlice).Error
+	err := db.Find(&confSlice).Error
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
 	}
 	return confSlice, nil
 }
 
-//Save saves new config record to the database
-func (r *TsConfigRepoImpl) Save(config *entitie.Tsconfig) (string, error) {
-	err := r.DB.Create(config).Error
+//Iterate calls fn for every config record matching the filters of options while reading them from a database cursor, so memory use does not grow with the number of records.
+//It returns a token for the next page, which is empty on the last page. Iteration stops at the first error returned by fn
+func (r *TsConfigRepoImpl) Iterate(ctx context.Context, options ListOptions, fn func(config *entitie.Tsconfig) error) (string, error) {
+	db := withContext(ctx, r.DB)
+	query, offset, err := listQuery(db, tsconfigColumns, options)
 	if err != nil {
-		log.Printf("error during saving to database: %v", err)
 		return "", err
 	}
+	rows, err := query.Model(&entitie.Tsconfig{}).Rows()
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	defer rows.Close()
+	pageSize := limitPageSize(options.PageSize)
+	for count := 0; rows.Next(); count++ {
+		if count == pageSize && pageSize > 0 {
+			return encodePageToken(offset + pageSize), nil
+		}
+		var config entitie.Tsconfig
+		if err = db.ScanRows(rows, &config); err != nil {
+			return "", contextError(ctx, err)
+		}
+		if err = fn(&config); err != nil {
+			return "", err
+		}
+	}
+	return "", contextError(ctx, rows.Err())
+}
+
+//Count returns the number of config records in the database
+func (r *TsConfigRepoImpl) Count(ctx context.Context) (int, error) {
+	db := withContext(ctx, r.DB)
+	var count int
+	if err := db.Model(&entitie.Tsconfig{}).Count(&count).Error; err != nil {
+		return 0, contextError(ctx, err)
+	}
+	return count, nil
+}
+
+//Save saves new config record to the database, the record starts at revision 1
+func (r *TsConfigRepoImpl) Save(ctx context.Context, config *entitie.Tsconfig) (string, error) {
+	if err := ValidateTsconfig(config); err != nil {
+		return "", err
+	}
+	db := withContext(ctx, r.DB)
+	config.Revision = 1
+	err := db.Create(config).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return "", contextError(ctx, err)
+	}
 	return "OK", nil
 }
 
-//Delete removes config record from database
-func (r *TsConfigRepoImpl) Delete(configName string) (string, error) {
-	rowsAffected := r.DB.Delete(entitie.Tsconfig{}, "module = ?", configName).RowsAffected
+//Delete removes config record from database if it has the expected revision, zero expectedRevision deletes unconditionally
+func (r *TsConfigRepoImpl) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
+	db := withContext(ctx, r.DB)
+	condition, args := revisionCondition("module = ?", expectedRevision, configName)
+	result := db.Delete(entitie.Tsconfig{}, append([]interface{}{condition}, args...)...)
+	if result.Error != nil {
+		return "", contextError(ctx, result.Error)
+	}
+	rowsAffected := result.RowsAffected
 	if rowsAffected < 1 {
-		return "", errors.New("could not delete from database")
+		if expectedRevision > 0 && !db.Where("module = ?", configName).Find(&entitie.Tsconfig{}).RecordNotFound() {
+			return "", ErrRevisionMismatch
+		}
+		return "", ErrNotFound
 	}
 	return fmt.Sprintf("deleted %d row(s)", rowsAffected), nil
 }
 
-//Update updates a record in database, rewriting the fields if string fields are not empty
-func (r *TsConfigRepoImpl) Update(newConfig *entitie.Tsconfig) (string, error) {
+//Update updates a record in database, rewriting all of its fields. newConfig has to follow the rules of tsconfigs.
+//The Revision of newConfig is the expected revision of the record (zero updates unconditionally), on success it is set to the new revision
+func (r *TsConfigRepoImpl) Update(ctx context.Context, newConfig *entitie.Tsconfig) (string, error) {
+	if err := ValidateTsconfig(newConfig); err != nil {
+		return "", err
+	}
+	db := withContext(ctx, r.DB)
 	var persistedConfig entitie.Tsconfig
-	err := r.DB.Where("module = ?", newConfig.Module).Find(&persistedConfig).Error
+	err := db.Where("module = ?", newConfig.Module).Find(&persistedConfig).Error
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	if newConfig.Revision > 0 && newConfig.Revision != persistedConfig.Revision {
+		return "", ErrRevisionMismatch
+	}
+	query, args := revisionCondition("UPDATE tsconfigs SET target = ?, source_map = ?, excluding = ?, extends = ?, compiler_options = ?, files = ?, include = ?, exclude = ?, variants = ?, labels = ?, annotations = ?, revision = revision + 1 WHERE module = ?", newConfig.Revision,
+		newConfig.Target, strconv.FormatBool(newConfig.SourceMap), strconv.Itoa(newConfig.Excluding), newConfig.Extends, newConfig.CompilerOptions, newConfig.Files, newConfig.Include, newConfig.Exclude, newConfig.Variants, newConfig.Labels, newConfig.Annotations, persistedConfig.Module)
+	err = db.Raw(query+" RETURNING revision", args...).Row().Scan(&newConfig.Revision)
+	if err == sql.ErrNoRows {
+		return "", ErrRevisionMismatch
+	}
 	if err != nil {
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return "", contextError(ctx, err)
+	}
+	return "OK", nil
+}
+
+//Patch writes only the named fields of config, given by their JSON names, leaving the other fields of the record unchanged.
+//The Revision of config is the expected revision of the record (zero patches unconditionally), on success it is set to the new revision
+func (r *TsConfigRepoImpl) Patch(ctx context.Context, config *entitie.Tsconfig, fields []string) (string, error) {
+	db := withContext(ctx, r.DB)
+	if err := patchRecord(ctx, db, "tsconfigs", "module", config.Module, config, fields, &config.Revision); err != nil {
 		return "", err
 	}
-	if newConfig.Target != "" {
-		err = r.DB.Exec("UPDATE tsconfigs SET target = ?, source_map = ?, excluding = ? WHERE module = ?", newConfig.Target, strconv.FormatBool(newConfig.SourceMap), strconv.Itoa(newConfig.Excluding), persistedConfig.Module).Error
-		if err != nil {
-			log.Printf("error during saving to database: %v", err)
+	return "OK", nil
+}
+
+//Find returns a feature flag record from database using its key
+func (r *FeatureFlagRepoImpl) Find(ctx context.Context, configName string) (*entitie.Featureflag, error) {
+	db := withContext(ctx, r.DB)
+	result := entitie.Featureflag{}
+	err := db.Where("key = ?", configName).Find(&result).Error
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &result, nil
+}
+
+//FindAll returns all feature flag records from database
+func (r *FeatureFlagRepoImpl) FindAll(ctx context.Context) ([]entitie.Featureflag, error) {
+	db := withContext(ctx, r.DB)
+	var confSlice []entitie.Featureflag
+	err := db.Find(&confSlice).Error
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return confSlice, nil
+}
+
+//Iterate calls fn for every feature flag record matching the filters of options while reading them from a database cursor, so memory use does not grow with the number of records.
+//It returns a token for the next page, which is empty on the last page. Iteration stops at the first error returned by fn
+func (r *FeatureFlagRepoImpl) Iterate(ctx context.Context, options ListOptions, fn func(config *entitie.Featureflag) error) (string, error) {
+	db := withContext(ctx, r.DB)
+	query, offset, err := listQuery(db, featureflagColumns, options)
+	if err != nil {
+		return "", err
+	}
+	rows, err := query.Model(&entitie.Featureflag{}).Rows()
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	defer rows.Close()
+	pageSize := limitPageSize(options.PageSize)
+	for count := 0; rows.Next(); count++ {
+		if count == pageSize && pageSize > 0 {
+			return encodePageToken(offset + pageSize), nil
+		}
+		var config entitie.Featureflag
+		if err = db.ScanRows(rows, &config); err != nil {
+			return "", contextError(ctx, err)
+		}
+		if err = fn(&config); err != nil {
 			return "", err
 		}
-		return "OK", nil
 	}
-	return "", errors.New("fields are empty")
+	return "", contextError(ctx, rows.Err())
+}
+
+//Count returns the number of config records in the database
+func (r *FeatureFlagRepoImpl) Count(ctx context.Context) (int, error) {
+	db := withContext(ctx, r.DB)
+	var count int
+	if err := db.Model(&entitie.Featureflag{}).Count(&count).Error; err != nil {
+		return 0, contextError(ctx, err)
+	}
+	return count, nil
+}
+
+//Save saves new feature flag record to the database, the record starts at revision 1
+func (r *FeatureFlagRepoImpl) Save(ctx context.Context, config *entitie.Featureflag) (string, error) {
+	if err := ValidateFeatureflag(config); err != nil {
+		return "", err
+	}
+	db := withContext(ctx, r.DB)
+	config.Revision = 1
+	err := db.Create(config).Error
+	if err != nil {
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return "", contextError(ctx, err)
+	}
+	return "OK", nil
+}
+
+//Delete removes feature flag record from database if it has the expected revision, zero expectedRevision deletes unconditionally
+func (r *FeatureFlagRepoImpl) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
+	db := withContext(ctx, r.DB)
+	condition, args := revisionCondition("key = ?", expectedRevision, configName)
+	result := db.Delete(entitie.Featureflag{}, append([]interface{}{condition}, args...)...)
+	if result.Error != nil {
+		return "", contextError(ctx, result.Error)
+	}
+	rowsAffected := result.RowsAffected
+	if rowsAffected < 1 {
+		if expectedRevision > 0 && !db.Where("key = ?", configName).Find(&entitie.Featureflag{}).RecordNotFound() {
+			return "", ErrRevisionMismatch
+		}
+		return "", ErrNotFound
+	}
+	return fmt.Sprintf("deleted %d row(s)", rowsAffected), nil
+}
+
+//Update rewrites all fields of a feature flag record in database.
+//The Revision of newConfig is the expected revision of the record (zero updates unconditionally), on success it is set to the new revision
+func (r *FeatureFlagRepoImpl) Update(ctx context.Context, newConfig *entitie.Featureflag) (string, error) {
+	db := withContext(ctx, r.DB)
+	var persistedConfig entitie.Featureflag
+	err := db.Where("key = ?", newConfig.Key).Find(&persistedConfig).Error
+	if err != nil {
+		return "", contextError(ctx, err)
+	}
+	if newConfig.Revision > 0 && newConfig.Revision != persistedConfig.Revision {
+		return "", ErrRevisionMismatch
+	}
+	if err = ValidateFeatureflag(newConfig); err != nil {
+		return "", err
+	}
+	query, args := revisionCondition("UPDATE featureflags SET enabled = ?, variants = ?, off_variant = ?, default_variant = ?, rollout = ?, rules = ?, labels = ?, annotations = ?, revision = revision + 1 WHERE key = ?", newConfig.Revision,
+		strconv.FormatBool(newConfig.Enabled), newConfig.Variants, newConfig.OffVariant, newConfig.DefaultVariant, newConfig.Rollout, newConfig.Rules, newConfig.Labels, newConfig.Annotations, persistedConfig.Key)
+	err = db.Raw(query+" RETURNING revision", args...).Row().Scan(&newConfig.Revision)
+	if err == sql.ErrNoRows {
+		return "", ErrRevisionMismatch
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "error during saving to database", "error", err)
+		return "", contextError(ctx, err)
+	}
+	return "OK", nil
+}
+
+//Patch writes only the named fields of config, given by their JSON names, leaving the other fields of the record unchanged.
+//The Revision of config is the expected revision of the record (zero patches unconditionally), on success it is set to the new revision
+func (r *FeatureFlagRepoImpl) Patch(ctx context.Context, config *entitie.Featureflag, fields []string) (string, error) {
+	db := withContext(ctx, r.DB)
+	if err := patchRecord(ctx, db, "featureflags", "key", config.Key, config, fields, &config.Revision); err != nil {
+		return "", err
+	}
+	return "OK", nil
 }
@@ -2,14 +2,19 @@
 package repository
 
 import (
+	"context"
+	"database/sql/driver"
 	"log"
+	"net"
 
 	"fmt"
 	"regexp"
 
 	"testing"
 
+	"github.com/YAWAL/GetMeConf/database"
 	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/selector"
 
 	"github.com/jinzhu/gorm"
 
@@ -17,7 +22,13 @@ import (
 
 	"strconv"
 
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"gopkg.in/DATA-DOG/go-sqlmock.v1"
 )
 
@@ -43,10 +54,10 @@ func formatRequest(s string) string {
 func TestFind(t *testing.T) {
 	m, db, _ := newDB()
 	mongoRepo := MongoDBConfigRepoImpl{DB: db}
-	mongodbConfig := entitie.Mongodb{Domain: "testDomain", Mongodb: true, Host: "testHost", Port: "testPort"}
+	mongodbConfig := entitie.Mongodb{Domain: "testDomain", Host: "testHost", Port: 8080}
 	mongoRows := getMongoDBRows(mongodbConfig.Domain)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).WithArgs("testDomain").WillReturnRows(mongoRows)
-	returnedMongoConfigs, err := mongoRepo.Find("testDomain")
+	returnedMongoConfigs, err := mongoRepo.Find(context.Background(), "testDomain")
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -55,7 +66,7 @@ func TestFind(t *testing.T) {
 	configName := "notExistingConfig"
 	expectedError := errors.New("db error")
 	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).WithArgs("notExistingConfig").WillReturnError(expectedError)
-	_, returnedErr := mongoRepo.Find(configName)
+	_, returnedErr := mongoRepo.Find(context.Background(), configName)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
@@ -64,7 +75,7 @@ func TestFind(t *testing.T) {
 	tsConfig := entitie.Tsconfig{Module: "testModule", Target: "testTarget", SourceMap: true, Excluding: 1}
 	tsRows := getTsConfigRows(tsConfig.Module)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\" WHERE (module = $1)")).WithArgs("testModule").WillReturnRows(tsRows)
-	returnedTsConfigs, err := tsRepo.Find("testModule")
+	returnedTsConfigs, err := tsRepo.Find(context.Background(), "testModule")
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -74,16 +85,16 @@ func TestFind(t *testing.T) {
 	configName = "notExistingConfig"
 	expectedError = errors.New("db error")
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\" WHERE (module = $1)")).WithArgs("notExistingConfig").WillReturnError(expectedError)
-	_, returnedErr = tsRepo.Find(configName)
+	_, returnedErr = tsRepo.Find(context.Background(), configName)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
 
 	tempRepo := TempConfigRepoImpl{DB: db}
-	tempConfig := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
+	tempConfig := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
 	tempRows := getTempConfigRows(tempConfig.RestApiRoot)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\" WHERE (rest_api_root = $1)")).WithArgs("testRestApiRoot").WillReturnRows(tempRows)
-	returnedTempConfigs, err := tempRepo.Find("testRestApiRoot")
+	returnedTempConfigs, err := tempRepo.Find(context.Background(), "testRestApiRoot")
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -93,7 +104,7 @@ func TestFind(t *testing.T) {
 	configName = "notExistingConfig"
 	expectedError = errors.New("db error")
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\" WHERE (rest_api_root = $1)")).WithArgs("notExistingConfig").WillReturnError(expectedError)
-	_, returnedErr = tempRepo.Find(configName)
+	_, returnedErr = tempRepo.Find(context.Background(), configName)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
@@ -103,11 +114,11 @@ func TestFind(t *testing.T) {
 func TestFindAll(t *testing.T) {
 	m, db, _ := newDB()
 	mongoRepo := MongoDBConfigRepoImpl{DB: db}
-	mongodbConfig := entitie.Mongodb{Domain: "testDomain", Mongodb: true, Host: "testHost", Port: "testPort"}
+	mongodbConfig := entitie.Mongodb{Domain: "testDomain", Host: "testHost", Port: 8080}
 	mongoRows := getMongoDBRows(mongodbConfig.Domain)
 	expConfigs := []entitie.Mongodb{mongodbConfig}
 	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\"")).WillReturnRows(mongoRows)
-	returnedMongoConfigs, err := mongoRepo.FindAll()
+	returnedMongoConfigs, err := mongoRepo.FindAll(context.Background())
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -115,7 +126,7 @@ func TestFindAll(t *testing.T) {
 
 	expectedError := errors.New("db error")
 	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\"")).WillReturnError(expectedError)
-	_, returnedErr := mongoRepo.FindAll()
+	_, returnedErr := mongoRepo.FindAll(context.Background())
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
@@ -125,7 +136,7 @@ func TestFindAll(t *testing.T) {
 	tsRows := getTsConfigRows(tsConfig.Module)
 	expTsConfigs := []entitie.Tsconfig{tsConfig}
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\"")).WillReturnRows(tsRows)
-	returnedTsConfigs, err := tsRepo.FindAll()
+	returnedTsConfigs, err := tsRepo.FindAll(context.Background())
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -133,17 +144,17 @@ func TestFindAll(t *testing.T) {
 
 	expectedError = errors.New("db error")
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\"")).WillReturnError(expectedError)
-	_, returnedErr = tsRepo.FindAll()
+	_, returnedErr = tsRepo.FindAll(context.Background())
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
 
 	tempRepo := TempConfigRepoImpl{DB: db}
-	tempConfig := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
+	tempConfig := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
 	tempRows := getTempConfigRows(tempConfig.RestApiRoot)
 	expTempConfigs := []entitie.Tempconfig{tempConfig}
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\"")).WillReturnRows(tempRows)
-	returnedTempConfigs, err := tempRepo.FindAll()
+	returnedTempConfigs, err := tempRepo.FindAll(context.Background())
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -152,42 +163,215 @@ func TestFindAll(t *testing.T) {
 
 	expectedError = errors.New("db error")
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\"")).WillReturnError(expectedError)
-	_, returnedErr = tempRepo.FindAll()
+	_, returnedErr = tempRepo.FindAll(context.Background())
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
 
 }
 
+func TestIterate(t *testing.T) {
+	m, db, _ := newDB()
+	mongoRepo := MongoDBConfigRepoImpl{DB: db}
+	var returnedMongoConfigs []entitie.Mongodb
+	collectMongo := func(config *entitie.Mongodb) error {
+		returnedMongoConfigs = append(returnedMongoConfigs, *config)
+		return nil
+	}
+	mongoRows := getMongoDBRows("testDomain").AddRow("testDomain2", "testHost", int64(8080))
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (host = $1) AND (domain LIKE $2) ORDER BY port DESC,domain DESC LIMIT 2")).
+		WithArgs("testHost", `/home\_%`).WillReturnRows(mongoRows)
+	options := ListOptions{
+		PageSize:   1,
+		Filters:    []Filter{{Field: "host", Operator: OperatorEqual, Value: "testHost"}, {Field: "name", Operator: OperatorPrefix, Value: "/home_"}},
+		OrderBy:    "port",
+		Descending: true,
+	}
+	token, err := mongoRepo.Iterate(context.Background(), options, collectMongo)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []entitie.Mongodb{{Domain: "testDomain", Host: "testHost", Port: 8080}}, returnedMongoConfigs)
+	assert.NotEmpty(t, token)
+
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (host = $1) AND (domain LIKE $2) ORDER BY port DESC,domain DESC LIMIT 2 OFFSET 1")).
+		WithArgs("testHost", `/home\_%`).WillReturnRows(getMongoDBRows("testDomain2"))
+	options.PageToken = token
+	returnedMongoConfigs = nil
+	token, err = mongoRepo.Iterate(context.Background(), options, collectMongo)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, 1, len(returnedMongoConfigs))
+	assert.Empty(t, token)
+
+	_, err = mongoRepo.Iterate(context.Background(), ListOptions{PageToken: "invalid token"}, collectMongo)
+	assert.Error(t, err)
+	_, err = mongoRepo.Iterate(context.Background(), ListOptions{Filters: []Filter{{Field: "password", Operator: OperatorEqual}}}, collectMongo)
+	assert.Error(t, err)
+	_, err = mongoRepo.Iterate(context.Background(), ListOptions{Filters: []Filter{{Field: "tls", Operator: OperatorPrefix, Value: "t"}}}, collectMongo)
+	assert.Error(t, err)
+	_, err = mongoRepo.Iterate(context.Background(), ListOptions{OrderBy: "password"}, collectMongo)
+	assert.Error(t, err)
+
+	expectedError := errors.New("stream error")
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" ORDER BY domain ASC")).
+		WillReturnRows(getMongoDBRows("testDomain").AddRow("testDomain2", "testHost", int64(8080)))
+	calls := 0
+	_, returnedErr := mongoRepo.Iterate(context.Background(), ListOptions{}, func(config *entitie.Mongodb) error {
+		calls++
+		return expectedError
+	})
+	if assert.Error(t, returnedErr) {
+		assert.Equal(t, expectedError, returnedErr)
+	}
+	assert.Equal(t, 1, calls, "iteration must stop at the first error")
+
+	tsRepo := TsConfigRepoImpl{DB: db}
+	var returnedTsConfigs []entitie.Tsconfig
+	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\" WHERE (source_map = $1) ORDER BY module ASC")).
+		WithArgs("true").WillReturnRows(getTsConfigRows("testModule"))
+	token, err = tsRepo.Iterate(context.Background(), ListOptions{Filters: []Filter{{Field: "sourceMap", Operator: OperatorEqual, Value: "true"}}}, func(config *entitie.Tsconfig) error {
+		returnedTsConfigs = append(returnedTsConfigs, *config)
+		return nil
+	})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []entitie.Tsconfig{{Module: "testModule", Target: "testTarget", SourceMap: true, Excluding: 1}}, returnedTsConfigs)
+	assert.Empty(t, token)
+
+	tempRepo := TempConfigRepoImpl{DB: db}
+	expectedError = errors.New("db error")
+	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\" ORDER BY rest_api_root ASC")).WillReturnError(expectedError)
+	_, returnedErr = tempRepo.Iterate(context.Background(), ListOptions{}, func(config *entitie.Tempconfig) error { return nil })
+	if assert.Error(t, returnedErr) {
+		assert.Equal(t, expectedError, returnedErr)
+	}
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestIterateLabelSelector(t *testing.T) {
+	m, db, _ := newDB()
+	flagRepo := FeatureFlagRepoImpl{DB: db}
+	labelSelector, err := selector.Parse("team=orders,env notin (dev,test),owner,!deprecated")
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	m.ExpectQuery(formatRequest(`SELECT * FROM "featureflags" WHERE (enabled = $1) AND (labels @> $2) AND (NOT COALESCE(labels @> $3 OR labels @> $4, false)) `+
+		`AND (labels ? 'owner') AND (NOT COALESCE(labels ? 'deprecated', false)) ORDER BY key ASC`)).
+		WithArgs("true", `{"team":"orders"}`, `{"env":"dev"}`, `{"env":"test"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"key", "labels", "annotations"}).AddRow("new-checkout", `{"team":"orders","owner":"ann"}`, `{"ticket":"SHOP-12"}`))
+	var returnedFlags []entitie.Featureflag
+	_, err = flagRepo.Iterate(context.Background(), ListOptions{Filters: []Filter{{Field: "enabled", Operator: OperatorEqual, Value: "true"}}, Selector: labelSelector},
+		func(config *entitie.Featureflag) error {
+			returnedFlags = append(returnedFlags, *config)
+			return nil
+		})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []entitie.Featureflag{{Key: "new-checkout", Labels: entitie.Labels{"team": "orders", "owner": "ann"}, Annotations: entitie.Annotations{"ticket": "SHOP-12"}}}, returnedFlags)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+//benchmarkRows is the size of the seeded table used by the listing benchmarks. FindAll holds all of the rows at once, Iterate only the current one
+const benchmarkRows = 10000
+
+func seededMongoDBRows() *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"domain", "host", "port"})
+	for i := 0; i < benchmarkRows; i++ {
+		rows.AddRow("domain"+strconv.Itoa(i), "testHost", int64(8080))
+	}
+	return rows
+}
+
+func BenchmarkFindAll(b *testing.B) {
+	b.ReportAllocs()
+	m, db, _ := newDB()
+	db.LogMode(false)
+	mongoRepo := MongoDBConfigRepoImpl{DB: db}
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m.ExpectQuery("SELECT").WillReturnRows(seededMongoDBRows())
+		b.StartTimer()
+		if _, err := mongoRepo.FindAll(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIterate(b *testing.B) {
+	b.ReportAllocs()
+	m, db, _ := newDB()
+	db.LogMode(false)
+	mongoRepo := MongoDBConfigRepoImpl{DB: db}
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		m.ExpectQuery("SELECT").WillReturnRows(seededMongoDBRows())
+		b.StartTimer()
+		_, err := mongoRepo.Iterate(context.Background(), ListOptions{}, func(config *entitie.Mongodb) error {
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+//mongodbInsert is the statement saving a MongoDB config
+const mongodbInsert = `INSERT INTO "mongodbs" ("domain","host","port","members","replica_set","database","auth_source","username","password_secret","tls",` +
+	`"read_preference","variants","labels","annotations","revision") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15) RETURNING "mongodbs"."id"`
+
+func TestCount(t *testing.T) {
+	m, db, _ := newDB()
+	m.ExpectQuery(formatRequest("SELECT count(*) FROM \"mongodbs\"")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(3)))
+	count, err := (&MongoDBConfigRepoImpl{DB: db}).Count(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, 3, count)
+	}
+	m.ExpectQuery(formatRequest("SELECT count(*) FROM \"featureflags\"")).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(int64(0)))
+	count, err = (&FeatureFlagRepoImpl{DB: db}).Count(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, 0, count)
+	}
+	expectedError := errors.New("db error")
+	m.ExpectQuery(formatRequest("SELECT count(*) FROM \"tsconfigs\"")).WillReturnError(expectedError)
+	_, err = (&TsConfigRepoImpl{DB: db}).Count(context.Background())
+	assert.Equal(t, expectedError, err)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
 func TestSave(t *testing.T) {
 	m, db, _ := newDB()
 	mockRepo := MongoDBConfigRepoImpl{DB: db}
-	mongodbConfig := entitie.Mongodb{Domain: "testDomain", Mongodb: true, Host: "testHost", Port: "testPort"}
-	m.ExpectExec(formatRequest("INSERT INTO \"mongodbs\" (\"domain\",\"mongodb\",\"host\",\"port\") VALUES ($1,$2,$3,$4) RETURNING \"mongodbs\".*")).
-		WithArgs("testDomain", true, "testHost", "testPort").
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	result, err := mockRepo.Save(&mongodbConfig)
+	mongodbConfig := entitie.Mongodb{Domain: "testDomain", Host: "testHost", Port: 8080}
+	m.ExpectQuery(formatRequest(mongodbInsert)).
+		WithArgs("testDomain", "testHost", 8080, nil, "", "", "", "", "", false, "", nil, nil, nil, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	result, err := mockRepo.Save(context.Background(), &mongodbConfig)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
 	assert.Equal(t, "OK", result)
+	assert.Equal(t, int64(1), mongodbConfig.ID, "saved configs get the surrogate id of their row")
 
-	mongodbConfigErr := entitie.Mongodb{Domain: "testDomainError", Mongodb: true, Host: "testHost", Port: "testPort"}
+	mongodbConfigErr := entitie.Mongodb{Domain: "testDomainError", Host: "testHost", Port: 8080}
 	expectedError := errors.New("db error")
-	m.ExpectExec(formatRequest("INSERT INTO \"mongodbs\" (\"domain\",\"mongodb\",\"host\",\"port\") VALUES ($1,$2,$3,$4) RETURNING \"mongodbs\".*")).
-		WithArgs("testDomainError", true, "testHost", "testPort").
+	m.ExpectQuery(formatRequest(mongodbInsert)).
+		WithArgs("testDomainError", "testHost", 8080, nil, "", "", "", "", "", false, "", nil, nil, nil, 1).
 		WillReturnError(expectedError)
-	_, returnedErr := mockRepo.Save(&mongodbConfigErr)
+	_, returnedErr := mockRepo.Save(context.Background(), &mongodbConfigErr)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
 
 	tsRepo := TsConfigRepoImpl{DB: db}
 	tsConfig := entitie.Tsconfig{Module: "testModule", Target: "testTarget", SourceMap: true, Excluding: 1}
-	m.ExpectExec(formatRequest("INSERT INTO \"tsconfigs\" (\"module\",\"target\",\"source_map\",\"excluding\") VALUES ($1,$2,$3,$4) RETURNING \"tsconfigs\".*")).
-		WithArgs("testModule", "testTarget", true, 1).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	result, err = tsRepo.Save(&tsConfig)
+	m.ExpectQuery(formatRequest("INSERT INTO \"tsconfigs\" (\"module\",\"target\",\"source_map\",\"excluding\",\"extends\",\"compiler_options\",\"files\",\"include\",\"exclude\",\"variants\",\"labels\",\"annotations\",\"revision\") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13) RETURNING \"tsconfigs\".\"id\"")).
+		WithArgs("testModule", "testTarget", true, 1, "", nil, nil, nil, nil, nil, nil, nil, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	result, err = tsRepo.Save(context.Background(), &tsConfig)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -195,36 +379,64 @@ func TestSave(t *testing.T) {
 
 	tsConfigErr := entitie.Tsconfig{Module: "testModuleError", Target: "testTarget", SourceMap: true, Excluding: 1}
 	expectedError = errors.New("db error")
-	m.ExpectExec(formatRequest("INSERT INTO \"tsconfigs\" (\"module\",\"target\",\"source_map\",\"excluding\") VALUES ($1,$2,$3,$4) RETURNING \"tsconfigs\".*")).
-		WithArgs("testModuleError", "testTarget", true, 1).
+	m.ExpectQuery(formatRequest("INSERT INTO \"tsconfigs\" (\"module\",\"target\",\"source_map\",\"excluding\",\"extends\",\"compiler_options\",\"files\",\"include\",\"exclude\",\"variants\",\"labels\",\"annotations\",\"revision\") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13) RETURNING \"tsconfigs\".\"id\"")).
+		WithArgs("testModuleError", "testTarget", true, 1, "", nil, nil, nil, nil, nil, nil, nil, 1).
 		WillReturnError(expectedError)
-	_, returnedErr = tsRepo.Save(&tsConfigErr)
+	_, returnedErr = tsRepo.Save(context.Background(), &tsConfigErr)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
 
 	tempRepo := TempConfigRepoImpl{DB: db}
-	tempConfig := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
-	m.ExpectExec(formatRequest("INSERT INTO \"tempconfigs\" (\"rest_api_root\",\"host\",\"port\",\"remoting\",\"legasy_explorer\") VALUES ($1,$2,$3,$4,$5) RETURNING \"tempconfigs\".*")).
-		WithArgs("testApiRoot", "testHost", "testPort", "testRemoting", true).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	result, err = tempRepo.Save(&tempConfig)
+	tempConfig := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
+	m.ExpectQuery(formatRequest("INSERT INTO \"tempconfigs\" (\"rest_api_root\",\"host\",\"port\",\"remoting\",\"legacy_explorer\",\"variants\",\"labels\",\"annotations\",\"revision\") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING \"tempconfigs\".\"id\"")).
+		WithArgs("testApiRoot", "testHost", 8080, `{"context":true}`, true, nil, nil, nil, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	result, err = tempRepo.Save(context.Background(), &tempConfig)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
 	assert.Equal(t, "OK", result)
 
-	tempConfigErr := entitie.Tempconfig{RestApiRoot: "testApiRootError", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
+	tempConfigErr := entitie.Tempconfig{RestApiRoot: "testApiRootError", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
 	expectedError = errors.New("db error")
-	m.ExpectExec(formatRequest("INSERT INTO \"tempconfigs\" (\"rest_api_root\",\"host\",\"port\",\"remoting\",\"legasy_explorer\") VALUES ($1,$2,$3,$4,$5) RETURNING \"tempconfigs\".*")).
-		WithArgs("testApiRootError", "testHost", "testPort", "testRemoting", true).
+	m.ExpectQuery(formatRequest("INSERT INTO \"tempconfigs\" (\"rest_api_root\",\"host\",\"port\",\"remoting\",\"legacy_explorer\",\"variants\",\"labels\",\"annotations\",\"revision\") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING \"tempconfigs\".\"id\"")).
+		WithArgs("testApiRootError", "testHost", 8080, `{"context":true}`, true, nil, nil, nil, 1).
 		WillReturnError(expectedError)
-	_, returnedErr = tempRepo.Save(&tempConfigErr)
+	_, returnedErr = tempRepo.Save(context.Background(), &tempConfigErr)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
 }
 
+func TestTsConfigWritesValidate(t *testing.T) {
+	m, db, _ := newDB()
+	tsRepo := TsConfigRepoImpl{DB: db}
+	invalid := entitie.Tsconfig{Module: "web", Target: "es2017", Labels: entitie.Labels{"team name": "web"}}
+	_, err := tsRepo.Save(context.Background(), &invalid)
+	assert.Equal(t, &FieldError{Field: "labels", Description: `"team name" is not a label key`}, err)
+	_, err = tsRepo.Update(context.Background(), &invalid)
+	assert.Equal(t, &FieldError{Field: "labels", Description: `"team name" is not a label key`}, err)
+	assert.NoError(t, m.ExpectationsWereMet(), "invalid configs are not written")
+
+	for field, variants := range map[string]entitie.ConfigVariants{
+		"variants[1].name":          {{Name: "node", Match: entitie.Labels{"runtime": "node20"}}, {Name: "node", Match: entitie.Labels{"runtime": "node22"}}},
+		"variants[0].match":         {{Name: "node"}},
+		"variants[0].config.module": {{Name: "node", Match: entitie.Labels{"runtime": "node20"}, Config: entitie.JSONMap{"module": "esnext"}}},
+	} {
+		invalid = entitie.Tsconfig{Module: "web", Target: "es2017", Variants: variants}
+		_, err = tsRepo.Save(context.Background(), &invalid)
+		if assert.IsType(t, &FieldError{}, err, field) {
+			assert.Equal(t, field, err.(*FieldError).Field)
+		}
+		_, err = tsRepo.Update(context.Background(), &invalid)
+		if assert.IsType(t, &FieldError{}, err, field) {
+			assert.Equal(t, field, err.(*FieldError).Field)
+		}
+	}
+	assert.NoError(t, m.ExpectationsWereMet(), "tsconfigs with invalid variants are not written")
+}
+
 func TestDelete(t *testing.T) {
 	m, db, _ := newDB()
 	mockRepo := MongoDBConfigRepoImpl{DB: db}
@@ -232,7 +444,7 @@ func TestDelete(t *testing.T) {
 	testID := "testID"
 	m.ExpectExec(formatRequest("DELETE FROM \"" + testType + "s\" WHERE (domain = $1)")).
 		WithArgs("testID").WillReturnResult(sqlmock.NewResult(0, 1))
-	res, err := mockRepo.Delete(testID)
+	res, err := mockRepo.Delete(context.Background(), testID, 0)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -241,7 +453,7 @@ func TestDelete(t *testing.T) {
 	testID = "notExistingTestID"
 	m.ExpectExec(formatRequest("DELETE FROM \"" + testType + "s\" WHERE (domain = $1)")).
 		WithArgs("notExistingTestID").WillReturnError(errors.New("could not delete from database"))
-	_, returnedErr := mockRepo.Delete(testID)
+	_, returnedErr := mockRepo.Delete(context.Background(), testID, 0)
 	expectedError := errors.New("could not delete from database")
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
@@ -252,7 +464,7 @@ func TestDelete(t *testing.T) {
 	testID = "testID"
 	m.ExpectExec(formatRequest("DELETE FROM \"" + testType + "s\" WHERE (module = $1)")).
 		WithArgs("testID").WillReturnResult(sqlmock.NewResult(0, 1))
-	res, err = tsRepo.Delete(testID)
+	res, err = tsRepo.Delete(context.Background(), testID, 0)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -261,7 +473,7 @@ func TestDelete(t *testing.T) {
 	testID = "notExistingTestID"
 	m.ExpectExec(formatRequest("DELETE FROM \"" + testType + "s\" WHERE (module = $1)")).
 		WithArgs("notExistingTestID").WillReturnError(errors.New("could not delete from database"))
-	_, returnedErr = tsRepo.Delete(testID)
+	_, returnedErr = tsRepo.Delete(context.Background(), testID, 0)
 	expectedError = errors.New("could not delete from database")
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
@@ -272,7 +484,7 @@ func TestDelete(t *testing.T) {
 	testID = "testID"
 	m.ExpectExec(formatRequest("DELETE FROM \"" + testType + "s\" WHERE (rest_api_root = $1)")).
 		WithArgs("testID").WillReturnResult(sqlmock.NewResult(0, 1))
-	res, err = tempRepo.Delete(testID)
+	res, err = tempRepo.Delete(context.Background(), testID, 0)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -281,65 +493,69 @@ func TestDelete(t *testing.T) {
 	testID = "notExistingTestID"
 	m.ExpectExec(formatRequest("DELETE FROM \"" + testType + "s\" WHERE (rest_api_root = $1)")).
 		WithArgs("notExistingTestID").WillReturnError(errors.New("could not delete from database"))
-	_, returnedErr = tempRepo.Delete(testID)
+	_, returnedErr = tempRepo.Delete(context.Background(), testID, 0)
 	expectedError = errors.New("could not delete from database")
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedError, returnedErr)
 	}
 }
 
+//mongodbUpdate is the statement updating a MongoDB config
+const mongodbUpdate = "UPDATE mongodbs SET port = $1, host = $2, members = $3, replica_set = $4, database = $5, auth_source = $6, username = $7, " +
+	"password_secret = $8, tls = $9, read_preference = $10, variants = $11, labels = $12, annotations = $13, revision = revision + 1 WHERE domain = $14"
+
 func TestUpdate(t *testing.T) {
 	m, db, _ := newDB()
 	mockRepo := MongoDBConfigRepoImpl{DB: db}
-	config := entitie.Mongodb{Domain: "testDomain", Mongodb: true, Host: "testHost", Port: "8080"}
+	config := entitie.Mongodb{Domain: "testDomain", Host: "testHost", Port: 8080}
 	rows := getMongoDBRows(config.Domain)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
 		WithArgs("testDomain").
 		WillReturnRows(rows)
-	m.ExpectExec(formatRequest("UPDATE mongodbs SET mongodb = $1, port = $2, host = $3 WHERE domain = $4")).
-		WithArgs(strconv.FormatBool(config.Mongodb), config.Port, config.Host, config.Domain).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	result, err := mockRepo.Update(&config)
+	m.ExpectQuery(formatRequest(mongodbUpdate+" RETURNING revision")).
+		WithArgs(config.Port, config.Host, nil, "", "", "", "", "", "false", "", nil, nil, nil, config.Domain).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(2))
+	result, err := mockRepo.Update(context.Background(), &config)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
 	assert.Equal(t, "OK", result)
 
-	configErrOne := entitie.Mongodb{Domain: "errOneConfig", Mongodb: true, Host: "testHost", Port: "8080"}
+	configErrOne := entitie.Mongodb{Domain: "errOneConfig", Host: "testHost", Port: 8080}
 	rows = getMongoDBRows(configErrOne.Domain)
 	expectedErrorOne := errors.New("record not found")
 	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
 		WithArgs("errOneConfig").
 		WillReturnError(expectedErrorOne)
-	_, returnedErr := mockRepo.Update(&configErrOne)
+	_, returnedErr := mockRepo.Update(context.Background(), &configErrOne)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedErrorOne, returnedErr)
 	}
 
 	expectedErrorTwo := errors.New("db error")
-	configErrTwo := entitie.Mongodb{Domain: "errTwoConfig", Mongodb: true, Host: "testHost", Port: "8080"}
+	configErrTwo := entitie.Mongodb{Domain: "errTwoConfig", Host: "testHost", Port: 8080}
 	rows = getMongoDBRows(configErrTwo.Domain)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
 		WithArgs("errTwoConfig").
 		WillReturnRows(rows)
-	m.ExpectExec(formatRequest("UPDATE mongodbs SET mongodb = $1, port = $2, host = $3 WHERE domain = $4")).
-		WithArgs(strconv.FormatBool(configErrTwo.Mongodb), configErrTwo.Port, configErrTwo.Host, configErrTwo.Domain).
+	m.ExpectQuery(formatRequest(mongodbUpdate+" RETURNING revision")).
+		WithArgs(configErrTwo.Port, configErrTwo.Host, nil, "", "", "", "", "", "false", "", nil, nil, nil, configErrTwo.Domain).
 		WillReturnError(expectedErrorTwo)
-	_, returnedErr = mockRepo.Update(&configErrTwo)
+	_, returnedErr = mockRepo.Update(context.Background(), &configErrTwo)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedErrorTwo, returnedErr)
 	}
 
-	expectedErrorThree := errors.New("fields are empty")
-	configErrThree := entitie.Mongodb{Domain: "errThreeConfig", Mongodb: true, Host: "", Port: ""}
+	expectedErrorThree := &FieldError{Field: "host", Description: "must not be empty"}
+	configErrThree := entitie.Mongodb{Domain: "errThreeConfig", Host: "", Port: 0}
 	rows = getMongoDBRows(configErrThree.Domain)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
 		WithArgs("errThreeConfig").
 		WillReturnRows(rows)
-	m.ExpectExec(formatRequest("UPDATE mongodbs SET mongodb = $1, port = $2, host = $3 WHERE domain = $4")).
-		WithArgs(strconv.FormatBool(configErrThree.Mongodb), configErrThree.Port, configErrThree.Host, configErrThree.Domain).
+	m.ExpectQuery(formatRequest(mongodbUpdate+" RETURNING revision")).
+		WithArgs(configErrThree.Port, configErrThree.Host, nil, "", "", "", "", "", "false", "", nil, nil, nil, configErrThree.Domain).
 		WillReturnError(expectedErrorThree)
-	_, returnedErr = mockRepo.Update(&configErrThree)
+	_, returnedErr = mockRepo.Update(context.Background(), &configErrThree)
 	if assert.Error(t, returnedErr) {
 		assert.Equal(t, expectedErrorThree, returnedErr)
 	}
@@ -352,10 +568,10 @@ func TestUpdate(t *testing.T) {
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\" WHERE (module = $1)")).
 		WithArgs("testModule").
 		WillReturnRows(tsRows)
-	m.ExpectExec(formatRequest("UPDATE tsconfigs SET target = $1, source_map = $2, excluding = $3 WHERE module = $4")).
-		WithArgs(tsConfig.Target, strconv.FormatBool(tsConfig.SourceMap), strconv.Itoa(tsConfig.Excluding), tsConfig.Module).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	tsResult, err := tsRepo.Update(&tsConfig)
+	m.ExpectQuery(formatRequest("UPDATE tsconfigs SET target = $1, source_map = $2, excluding = $3, extends = $4, compiler_options = $5, files = $6, include = $7, exclude = $8, variants = $9, labels = $10, annotations = $11, revision = revision + 1 WHERE module = $12 RETURNING revision")).
+		WithArgs(tsConfig.Target, strconv.FormatBool(tsConfig.SourceMap), strconv.Itoa(tsConfig.Excluding), tsConfig.Extends, nil, nil, nil, nil, nil, nil, nil, tsConfig.Module).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(2))
+	tsResult, err := tsRepo.Update(context.Background(), &tsConfig)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -366,7 +582,7 @@ func TestUpdate(t *testing.T) {
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\" WHERE (module = $1)")).
 		WithArgs("errOneConfig").
 		WillReturnError(expectedTsErrorOne)
-	_, tsReturnedErr := tsRepo.Update(&tsConfigErrOne)
+	_, tsReturnedErr := tsRepo.Update(context.Background(), &tsConfigErrOne)
 	if assert.Error(t, tsReturnedErr) {
 		assert.Equal(t, expectedTsErrorOne, tsReturnedErr)
 	}
@@ -377,24 +593,17 @@ func TestUpdate(t *testing.T) {
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\" WHERE (module = $1)")).
 		WithArgs("errTwoConfig").
 		WillReturnRows(tsRows)
-	m.ExpectExec(formatRequest("UPDATE tsconfigs SET target = $1, source_map = $2, excluding = $3 WHERE module = $4")).
-		WithArgs(tsConfigErrTwo.Target, strconv.FormatBool(tsConfigErrTwo.SourceMap), strconv.Itoa(tsConfigErrTwo.Excluding), tsConfigErrTwo.Module).
+	m.ExpectQuery(formatRequest("UPDATE tsconfigs SET target = $1, source_map = $2, excluding = $3, extends = $4, compiler_options = $5, files = $6, include = $7, exclude = $8, variants = $9, labels = $10, annotations = $11, revision = revision + 1 WHERE module = $12 RETURNING revision")).
+		WithArgs(tsConfigErrTwo.Target, strconv.FormatBool(tsConfigErrTwo.SourceMap), strconv.Itoa(tsConfigErrTwo.Excluding), tsConfigErrTwo.Extends, nil, nil, nil, nil, nil, nil, nil, tsConfigErrTwo.Module).
 		WillReturnError(expectedTsErrorTwo)
-	_, tsReturnedErrTwo := tsRepo.Update(&tsConfigErrTwo)
+	_, tsReturnedErrTwo := tsRepo.Update(context.Background(), &tsConfigErrTwo)
 	if assert.Error(t, tsReturnedErrTwo) {
 		assert.Equal(t, expectedTsErrorTwo, tsReturnedErrTwo)
 	}
 
-	expectedTsErrorThree := errors.New("fields are empty")
+	expectedTsErrorThree := &FieldError{Field: "target", Description: "must not be empty"}
 	tsConfigErrThree := entitie.Tsconfig{Module: "errThreeConfig", Target: "", SourceMap: true, Excluding: 1}
-	tsRows = getTsConfigRows(tsConfigErrThree.Module)
-	m.ExpectQuery(formatRequest("SELECT * FROM \"tsconfigs\" WHERE (module = $1)")).
-		WithArgs("errThreeConfig").
-		WillReturnRows(tsRows)
-	m.ExpectExec(formatRequest("UPDATE tsconfigs SET target = $1, source_map = $2, excluding = $3 WHERE module = $4")).
-		WithArgs(tsConfigErrThree.Target, strconv.FormatBool(tsConfigErrThree.SourceMap), strconv.Itoa(tsConfigErrThree.Excluding), tsConfigErrThree.Module).
-		WillReturnError(expectedTsErrorThree)
-	_, tsReturnedErrThree := tsRepo.Update(&tsConfigErrThree)
+	_, tsReturnedErrThree := tsRepo.Update(context.Background(), &tsConfigErrThree)
 	if assert.Error(t, tsReturnedErrThree) {
 		assert.Equal(t, expectedTsErrorThree, tsReturnedErrThree)
 	}
@@ -402,64 +611,271 @@ func TestUpdate(t *testing.T) {
 	m, db, _ = newDB()
 
 	tempRepo := TempConfigRepoImpl{DB: db}
-	tempConfig := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
+	tempConfig := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
 	tempRows := getTempConfigRows(tempConfig.RestApiRoot)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\" WHERE (rest_api_root = $1)")).
 		WithArgs("testApiRoot").
 		WillReturnRows(tempRows)
-	m.ExpectExec(formatRequest("UPDATE tempconfigs SET remoting = $1, port = $2, host = $3, legasy_explorer = $4 WHERE rest_api_root = $5")).
-		WithArgs(tempConfig.Remoting, tempConfig.Port, tempConfig.Host, strconv.FormatBool(tempConfig.LegasyExplorer), tempConfig.RestApiRoot).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	tempResult, err := tempRepo.Update(&tempConfig)
+	m.ExpectQuery(formatRequest("UPDATE tempconfigs SET remoting = $1, port = $2, host = $3, legacy_explorer = $4, variants = $5, labels = $6, annotations = $7, revision = revision + 1 WHERE rest_api_root = $8 RETURNING revision")).
+		WithArgs(tempConfig.Remoting, tempConfig.Port, tempConfig.Host, strconv.FormatBool(tempConfig.LegacyExplorer), nil, nil, nil, tempConfig.RestApiRoot).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(2))
+	tempResult, err := tempRepo.Update(context.Background(), &tempConfig)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
 	assert.Equal(t, "OK", tempResult)
 
-	tempConfigErrOne := entitie.Tempconfig{RestApiRoot: "errOneConfig", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
+	tempConfigErrOne := entitie.Tempconfig{RestApiRoot: "errOneConfig", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
 	expectedTempErrorOne := errors.New("record not found")
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\" WHERE (rest_api_root = $1)")).
 		WithArgs("errOneConfig").
 		WillReturnError(expectedTempErrorOne)
-	_, tempReturnedErr := tempRepo.Update(&tempConfigErrOne)
+	_, tempReturnedErr := tempRepo.Update(context.Background(), &tempConfigErrOne)
 	if assert.Error(t, tempReturnedErr) {
 		assert.Equal(t, expectedTempErrorOne, tempReturnedErr)
 	}
 
 	expectedTempErrorTwo := errors.New("db error")
-	tempConfigErrTwo := entitie.Tempconfig{RestApiRoot: "errTwoConfig", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
+	tempConfigErrTwo := entitie.Tempconfig{RestApiRoot: "errTwoConfig", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
 	tempRows = getTempConfigRows(tempConfigErrTwo.RestApiRoot)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\" WHERE (rest_api_root = $1)")).
 		WithArgs("errTwoConfig").
 		WillReturnRows(tempRows)
-	m.ExpectExec(formatRequest("UPDATE tempconfigs SET remoting = $1, port = $2, host = $3, legasy_explorer = $4 WHERE rest_api_root = $5")).
-		WithArgs(tempConfigErrTwo.Remoting, tempConfigErrTwo.Port, tempConfigErrTwo.Host, strconv.FormatBool(tempConfigErrTwo.LegasyExplorer), tempConfigErrTwo.RestApiRoot).
+	m.ExpectQuery(formatRequest("UPDATE tempconfigs SET remoting = $1, port = $2, host = $3, legacy_explorer = $4, variants = $5, labels = $6, annotations = $7, revision = revision + 1 WHERE rest_api_root = $8 RETURNING revision")).
+		WithArgs(tempConfigErrTwo.Remoting, tempConfigErrTwo.Port, tempConfigErrTwo.Host, strconv.FormatBool(tempConfigErrTwo.LegacyExplorer), nil, nil, nil, tempConfigErrTwo.RestApiRoot).
 		WillReturnError(expectedTempErrorTwo)
-	_, tempReturnedErrTwo := tempRepo.Update(&tempConfigErrTwo)
+	_, tempReturnedErrTwo := tempRepo.Update(context.Background(), &tempConfigErrTwo)
 	if assert.Error(t, tempReturnedErrTwo) {
 		assert.Equal(t, expectedTempErrorTwo, tempReturnedErrTwo)
 	}
 
-	expectedTempErrorThree := errors.New("fields are empty")
-	tempConfigErrThree := entitie.Tempconfig{RestApiRoot: "errThreeConfig", Host: "", Port: "", Remoting: "", LegasyExplorer: true}
+	expectedTempErrorThree := &FieldError{Field: "host", Description: "must not be empty"}
+	tempConfigErrThree := entitie.Tempconfig{RestApiRoot: "errThreeConfig", Host: "", Port: 0, LegacyExplorer: true}
 	tempRows = getTempConfigRows(tempConfigErrThree.RestApiRoot)
 	m.ExpectQuery(formatRequest("SELECT * FROM \"tempconfigs\" WHERE (rest_api_root = $1)")).
 		WithArgs("errThreeConfig").
 		WillReturnRows(tempRows)
-	m.ExpectExec(formatRequest("UPDATE tempconfigs SET remoting = $1, port = $2, host = $3, legasy_explorer = $4 WHERE rest_api_root = $5")).
-		WithArgs(tempConfigErrThree.Remoting, tempConfigErrThree.Port, tempConfigErrThree.Host, strconv.FormatBool(tempConfigErrThree.LegasyExplorer), tempConfigErrThree.RestApiRoot).
+	m.ExpectQuery(formatRequest("UPDATE tempconfigs SET remoting = $1, port = $2, host = $3, legacy_explorer = $4, variants = $5, labels = $6, annotations = $7, revision = revision + 1 WHERE rest_api_root = $8 RETURNING revision")).
+		WithArgs(tempConfigErrThree.Remoting, tempConfigErrThree.Port, tempConfigErrThree.Host, strconv.FormatBool(tempConfigErrThree.LegacyExplorer), nil, nil, nil, tempConfigErrThree.RestApiRoot).
 		WillReturnError(expectedTempErrorThree)
-	_, tempReturnedErrThree := tempRepo.Update(&tempConfigErrThree)
+	_, tempReturnedErrThree := tempRepo.Update(context.Background(), &tempConfigErrThree)
 	if assert.Error(t, tempReturnedErrThree) {
 		assert.Equal(t, expectedTempErrorThree, tempReturnedErrThree)
 	}
 }
 
+func TestConditionalWrites(t *testing.T) {
+	m, db, _ := newDB()
+	mongoRepo := MongoDBConfigRepoImpl{DB: db}
+	revisionRows := func(revision int64) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"domain", "host", "port", "revision"}).AddRow("testDomain", "testHost", int64(8080), revision)
+	}
+
+	config := entitie.Mongodb{Domain: "testDomain", Host: "testHost", Port: 8080, Revision: 3}
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
+		WithArgs("testDomain").WillReturnRows(revisionRows(3))
+	m.ExpectQuery(formatRequest(mongodbUpdate+" AND revision = $15 RETURNING revision")).
+		WithArgs(8080, "testHost", nil, "", "", "", "", "", "false", "", nil, nil, nil, "testDomain", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(4))
+	_, err := mongoRepo.Update(context.Background(), &config)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, int64(4), config.Revision)
+
+	config.Revision = 3
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
+		WithArgs("testDomain").WillReturnRows(revisionRows(4))
+	_, returnedErr := mongoRepo.Update(context.Background(), &config)
+	assert.Equal(t, ErrRevisionMismatch, returnedErr)
+
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
+		WithArgs("testDomain").WillReturnRows(revisionRows(3))
+	m.ExpectQuery(formatRequest(mongodbUpdate+" AND revision = $15 RETURNING revision")).
+		WithArgs(8080, "testHost", nil, "", "", "", "", "", "false", "", nil, nil, nil, "testDomain", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+	_, returnedErr = mongoRepo.Update(context.Background(), &config)
+	assert.Equal(t, ErrRevisionMismatch, returnedErr, "a concurrent update between reading and writing must be detected")
+
+	m.ExpectExec(formatRequest("DELETE FROM \"mongodbs\" WHERE (domain = $1 AND revision = $2)")).
+		WithArgs("testDomain", 3).WillReturnResult(sqlmock.NewResult(0, 0))
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
+		WithArgs("testDomain").WillReturnRows(revisionRows(4))
+	_, returnedErr = mongoRepo.Delete(context.Background(), "testDomain", 3)
+	assert.Equal(t, ErrRevisionMismatch, returnedErr)
+
+	m.ExpectExec(formatRequest("DELETE FROM \"mongodbs\" WHERE (domain = $1 AND revision = $2)")).
+		WithArgs("testDomain", 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	res, err := mongoRepo.Delete(context.Background(), "testDomain", 4)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, "deleted 1 row(s)", res)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestPatch(t *testing.T) {
+	m, db, _ := newDB()
+	mongoRepo := MongoDBConfigRepoImpl{DB: db}
+	config := entitie.Mongodb{Domain: "testDomain", Host: "", Port: 8080, Revision: 3}
+	m.ExpectQuery(formatRequest("UPDATE mongodbs SET host = $1, tls = $2, revision = revision + 1 WHERE domain = $3 AND revision = $4 RETURNING revision")).
+		WithArgs("", false, "testDomain", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(4))
+	res, err := mongoRepo.Patch(context.Background(), &config, []string{"host", "tls"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, "OK", res)
+	assert.Equal(t, int64(4), config.Revision)
+
+	config.Revision = 3
+	m.ExpectQuery(formatRequest("UPDATE mongodbs SET port = $1, revision = revision + 1 WHERE domain = $2 AND revision = $3 RETURNING revision")).
+		WithArgs(8080, "testDomain", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+	_, returnedErr := mongoRepo.Patch(context.Background(), &config, []string{"port"})
+	assert.Equal(t, ErrRevisionMismatch, returnedErr)
+
+	config.Revision = 0
+	m.ExpectQuery(formatRequest("UPDATE mongodbs SET port = $1, revision = revision + 1 WHERE domain = $2 RETURNING revision")).
+		WithArgs(8080, "testDomain").
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}))
+	_, returnedErr = mongoRepo.Patch(context.Background(), &config, []string{"port"})
+	assert.Equal(t, ErrNotFound, returnedErr)
+
+	tsRepo := TsConfigRepoImpl{DB: db}
+	tsConfig := entitie.Tsconfig{Module: "testModule", Include: entitie.StringList{"src"}}
+	m.ExpectQuery(formatRequest("UPDATE tsconfigs SET include = $1, revision = revision + 1 WHERE module = $2 RETURNING revision")).
+		WithArgs("[\"src\"]", "testModule").
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(2))
+	_, err = tsRepo.Patch(context.Background(), &tsConfig, []string{"include"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, int64(2), tsConfig.Revision)
+
+	for _, fields := range [][]string{nil, {"module"}, {"revision"}, {"unknown"}} {
+		_, returnedErr = tsRepo.Patch(context.Background(), &tsConfig, fields)
+		assert.Error(t, returnedErr, "%v", fields)
+	}
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestDBError(t *testing.T) {
+	assert.Equal(t, ErrNotFound, dbError(gorm.ErrRecordNotFound))
+	assert.Equal(t, ErrAlreadyExists, dbError(&pq.Error{Code: "23505"}))
+	assert.Equal(t, ErrUnavailable, dbError(&pq.Error{Code: "08006"}))
+	assert.Equal(t, ErrUnavailable, dbError(&pq.Error{Code: "57P01"}))
+	assert.Equal(t, ErrUnavailable, dbError(driver.ErrBadConn))
+	assert.Equal(t, ErrUnavailable, dbError(&net.OpError{Op: "dial", Err: errors.New("connection refused")}))
+	syntaxError := &pq.Error{Code: "42601"}
+	assert.Equal(t, syntaxError, dbError(syntaxError))
+	assert.Nil(t, dbError(nil))
+
+	m, db, _ := newDB()
+	mockRepo := MongoDBConfigRepoImpl{DB: db}
+	m.ExpectExec(formatRequest("DELETE FROM \"mongodbs\" WHERE (domain = $1)")).
+		WithArgs("notExistingTestID").WillReturnResult(sqlmock.NewResult(0, 0))
+	_, returnedErr := mockRepo.Delete(context.Background(), "notExistingTestID", 0)
+	assert.Equal(t, ErrNotFound, returnedErr)
+
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
+		WithArgs("notExistingTestID").WillReturnError(gorm.ErrRecordNotFound)
+	_, returnedErr = mockRepo.Find(context.Background(), "notExistingTestID")
+	assert.Equal(t, ErrNotFound, returnedErr)
+
+	m.ExpectQuery("INSERT INTO \"mongodbs\"").WillReturnError(&pq.Error{Code: "23505"})
+	_, returnedErr = mockRepo.Save(context.Background(), &entitie.Mongodb{Domain: "testDomain", Host: "testHost", Port: 8080})
+	assert.Equal(t, ErrAlreadyExists, returnedErr)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestContextCancellation(t *testing.T) {
+	m, db, _ := newDB()
+	mockRepo := MongoDBConfigRepoImpl{DB: db}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, returnedErr := mockRepo.Find(ctx, "testDomain")
+	assert.Equal(t, context.Canceled, returnedErr)
+	_, returnedErr = mockRepo.Delete(ctx, "testDomain", 0)
+	assert.Equal(t, context.Canceled, returnedErr)
+	returnedErr = (&PostgresTransactor{DB: db}).InTransaction(ctx, func(repos ConfigRepos) error {
+		t.Error("transaction must not start with a cancelled context")
+		return nil
+	})
+	assert.Equal(t, context.Canceled, returnedErr)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestStatementSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	m, db, _ := newDB()
+	mockRepo := MongoDBConfigRepoImpl{DB: db}
+	m.ExpectQuery(formatRequest("SELECT * FROM \"mongodbs\" WHERE (domain = $1)")).
+		WithArgs("testDomain").WillReturnError(errors.New("connection reset"))
+	_, err := mockRepo.Find(context.Background(), "testDomain")
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "SELECT", spans[0].Name)
+		assert.Contains(t, spans[0].Attributes, semconv.DBStatement(`SELECT * FROM "mongodbs"  WHERE (domain = $1)`))
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+	}
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestCheckPostgres(t *testing.T) {
+	m, db, _ := newDB()
+	migrations, err := LoadMigrations(database.Migrations)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	query := formatRequest("SELECT version FROM schema_migrations")
+	applied := sqlmock.NewRows([]string{"version"})
+	for _, migration := range migrations {
+		applied.AddRow(migration.Version)
+	}
+	m.ExpectQuery(query).WillReturnRows(applied)
+	assert.NoError(t, CheckPostgres(context.Background(), db))
+
+	m.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1).AddRow(2))
+	assert.Equal(t, ErrMigrationsPending, CheckPostgres(context.Background(), db))
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
+func TestInTransaction(t *testing.T) {
+	m, db, _ := newDB()
+	transactor := PostgresTransactor{DB: db}
+	m.ExpectBegin()
+	m.ExpectExec(formatRequest("DELETE FROM \"mongodbs\" WHERE (domain = $1)")).
+		WithArgs("testID").WillReturnResult(sqlmock.NewResult(0, 1))
+	m.ExpectCommit()
+	err := transactor.InTransaction(context.Background(), func(repos ConfigRepos) error {
+		_, err := repos.MongoDB.Delete(context.Background(), "testID", 0)
+		return err
+	})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+
+	expectedError := errors.New("import error")
+	m.ExpectBegin()
+	m.ExpectRollback()
+	returnedErr := transactor.InTransaction(context.Background(), func(repos ConfigRepos) error {
+		return expectedError
+	})
+	if assert.Error(t, returnedErr) {
+		assert.Equal(t, expectedError, returnedErr)
+	}
+	assert.NoError(t, m.ExpectationsWereMet())
+}
+
 func getMongoDBRows(configID string) *sqlmock.Rows {
-	var fieldNames = []string{"domain", "mongodb", "host", "port"}
+	var fieldNames = []string{"domain", "host", "port"}
 	rows := sqlmock.NewRows(fieldNames)
-	mongodbConfig := entitie.Mongodb{Domain: configID, Mongodb: true, Host: "testHost", Port: "testPort"}
-	rows = rows.AddRow(mongodbConfig.Domain, mongodbConfig.Mongodb, mongodbConfig.Host, mongodbConfig.Port)
+	mongodbConfig := entitie.Mongodb{Domain: configID, Host: "testHost", Port: 8080}
+	rows = rows.AddRow(mongodbConfig.Domain, mongodbConfig.Host, int64(mongodbConfig.Port))
 	return rows
 }
 
@@ -472,9 +888,69 @@ func getTsConfigRows(configID string) *sqlmock.Rows {
 }
 
 func getTempConfigRows(configID string) *sqlmock.Rows {
-	var fieldNames = []string{"rest_api_root", "host", "port", "remoting", "legasy_explorer"}
+	var fieldNames = []string{"rest_api_root", "host", "port", "remoting", "legacy_explorer"}
 	rows := sqlmock.NewRows(fieldNames)
-	tempConfig := entitie.Tempconfig{RestApiRoot: configID, Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
-	rows = rows.AddRow(tempConfig.RestApiRoot, tempConfig.Host, tempConfig.Port, tempConfig.Remoting, tempConfig.LegasyExplorer)
+	tempConfig := entitie.Tempconfig{RestApiRoot: configID, Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
+	rows = rows.AddRow(tempConfig.RestApiRoot, tempConfig.Host, int64(tempConfig.Port), `{"context":true}`, tempConfig.LegacyExplorer)
 	return rows
 }
+
+func TestFeatureFlagRepo(t *testing.T) {
+	m, db, _ := newDB()
+	repo := FeatureFlagRepoImpl{DB: db}
+	flag := entitie.Featureflag{Key: "new-checkout", Enabled: true, Variants: entitie.JSONMap{"on": true, "off": false}, OffVariant: "off", DefaultVariant: "off",
+		Rollout: entitie.FlagRollout{{Variant: "on", Percent: 10}, {Variant: "off", Percent: 90}}}
+	variants := `{"off":false,"on":true}`
+	rollout := `[{"variant":"on","percent":10},{"variant":"off","percent":90}]`
+
+	m.ExpectQuery(formatRequest("INSERT INTO \"featureflags\" (\"key\",\"enabled\",\"variants\",\"off_variant\",\"default_variant\",\"rollout\",\"rules\",\"labels\",\"annotations\",\"revision\") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) RETURNING \"featureflags\".\"id\"")).
+		WithArgs("new-checkout", true, variants, "off", "off", rollout, nil, nil, nil, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	result, err := repo.Save(context.Background(), &flag)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, "OK", result)
+
+	_, err = repo.Save(context.Background(), &entitie.Featureflag{Key: "broken", Variants: entitie.JSONMap{"on": true}, OffVariant: "off", DefaultVariant: "on"})
+	assert.Equal(t, &FieldError{Field: "offVariant", Description: `"off" is not one of the variants`}, err)
+
+	m.ExpectQuery(formatRequest("SELECT * FROM \"featureflags\" WHERE (key = $1)")).WithArgs("new-checkout").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "key", "enabled", "variants", "off_variant", "default_variant", "rollout", "rules", "revision"}).
+			AddRow(int64(1), "new-checkout", true, variants, "off", "off", rollout, nil, int64(1)))
+	found, err := repo.Find(context.Background(), "new-checkout")
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, &flag, found)
+
+	m.ExpectQuery(formatRequest("SELECT * FROM \"featureflags\" WHERE (key = $1)")).WithArgs("new-checkout").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "revision"}).AddRow("new-checkout", int64(1)))
+	m.ExpectQuery(formatRequest("UPDATE featureflags SET enabled = $1, variants = $2, off_variant = $3, default_variant = $4, rollout = $5, rules = $6, labels = $7, annotations = $8, revision = revision + 1 WHERE key = $9 AND revision = $10 RETURNING revision")).
+		WithArgs("true", variants, "off", "off", rollout, nil, nil, nil, "new-checkout", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(2))
+	_, err = repo.Update(context.Background(), &flag)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, int64(2), flag.Revision)
+
+	flag.Enabled = false
+	m.ExpectQuery(formatRequest("UPDATE featureflags SET enabled = $1, revision = revision + 1 WHERE key = $2 AND revision = $3 RETURNING revision")).
+		WithArgs(false, "new-checkout", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"revision"}).AddRow(3))
+	_, err = repo.Patch(context.Background(), &flag, []string{"enabled"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, int64(3), flag.Revision)
+
+	m.ExpectExec(formatRequest("DELETE FROM \"featureflags\" WHERE (key = $1)")).
+		WithArgs("new-checkout").WillReturnResult(sqlmock.NewResult(0, 1))
+	result, err = repo.Delete(context.Background(), "new-checkout", 0)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, "deleted 1 row(s)", result)
+	assert.NoError(t, m.ExpectationsWereMet())
+}
@@ -2,32 +2,116 @@
 package repository
 
 import (
+	"context"
+	"errors"
+
 	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/selector"
+)
+
+var (
+	//ErrRevisionMismatch is returned by conditional writes when the stored revision of a config differs from the expected one
+	ErrRevisionMismatch = errors.New("revision mismatch")
+	//ErrNotFound is returned when there is no config with the requested name
+	ErrNotFound = errors.New("config not found")
+	//ErrAlreadyExists is returned when a config with the same name is already saved
+	ErrAlreadyExists = errors.New("config already exists")
+	//ErrUnavailable is returned when the database can not be reached, the operation may succeed when retried
+	ErrUnavailable = errors.New("database is unavailable")
 )
 
+//FieldError is returned when a field of a config or of the list options is invalid, Field is the JSON name of the field
+type FieldError struct {
+	Field       string
+	Description string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Description
+}
+
 //MongoDBConfigRepo is a repository interface for MongoDB configs
 type MongoDBConfigRepo interface {
-	Find(configName string) (*entitie.Mongodb, error)
-	FindAll() ([]entitie.Mongodb, error)
-	Update(config *entitie.Mongodb) (string, error)
-	Save(config *entitie.Mongodb) (string, error)
-	Delete(configName string) (string, error)
+	Find(ctx context.Context, configName string) (*entitie.Mongodb, error)
+	FindAll(ctx context.Context) ([]entitie.Mongodb, error)
+	Iterate(ctx context.Context, options ListOptions, fn func(config *entitie.Mongodb) error) (string, error)
+	Count(ctx context.Context) (int, error)
+	Update(ctx context.Context, config *entitie.Mongodb) (string, error)
+	Patch(ctx context.Context, config *entitie.Mongodb, fields []string) (string, error)
+	Save(ctx context.Context, config *entitie.Mongodb) (string, error)
+	Delete(ctx context.Context, configName string, expectedRevision int64) (string, error)
 }
 
 //TempConfigRepo is a repository interface for Tempconfigs
 type TempConfigRepo interface {
-	Find(configName string) (*entitie.Tempconfig, error)
-	FindAll() ([]entitie.Tempconfig, error)
-	Update(config *entitie.Tempconfig) (string, error)
-	Save(config *entitie.Tempconfig) (string, error)
-	Delete(configName string) (string, error)
+	Find(ctx context.Context, configName string) (*entitie.Tempconfig, error)
+	FindAll(ctx context.Context) ([]entitie.Tempconfig, error)
+	Iterate(ctx context.Context, options ListOptions, fn func(config *entitie.Tempconfig) error) (string, error)
+	Count(ctx context.Context) (int, error)
+	Update(ctx context.Context, config *entitie.Tempconfig) (string, error)
+	Patch(ctx context.Context, config *entitie.Tempconfig, fields []string) (string, error)
+	Save(ctx context.Context, config *entitie.Tempconfig) (string, error)
+	Delete(ctx context.Context, configName string, expectedRevision int64) (string, error)
 }
 
 //TsConfigRepo is a repository interface for Tsconfigs
 type TsConfigRepo interface {
-	Find(configName string) (*entitie.Tsconfig, error)
-	FindAll() ([]entitie.Tsconfig, error)
-	Update(config *entitie.Tsconfig) (string, error)
-	Save(config *entitie.Tsconfig) (string, error)
-	Delete(configName string) (string, error)
+	Find(ctx context.Context, configName string) (*entitie.Tsconfig, error)
+	FindAll(ctx context.Context) ([]entitie.Tsconfig, error)
+	Iterate(ctx context.Context, options ListOptions, fn func(config *entitie.Tsconfig) error) (string, error)
+	Count(ctx context.Context) (int, error)
+	Update(ctx context.Context, config *entitie.Tsconfig) (string, error)
+	Patch(ctx context.Context, config *entitie.Tsconfig, fields []string) (string, error)
+	Save(ctx context.Context, config *entitie.Tsconfig) (string, error)
+	Delete(ctx context.Context, configName string, expectedRevision int64) (string, error)
+}
+
+//FeatureFlagRepo is a repository interface for feature flags, which are named by their key
+type FeatureFlagRepo interface {
+	Find(ctx context.Context, configName string) (*entitie.Featureflag, error)
+	FindAll(ctx context.Context) ([]entitie.Featureflag, error)
+	Iterate(ctx context.Context, options ListOptions, fn func(config *entitie.Featureflag) error) (string, error)
+	Count(ctx context.Context) (int, error)
+	Update(ctx context.Context, config *entitie.Featureflag) (string, error)
+	Patch(ctx context.Context, config *entitie.Featureflag, fields []string) (string, error)
+	Save(ctx context.Context, config *entitie.Featureflag) (string, error)
+	Delete(ctx context.Context, configName string, expectedRevision int64) (string, error)
+}
+
+//ConfigRepos groups repositories of all config types
+type ConfigRepos struct {
+	MongoDB     MongoDBConfigRepo
+	TempConfig  TempConfigRepo
+	TsConfig    TsConfigRepo
+	FeatureFlag FeatureFlagRepo
+}
+
+//Transactor runs a function against config repositories sharing one database transaction
+type Transactor interface {
+	InTransaction(ctx context.Context, fn func(repos ConfigRepos) error) error
+}
+
+const (
+	//OperatorEqual matches configs whose field equals the filter value
+	OperatorEqual = "="
+	//OperatorPrefix matches configs whose field starts with the filter value
+	OperatorPrefix = "prefix"
+)
+
+//Filter restricts listed configs by a field value, Field is the JSON name of a config field or "name" for the unique name of a config
+type Filter struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+//ListOptions describes which page of configs is iterated by Iterate. A zero PageSize means no limit, configs are ordered by name unless OrderBy is set.
+//Selector restricts configs to those whose labels match it
+type ListOptions struct {
+	PageSize   int
+	PageToken  string
+	Filters    []Filter
+	Selector   selector.Selector
+	OrderBy    string
+	Descending bool
 }
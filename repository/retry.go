@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"math/rand"
+	"time"
+)
+
+//Backoff returns the delay after the given failed attempt to connect. It doubles with every attempt up to the maximum backoff,
+//a random jitter shortens it by up to half, so replicas do not reconnect all at once
+func (c PostgresConfig) Backoff(attempt int) time.Duration {
+	delay := time.Duration(c.ConnectBackoffMilliseconds) * time.Millisecond
+	max := time.Duration(c.ConnectMaxBackoffSeconds) * time.Second
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	if delay < 2 {
+		return delay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)))
+}
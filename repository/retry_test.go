@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	c := PostgresConfig{ConnectBackoffMilliseconds: 100, ConnectMaxBackoffSeconds: 1}
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		delay := c.Backoff(attempt + 1)
+		assert.True(t, delay >= max/2 && delay < max, "attempt %d: %v is not in [%v, %v)", attempt+1, delay, max/2, max)
+	}
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/jinzhu/gorm"
+)
+
+//IterateSlice calls fn for every config of configs matching options, in the order and the pages Iterate of the database repositories uses.
+//It lists configs kept in memory, like a snapshot of the database. T is one of the config entities
+func IterateSlice[T any](configs []T, options ListOptions, fn func(config *T) error) (string, error) {
+	columns := sliceColumns(reflect.TypeOf((*T)(nil)).Elem())
+	offset, err := decodePageToken(options.PageToken)
+	if err != nil {
+		return "", err
+	}
+	for _, filter := range options.Filters {
+		column, ok := columns[filter.Field]
+		if !ok {
+			return "", &FieldError{Field: "filters", Description: "unknown filter field " + filter.Field}
+		}
+		if filter.Operator != OperatorEqual && (filter.Operator != OperatorPrefix || !column.text) {
+			return "", &FieldError{Field: "filters", Description: fmt.Sprintf("unexpected operator %q for field %s", filter.Operator, filter.Field)}
+		}
+	}
+	orderBy := columns["name"]
+	if options.OrderBy != "" {
+		column, ok := columns[options.OrderBy]
+		if !ok {
+			return "", &FieldError{Field: "orderBy", Description: "unknown order field " + options.OrderBy}
+		}
+		orderBy = column
+	}
+	var matching []reflect.Value
+	for i := range configs {
+		config := reflect.ValueOf(&configs[i]).Elem()
+		if sliceMatches(config, columns, options) {
+			matching = append(matching, config)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		a, b := matching[i], matching[j]
+		if options.Descending {
+			a, b = b, a
+		}
+		if c := compareField(fieldByColumn(a, orderBy.name), fieldByColumn(b, orderBy.name)); c != 0 {
+			return c < 0
+		}
+		return compareField(fieldByColumn(a, columns["name"].name), fieldByColumn(b, columns["name"].name)) < 0
+	})
+	if offset > len(matching) {
+		offset = len(matching)
+	}
+	matching = matching[offset:]
+	nextPageToken := ""
+	if pageSize := limitPageSize(options.PageSize); pageSize > 0 && len(matching) > pageSize {
+		matching = matching[:pageSize]
+		nextPageToken = encodePageToken(offset + pageSize)
+	}
+	for _, config := range matching {
+		if err = fn(config.Addr().Interface().(*T)); err != nil {
+			return "", err
+		}
+	}
+	return nextPageToken, nil
+}
+
+//sliceColumns returns the columns configs of type t are listed by
+func sliceColumns(t reflect.Type) map[string]listColumn {
+	switch t {
+	case reflect.TypeOf(entitie.Mongodb{}):
+		return mongodbColumns
+	case reflect.TypeOf(entitie.Tempconfig{}):
+		return tempconfigColumns
+	case reflect.TypeOf(entitie.Tsconfig{}):
+		return tsconfigColumns
+	case reflect.TypeOf(entitie.Featureflag{}):
+		return featureflagColumns
+	}
+	panic("unexpected config type " + t.String())
+}
+
+//sliceMatches reports whether config matches the filters and the label selector of options, as the conditions of listQuery would
+func sliceMatches(config reflect.Value, columns map[string]listColumn, options ListOptions) bool {
+	for _, filter := range options.Filters {
+		value := fieldString(fieldByColumn(config, columns[filter.Field].name))
+		if filter.Operator == OperatorPrefix && !strings.HasPrefix(value, filter.Value) || filter.Operator == OperatorEqual && value != filter.Value {
+			return false
+		}
+	}
+	labels, _ := config.FieldByName("Labels").Interface().(entitie.Labels)
+	return options.Selector.Matches(labels)
+}
+
+func fieldByColumn(config reflect.Value, column string) reflect.Value {
+	return config.FieldByNameFunc(func(name string) bool {
+		return gorm.ToDBName(name) == column
+	})
+}
+
+func fieldString(field reflect.Value) string {
+	switch field.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10)
+	}
+	return field.String()
+}
+
+//compareField returns -1, 0 or 1 as a is ordered before, with or after b, false is ordered before true
+func compareField(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Bool:
+		if a.Bool() == b.Bool() {
+			return 0
+		}
+		if b.Bool() {
+			return -1
+		}
+		return 1
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if a.Int() < b.Int() {
+			return -1
+		}
+		if a.Int() > b.Int() {
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a.String(), b.String())
+}
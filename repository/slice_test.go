@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/selector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterateSlice(t *testing.T) {
+	configs := []entitie.Mongodb{
+		{Domain: "c", Host: "db", Port: 2, Labels: entitie.Labels{"team": "a"}},
+		{Domain: "a", Host: "db", Port: 3},
+		{Domain: "b", Host: "other", Port: 2, Labels: entitie.Labels{"team": "b"}},
+		{Domain: "d", Host: "db", Port: 1, TLS: true},
+	}
+	teamSelector, err := selector.Parse("team")
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	list := func(options ListOptions) ([]string, string, error) {
+		var names []string
+		nextPageToken, err := IterateSlice(configs, options, func(config *entitie.Mongodb) error {
+			names = append(names, config.Domain)
+			return nil
+		})
+		return names, nextPageToken, err
+	}
+	testCases := []struct {
+		name    string
+		options ListOptions
+		names   []string
+	}{
+		{name: "all by name", options: ListOptions{}, names: []string{"a", "b", "c", "d"}},
+		{name: "descending", options: ListOptions{Descending: true}, names: []string{"d", "c", "b", "a"}},
+		{name: "by port then name", options: ListOptions{OrderBy: "port"}, names: []string{"d", "b", "c", "a"}},
+		{name: "equal filter", options: ListOptions{Filters: []Filter{{Field: "host", Operator: OperatorEqual, Value: "db"}}}, names: []string{"a", "c", "d"}},
+		{name: "prefix filter", options: ListOptions{Filters: []Filter{{Field: "host", Operator: OperatorPrefix, Value: "ot"}}}, names: []string{"b"}},
+		{name: "number filter", options: ListOptions{Filters: []Filter{{Field: "port", Operator: OperatorEqual, Value: "2"}}}, names: []string{"b", "c"}},
+		{name: "bool filter", options: ListOptions{Filters: []Filter{{Field: "tls", Operator: OperatorEqual, Value: "true"}}}, names: []string{"d"}},
+		{name: "selector", options: ListOptions{Selector: teamSelector}, names: []string{"b", "c"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			names, nextPageToken, err := list(tc.options)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.names, names)
+				assert.Empty(t, nextPageToken)
+			}
+		})
+	}
+
+	names, nextPageToken, err := list(ListOptions{PageSize: 3})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"a", "b", "c"}, names)
+		assert.Equal(t, encodePageToken(3), nextPageToken)
+	}
+	names, nextPageToken, err = list(ListOptions{PageSize: 3, PageToken: nextPageToken})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"d"}, names)
+		assert.Empty(t, nextPageToken)
+	}
+
+	_, _, err = list(ListOptions{Filters: []Filter{{Field: "unknown", Operator: OperatorEqual}}})
+	assert.Equal(t, &FieldError{Field: "filters", Description: "unknown filter field unknown"}, err)
+	_, _, err = list(ListOptions{Filters: []Filter{{Field: "port", Operator: OperatorPrefix, Value: "2"}}})
+	assert.Equal(t, &FieldError{Field: "filters", Description: `unexpected operator "prefix" for field port`}, err)
+	_, _, err = list(ListOptions{OrderBy: "unknown"})
+	assert.Equal(t, &FieldError{Field: "orderBy", Description: "unknown order field unknown"}, err)
+	_, _, err = list(ListOptions{PageToken: "!"})
+	assert.Equal(t, &FieldError{Field: "pageToken", Description: "invalid page token"}, err)
+}
@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/selector"
+)
+
+//readPreferences are the read preference modes of MongoDB
+var readPreferences = []string{"primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest"}
+
+//fieldErrors collects FieldErrors in the order they are found, one for every field
+type fieldErrors []*FieldError
+
+func (e *fieldErrors) add(field, description string) {
+	if !e.has(field) {
+		*e = append(*e, &FieldError{Field: field, Description: description})
+	}
+}
+
+func (e fieldErrors) has(field string) bool {
+	for _, err := range e {
+		if err.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+//first returns the first FieldError or nil
+func (e fieldErrors) first() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e[0]
+}
+
+//ValidateMongodb returns a FieldError for the first field of config breaking the rules of MongoDB configs
+func ValidateMongodb(config *entitie.Mongodb) error {
+	return fieldErrors(MongodbFieldErrors(config)).first()
+}
+
+//MongodbFieldErrors returns FieldErrors for all fields of config breaking the rules of MongoDB configs
+func MongodbFieldErrors(config *entitie.Mongodb) []*FieldError {
+	var errs fieldErrors
+	errs.empty("domain", config.Domain, "host", config.Host)
+	errs.address("", config.Host, config.Port)
+	for i, member := range config.Members {
+		errs.address(fmt.Sprintf("members[%d].", i), member.Host, member.Port)
+	}
+	if len(config.Members) > 0 && config.ReplicaSet == "" {
+		errs.add("replicaSet", "must be set when there are members")
+	}
+	if config.PasswordSecret != "" && config.Username == "" {
+		errs.add("username", "must be set when there is a passwordSecret")
+	}
+	if config.ReadPreference != "" && !slices.Contains(readPreferences, config.ReadPreference) {
+		errs.add("readPreference", "must be one of "+strings.Join(readPreferences, ", "))
+	}
+	errs.metadata(config.Labels, config.Annotations)
+	variantsErrors(&errs, config, config.Variants, "domain", MongodbFieldErrors)
+	return errs
+}
+
+//ValidateTempconfig returns a FieldError for the first field of config breaking the rules of Tempconfigs
+func ValidateTempconfig(config *entitie.Tempconfig) error {
+	return fieldErrors(TempconfigFieldErrors(config)).first()
+}
+
+//TempconfigFieldErrors returns FieldErrors for all fields of config breaking the rules of Tempconfigs
+func TempconfigFieldErrors(config *entitie.Tempconfig) []*FieldError {
+	var errs fieldErrors
+	errs.empty("restApiRoot", config.RestApiRoot, "host", config.Host)
+	errs.address("", config.Host, config.Port)
+	remoting := config.Remoting
+	if remoting.JSON != nil && remoting.JSON.Limit != "" && !sizeLimit.MatchString(remoting.JSON.Limit) {
+		errs.add("remoting.json.limit", fmt.Sprintf("%q is not a size like 100kb", remoting.JSON.Limit))
+	}
+	if remoting.URLEncoded != nil && remoting.URLEncoded.Limit != "" && !sizeLimit.MatchString(remoting.URLEncoded.Limit) {
+		errs.add("remoting.urlencoded.limit", fmt.Sprintf("%q is not a size like 100kb", remoting.URLEncoded.Limit))
+	}
+	if remoting.CORS != nil && !validOrigin(remoting.CORS.Origin) {
+		errs.add("remoting.cors.origin", "must be true, an origin or a list of origins")
+	}
+	errs.metadata(config.Labels, config.Annotations)
+	variantsErrors(&errs, config, config.Variants, "restApiRoot", TempconfigFieldErrors)
+	return errs
+}
+
+//validOrigin reports whether origin is unset, a flag, an origin or a list of origins, as decoded from JSON, YAML or TOML
+func validOrigin(origin interface{}) bool {
+	switch value := origin.(type) {
+	case nil, bool, string, []string:
+		return true
+	case []interface{}:
+		for _, item := range value {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+//ValidateTsconfig returns a FieldError for the first field of config breaking the rules of Tsconfigs
+func ValidateTsconfig(config *entitie.Tsconfig) error {
+	return fieldErrors(TsconfigFieldErrors(config)).first()
+}
+
+//TsconfigFieldErrors returns FieldErrors for all fields of config breaking the rules of Tsconfigs
+func TsconfigFieldErrors(config *entitie.Tsconfig) []*FieldError {
+	var errs fieldErrors
+	errs.empty("module", config.Module, "target", config.Target)
+	errs.metadata(config.Labels, config.Annotations)
+	variantsErrors(&errs, config, config.Variants, "module", TsconfigFieldErrors)
+	return errs
+}
+
+//variantsErrors adds FieldErrors for variants of config without a unique name or labels to match, which change the name of the config,
+//or which turn config into one breaking the rules checked by fieldErrorsOf. Fields already breaking them in config are not reported again for its variants
+func variantsErrors[T any](errs *fieldErrors, config *T, variants entitie.ConfigVariants, nameField string, fieldErrorsOf func(*T) []*FieldError) {
+	inherited := *errs
+	names := make(map[string]bool, len(variants))
+	for i := range variants {
+		variant := &variants[i]
+		prefix := fmt.Sprintf("variants[%d].", i)
+		if variant.Name == "" {
+			errs.add(prefix+"name", "must not be empty")
+		} else if names[variant.Name] {
+			errs.add(prefix+"name", fmt.Sprintf("%q is already the name of another variant", variant.Name))
+		}
+		names[variant.Name] = true
+		if len(variant.Match) == 0 {
+			errs.add(prefix+"match", "must not be empty")
+		}
+		for _, field := range []string{nameField, "revision", "variants"} {
+			if _, ok := variant.Config[field]; ok {
+				errs.add(prefix+"config."+field, "can not be changed by a variant")
+			}
+		}
+		resolved, err := entitie.ApplyVariant(config, variant)
+		if err != nil {
+			errs.add(prefix+"config", err.Error())
+			continue
+		}
+		for _, fieldErr := range fieldErrorsOf(resolved.(*T)) {
+			if !inherited.has(fieldErr.Field) {
+				errs.add(prefix+"config."+fieldErr.Field, fieldErr.Description)
+			}
+		}
+	}
+}
+
+//ValidateFeatureflag returns a FieldError for the first field of config breaking the rules of feature flags.
+//Every variant served has to be one of its variants and the percentages of every rollout have to add up to 100
+func ValidateFeatureflag(config *entitie.Featureflag) error {
+	return fieldErrors(FeatureflagFieldErrors(config)).first()
+}
+
+//FeatureflagFieldErrors returns FieldErrors for all fields of config breaking the rules of feature flags
+func FeatureflagFieldErrors(config *entitie.Featureflag) []*FieldError {
+	var errs fieldErrors
+	errs.empty("key", config.Key, "offVariant", config.OffVariant, "defaultVariant", config.DefaultVariant)
+	if len(config.Variants) == 0 {
+		errs.add("variants", "must not be empty")
+	}
+	errs.variant("offVariant", config.OffVariant, config.Variants)
+	errs.variant("defaultVariant", config.DefaultVariant, config.Variants)
+	errs.rollout("rollout", config.Rollout, config.Variants)
+	for i, rule := range config.Rules {
+		prefix := fmt.Sprintf("rules[%d].", i)
+		if len(rule.Conditions) == 0 {
+			errs.add(prefix+"conditions", "must not be empty")
+		}
+		for j, condition := range rule.Conditions {
+			field := fmt.Sprintf("%sconditions[%d].", prefix, j)
+			if condition.Attribute == "" {
+				errs.add(field+"attribute", "must not be empty")
+			}
+			if !slices.Contains(entitie.FlagOperators, condition.Operator) {
+				errs.add(field+"operator", "must be one of "+strings.Join(entitie.FlagOperators, ", "))
+			}
+			if len(condition.Values) == 0 {
+				errs.add(field+"values", "must not be empty")
+			}
+		}
+		if (rule.Variant == "") == (len(rule.Rollout) == 0) {
+			errs.add(prefix+"variant", "either variant or rollout must be set")
+		}
+		if rule.Variant != "" {
+			errs.variant(prefix+"variant", rule.Variant, config.Variants)
+		}
+		errs.rollout(prefix+"rollout", rule.Rollout, config.Variants)
+	}
+	errs.metadata(config.Labels, config.Annotations)
+	return errs
+}
+
+//empty adds a FieldError for every empty field of the given pairs of JSON field names and values
+func (e *fieldErrors) empty(namesAndValues ...string) {
+	for i := 0; i+1 < len(namesAndValues); i += 2 {
+		if namesAndValues[i+1] == "" {
+			e.add(namesAndValues[i], "must not be empty")
+		}
+	}
+}
+
+//metadata adds FieldErrors for the first label, by key, which is not a valid label key and value for label selectors,
+//and for the first annotation whose key is not a valid label key. Annotations are free text
+func (e *fieldErrors) metadata(labels entitie.Labels, annotations entitie.Annotations) {
+	for _, key := range sortedKeys(labels) {
+		if err := selector.ValidateKey(key); err != nil {
+			e.add("labels", err.Error())
+		} else if err = selector.ValidateValue(labels[key]); err != nil {
+			e.add("labels."+key, err.Error())
+		}
+	}
+	for _, key := range sortedKeys(annotations) {
+		if err := selector.ValidateKey(key); err != nil {
+			e.add("annotations", err.Error())
+		}
+	}
+}
+
+func sortedKeys[M ~map[string]string](m M) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//variant adds a FieldError unless variant is one of variants
+func (e *fieldErrors) variant(field, variant string, variants entitie.JSONMap) {
+	if _, ok := variants[variant]; !ok {
+		e.add(field, fmt.Sprintf("%q is not one of the variants", variant))
+	}
+}
+
+//rollout adds FieldErrors for a rollout serving unknown variants or whose percentages do not add up to 100, an empty rollout is valid
+func (e *fieldErrors) rollout(field string, rollout entitie.FlagRollout, variants entitie.JSONMap) {
+	if len(rollout) == 0 {
+		return
+	}
+	total := 0
+	for i, weight := range rollout {
+		e.variant(fmt.Sprintf("%s[%d].variant", field, i), weight.Variant, variants)
+		if weight.Percent < 0 || weight.Percent > 100 {
+			e.add(fmt.Sprintf("%s[%d].percent", field, i), fmt.Sprintf("%d is not a percentage from 0 to 100", weight.Percent))
+		}
+		total += weight.Percent
+	}
+	if total != 100 {
+		e.add(field, fmt.Sprintf("percentages add up to %d instead of 100", total))
+	}
+}
+
+//address adds FieldErrors for an invalid host and a missing or invalid port, prefix is prepended to their field names
+func (e *fieldErrors) address(prefix, host string, port entitie.Port) {
+	if err := ValidateHost(host); err != nil {
+		e.add(prefix+"host", err.Error())
+	}
+	if port == 0 {
+		e.add(prefix+"port", "must not be empty")
+	} else if err := ValidatePort(port); err != nil {
+		e.add(prefix+"port", err.Error())
+	}
+}
+
+//ValidatePort returns an error unless port is from 1 to 65535
+func ValidatePort(port entitie.Port) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%d is not a port number from 1 to 65535", port)
+	}
+	return nil
+}
+
+//ValidateHost returns an error unless host is an IP address or a host name of dot separated labels,
+//which consist of letters, digits and hyphens and neither start nor end with a hyphen
+func ValidateHost(host string) error {
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+	if host == "" || len(host) > 253 {
+		return fmt.Errorf("%q is not a host name or IP address", host)
+	}
+	for _, label := range strings.Split(host, ".") {
+		if !hostLabel.MatchString(label) {
+			return fmt.Errorf("%q is not a host name or IP address", host)
+		}
+	}
+	return nil
+}
+
+//sizeLimit matches a body size limit of LoopBack like 100kb or 1.5mb
+var sizeLimit = regexp.MustCompile(`^(?i)[0-9]+(\.[0-9]+)?\s*(b|kb|mb|gb|tb|pb)?$`)
+
+//hostLabel matches a label of a host name
+var hostLabel = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?$`)
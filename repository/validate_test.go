@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePort(t *testing.T) {
+	for _, port := range []entitie.Port{1, 8080, 65535} {
+		assert.NoError(t, ValidatePort(port), port)
+	}
+	for _, port := range []entitie.Port{0, 65536, -1} {
+		assert.Error(t, ValidatePort(port), port)
+	}
+}
+
+func TestValidateHost(t *testing.T) {
+	for _, host := range []string{"localhost", "db.example.com", "DB-1.example.com", "127.0.0.1", "::1", "2001:db8::1"} {
+		assert.NoError(t, ValidateHost(host), host)
+	}
+	for _, host := range []string{"", " localhost", "localhost.", "-db.example.com", "db_1", "http://db", "db:5432", "a..b"} {
+		assert.Error(t, ValidateHost(host), host)
+	}
+}
+
+func TestValidateMongodb(t *testing.T) {
+	valid := entitie.Mongodb{Domain: "orders", Host: "mongo-0", Port: 27017, Members: entitie.MongodbMembers{{Host: "mongo-1", Port: 27017}},
+		ReplicaSet: "rs0", Username: "orders", PasswordSecret: "orders/password", ReadPreference: "nearest"}
+	assert.NoError(t, ValidateMongodb(&valid))
+
+	for field, change := range map[string]func(config *entitie.Mongodb){
+		"host":            func(config *entitie.Mongodb) { config.Host = "" },
+		"members[0].host": func(config *entitie.Mongodb) { config.Members[0].Host = "mongo_1" },
+		"members[0].port": func(config *entitie.Mongodb) { config.Members[0].Port = 0 },
+		"replicaSet":      func(config *entitie.Mongodb) { config.ReplicaSet = "" },
+		"username":        func(config *entitie.Mongodb) { config.Username = "" },
+		"readPreference":  func(config *entitie.Mongodb) { config.ReadPreference = "fastest" },
+	} {
+		config := valid
+		config.Members = append(entitie.MongodbMembers(nil), valid.Members...)
+		change(&config)
+		err := ValidateMongodb(&config)
+		if assert.IsType(t, &FieldError{}, err, field) {
+			assert.Equal(t, field, err.(*FieldError).Field)
+		}
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	var fields []string
+	for _, err := range MongodbFieldErrors(&entitie.Mongodb{Domain: "orders", Host: "mongo_0", Members: entitie.MongodbMembers{{Host: "mongo-1", Port: 70000}},
+		PasswordSecret: "orders/password", Labels: entitie.Labels{"team": "orders"},
+		Variants: entitie.ConfigVariants{{Name: "eu", Match: entitie.Labels{"region": "eu"}, Config: entitie.JSONMap{"readPreference": "fastest"}}}}) {
+		fields = append(fields, err.Field)
+	}
+	assert.Equal(t, []string{"host", "port", "members[0].port", "replicaSet", "username", "variants[0].config.readPreference"}, fields,
+		"fields breaking the rules in the config are not reported again for its variants")
+	assert.Empty(t, TsconfigFieldErrors(&entitie.Tsconfig{Module: "web", Target: "es2017"}))
+
+	fields = nil
+	for _, err := range FeatureflagFieldErrors(&entitie.Featureflag{Key: "checkout", Variants: entitie.JSONMap{"on": true}, DefaultVariant: "off",
+		Rollout: entitie.FlagRollout{{Variant: "on", Percent: 120}}}) {
+		fields = append(fields, err.Field)
+	}
+	assert.Equal(t, []string{"offVariant", "defaultVariant", "rollout[0].percent", "rollout"}, fields, "every field is reported once")
+}
+
+func TestValidateTempconfig(t *testing.T) {
+	valid := entitie.Tempconfig{RestApiRoot: "/api", Host: "0.0.0.0", Port: 3000, Remoting: entitie.LoopbackRemoting{
+		JSON:       &entitie.LoopbackJSONParser{Limit: "100kb"},
+		URLEncoded: &entitie.LoopbackURLEncodedParser{Limit: "1.5 MB"},
+		CORS:       &entitie.LoopbackCORS{Origin: []interface{}{"https://example.com"}},
+	}}
+	assert.NoError(t, ValidateTempconfig(&valid))
+	assert.NoError(t, ValidateTempconfig(&entitie.Tempconfig{RestApiRoot: "/api", Host: "localhost", Port: 3000}), "remoting options are optional")
+
+	for field, remoting := range map[string]entitie.LoopbackRemoting{
+		"remoting.json.limit":       {JSON: &entitie.LoopbackJSONParser{Limit: "lots"}},
+		"remoting.urlencoded.limit": {URLEncoded: &entitie.LoopbackURLEncodedParser{Limit: "-1kb"}},
+		"remoting.cors.origin":      {CORS: &entitie.LoopbackCORS{Origin: []interface{}{"https://example.com", 1.0}}},
+	} {
+		config := valid
+		config.Remoting = remoting
+		err := ValidateTempconfig(&config)
+		if assert.IsType(t, &FieldError{}, err, field) {
+			assert.Equal(t, field, err.(*FieldError).Field)
+		}
+	}
+}
+
+func TestValidateFeatureflag(t *testing.T) {
+	newValid := func() entitie.Featureflag {
+		return entitie.Featureflag{Key: "new-checkout", Enabled: true, Variants: entitie.JSONMap{"on": true, "off": false}, OffVariant: "off", DefaultVariant: "off",
+			Rollout: entitie.FlagRollout{{Variant: "on", Percent: 25}, {Variant: "off", Percent: 75}},
+			Rules:   entitie.FlagRules{{Conditions: []entitie.FlagCondition{{Attribute: "country", Operator: entitie.FlagOperatorIn, Values: entitie.StringList{"UA"}}}, Variant: "on"}}}
+	}
+	valid := newValid()
+	assert.NoError(t, ValidateFeatureflag(&valid))
+	assert.NoError(t, ValidateFeatureflag(&entitie.Featureflag{Key: "kill-switch", Variants: entitie.JSONMap{"off": false}, OffVariant: "off", DefaultVariant: "off"}), "rollout and rules are optional")
+
+	for field, change := range map[string]func(config *entitie.Featureflag){
+		"key":                              func(config *entitie.Featureflag) { config.Key = "" },
+		"variants":                         func(config *entitie.Featureflag) { config.Variants = nil },
+		"offVariant":                       func(config *entitie.Featureflag) { config.OffVariant = "disabled" },
+		"defaultVariant":                   func(config *entitie.Featureflag) { config.DefaultVariant = "" },
+		"rollout":                          func(config *entitie.Featureflag) { config.Rollout[1].Percent = 70 },
+		"rollout[0].variant":               func(config *entitie.Featureflag) { config.Rollout[0].Variant = "maybe" },
+		"rollout[1].percent":               func(config *entitie.Featureflag) { config.Rollout[1].Percent = 101 },
+		"rules[0].conditions":              func(config *entitie.Featureflag) { config.Rules[0].Conditions = nil },
+		"rules[0].conditions[0].attribute": func(config *entitie.Featureflag) { config.Rules[0].Conditions[0].Attribute = "" },
+		"rules[0].conditions[0].operator":  func(config *entitie.Featureflag) { config.Rules[0].Conditions[0].Operator = "matches" },
+		"rules[0].conditions[0].values":    func(config *entitie.Featureflag) { config.Rules[0].Conditions[0].Values = nil },
+		"rules[0].variant": func(config *entitie.Featureflag) {
+			config.Rules[0].Rollout = entitie.FlagRollout{{Variant: "on", Percent: 100}}
+		},
+		"rules[0].rollout": func(config *entitie.Featureflag) {
+			config.Rules[0].Variant, config.Rules[0].Rollout = "", entitie.FlagRollout{{Variant: "on", Percent: 50}}
+		},
+	} {
+		config := newValid()
+		change(&config)
+		err := ValidateFeatureflag(&config)
+		if assert.IsType(t, &FieldError{}, err, field) {
+			assert.Equal(t, field, err.(*FieldError).Field)
+		}
+	}
+}
+
+func TestValidateVariants(t *testing.T) {
+	newValid := func() entitie.Mongodb {
+		return entitie.Mongodb{Domain: "orders", Host: "mongo-0", Port: 27017, Variants: entitie.ConfigVariants{
+			{Name: "eu", Match: entitie.Labels{"region": "eu"}, Config: entitie.JSONMap{"host": "mongo-eu"}},
+			{Name: "canary", Match: entitie.Labels{"version": "2"}, Config: entitie.JSONMap{"readPreference": "nearest"}},
+		}}
+	}
+	valid := newValid()
+	assert.NoError(t, ValidateMongodb(&valid))
+
+	for field, change := range map[string]func(config *entitie.Mongodb){
+		"variants[0].name":                  func(config *entitie.Mongodb) { config.Variants[0].Name = "" },
+		"variants[1].name":                  func(config *entitie.Mongodb) { config.Variants[1].Name = "eu" },
+		"variants[1].match":                 func(config *entitie.Mongodb) { config.Variants[1].Match = nil },
+		"variants[0].config.domain":         func(config *entitie.Mongodb) { config.Variants[0].Config["domain"] = "payments" },
+		"variants[0].config.variants":       func(config *entitie.Mongodb) { config.Variants[0].Config["variants"] = nil },
+		"variants[0].config":                func(config *entitie.Mongodb) { config.Variants[0].Config["port"] = "http" },
+		"variants[0].config.host":           func(config *entitie.Mongodb) { config.Variants[0].Config["host"] = nil },
+		"variants[1].config.readPreference": func(config *entitie.Mongodb) { config.Variants[1].Config["readPreference"] = "fastest" },
+	} {
+		config := newValid()
+		change(&config)
+		err := ValidateMongodb(&config)
+		if assert.IsType(t, &FieldError{}, err, field) {
+			assert.Equal(t, field, err.(*FieldError).Field)
+		}
+	}
+
+	tsconfig := entitie.Tsconfig{Module: "commonjs", Target: "es5", Variants: entitie.ConfigVariants{{Name: "modern", Match: entitie.Labels{"runtime": "node20"}, Config: entitie.JSONMap{"target": nil}}}}
+	err := ValidateTsconfig(&tsconfig)
+	if assert.IsType(t, &FieldError{}, err) {
+		assert.Equal(t, "variants[0].config.target", err.(*FieldError).Field)
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	valid := entitie.Tsconfig{Module: "commonjs", Target: "es5", Labels: entitie.Labels{"team": "web", "example.com/tier": ""},
+		Annotations: entitie.Annotations{"description": "Compiler options of the web apps, see https://example.com/SHOP-12"}}
+	assert.NoError(t, ValidateTsconfig(&valid))
+
+	for field, config := range map[string]entitie.Tsconfig{
+		"labels":      {Module: "commonjs", Target: "es5", Labels: entitie.Labels{"team": "web", "team name": "web"}},
+		"labels.team": {Module: "commonjs", Target: "es5", Labels: entitie.Labels{"team": "web apps"}},
+		"annotations": {Module: "commonjs", Target: "es5", Annotations: entitie.Annotations{"-owner": "ann"}},
+	} {
+		err := ValidateTsconfig(&config)
+		if assert.IsType(t, &FieldError{}, err, field) {
+			assert.Equal(t, field, err.(*FieldError).Field)
+		}
+	}
+	flag := entitie.Featureflag{Key: "new-checkout", Variants: entitie.JSONMap{"off": false}, OffVariant: "off", DefaultVariant: "off", Labels: entitie.Labels{"team": "web apps"}}
+	assert.Equal(t, &FieldError{Field: "labels.team", Description: `"web apps" is not a label value`}, ValidateFeatureflag(&flag))
+}
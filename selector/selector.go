@@ -0,0 +1,189 @@
+// Package selector parses Kubernetes style label selectors like "team=orders,env in (prod,staging),!deprecated" and matches labels with them
+package selector
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+//operators of requirements
+const (
+	//OperatorEquals matches labels with the key and the value, written key=value or key==value
+	OperatorEquals = "="
+	//OperatorNotEquals matches labels without the key or with another value, written key!=value
+	OperatorNotEquals = "!="
+	//OperatorIn matches labels with the key and one of the values, written key in (a,b)
+	OperatorIn = "in"
+	//OperatorNotIn matches labels without the key or with none of the values, written key notin (a,b)
+	OperatorNotIn = "notin"
+	//OperatorExists matches labels with the key, written key
+	OperatorExists = "exists"
+	//OperatorDoesNotExist matches labels without the key, written !key
+	OperatorDoesNotExist = "!"
+)
+
+//Requirement is a condition on one label, Values holds the one value of equality and the set of set requirements
+type Requirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+//Selector is a list of requirements, labels match a selector when they match all requirements. An empty selector matches all labels
+type Selector []Requirement
+
+//Parse reads a selector of comma separated requirements, keys and values have to be valid label keys and values
+func Parse(text string) (Selector, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	var selector Selector
+	for _, part := range splitRequirements(text) {
+		requirement, err := parseRequirement(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		selector = append(selector, requirement)
+	}
+	return selector, nil
+}
+
+//Matches reports whether labels match all requirements
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, requirement := range s {
+		if !requirement.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+//String writes the selector the way it is parsed
+func (s Selector) String() string {
+	requirements := make([]string, len(s))
+	for i, requirement := range s {
+		requirements[i] = requirement.String()
+	}
+	return strings.Join(requirements, ",")
+}
+
+//Matches reports whether labels match the requirement
+func (r Requirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case OperatorEquals, OperatorIn:
+		return ok && slices.Contains(r.Values, value)
+	case OperatorNotEquals, OperatorNotIn:
+		return !ok || !slices.Contains(r.Values, value)
+	case OperatorExists:
+		return ok
+	case OperatorDoesNotExist:
+		return !ok
+	default:
+		return false
+	}
+}
+
+//String writes the requirement the way it is parsed
+func (r Requirement) String() string {
+	switch r.Operator {
+	case OperatorEquals, OperatorNotEquals:
+		return r.Key + r.Operator + strings.Join(r.Values, "")
+	case OperatorIn, OperatorNotIn:
+		return r.Key + " " + r.Operator + " (" + strings.Join(r.Values, ",") + ")"
+	case OperatorDoesNotExist:
+		return "!" + r.Key
+	default:
+		return r.Key
+	}
+}
+
+//ValidateKey returns an error unless key is a label key: a name of up to 63 letters, digits, dashes, underscores and dots,
+//starting and ending with a letter or digit, optionally prefixed by a DNS subdomain and a slash like example.com/team
+func ValidateKey(key string) error {
+	name := key
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		prefix := key[:i]
+		name = key[i+1:]
+		if len(prefix) > 253 || !dnsSubdomain.MatchString(prefix) {
+			return fmt.Errorf("%q is not a label key, its prefix must be a DNS subdomain", key)
+		}
+	}
+	if len(name) > 63 || !labelName.MatchString(name) {
+		return fmt.Errorf("%q is not a label key", key)
+	}
+	return nil
+}
+
+//ValidateValue returns an error unless value is a label value, which is empty or like the name of a key
+func ValidateValue(value string) error {
+	if value != "" && (len(value) > 63 || !labelName.MatchString(value)) {
+		return fmt.Errorf("%q is not a label value", value)
+	}
+	return nil
+}
+
+//splitRequirements splits text at the commas which are not within the parentheses of a set
+func splitRequirements(text string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range text {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, text[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, text[start:])
+}
+
+func parseRequirement(text string) (Requirement, error) {
+	var requirement Requirement
+	switch {
+	case text == "":
+		return requirement, fmt.Errorf("empty requirement")
+	case setRequirement.MatchString(text):
+		match := setRequirement.FindStringSubmatch(text)
+		requirement = Requirement{Key: match[1], Operator: match[2]}
+		for _, value := range strings.Split(match[3], ",") {
+			requirement.Values = append(requirement.Values, strings.TrimSpace(value))
+		}
+	case strings.HasPrefix(text, "!") && !strings.HasPrefix(text, "!="):
+		requirement = Requirement{Key: strings.TrimSpace(text[1:]), Operator: OperatorDoesNotExist}
+	case strings.Contains(text, "!="):
+		key, value, _ := strings.Cut(text, "!=")
+		requirement = Requirement{Key: strings.TrimSpace(key), Operator: OperatorNotEquals, Values: []string{strings.TrimSpace(value)}}
+	case strings.Contains(text, "="):
+		key, value, _ := strings.Cut(text, "=")
+		value = strings.TrimPrefix(value, "=")
+		requirement = Requirement{Key: strings.TrimSpace(key), Operator: OperatorEquals, Values: []string{strings.TrimSpace(value)}}
+	default:
+		requirement = Requirement{Key: text, Operator: OperatorExists}
+	}
+	if err := ValidateKey(requirement.Key); err != nil {
+		return requirement, fmt.Errorf("invalid requirement %q: %v", text, err)
+	}
+	for _, value := range requirement.Values {
+		if err := ValidateValue(value); err != nil {
+			return requirement, fmt.Errorf("invalid requirement %q: %v", text, err)
+		}
+	}
+	return requirement, nil
+}
+
+//setRequirement matches a set requirement like env in (prod, staging)
+var setRequirement = regexp.MustCompile(`^(\S+)\s+(in|notin)\s*\(([^()]*)\)$`)
+
+//labelName matches the name of a label key and a non-empty label value
+var labelName = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+
+//dnsSubdomain matches a DNS subdomain
+var dnsSubdomain = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
@@ -0,0 +1,68 @@
+package selector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	selector, err := Parse(" team = orders, env in (prod, staging),tier notin (cache),example.com/owner,!deprecated,region!=eu,version==2")
+	if assert.NoError(t, err) {
+		assert.Equal(t, Selector{
+			{Key: "team", Operator: OperatorEquals, Values: []string{"orders"}},
+			{Key: "env", Operator: OperatorIn, Values: []string{"prod", "staging"}},
+			{Key: "tier", Operator: OperatorNotIn, Values: []string{"cache"}},
+			{Key: "example.com/owner", Operator: OperatorExists},
+			{Key: "deprecated", Operator: OperatorDoesNotExist},
+			{Key: "region", Operator: OperatorNotEquals, Values: []string{"eu"}},
+			{Key: "version", Operator: OperatorEquals, Values: []string{"2"}},
+		}, selector)
+		assert.Equal(t, "team=orders,env in (prod,staging),tier notin (cache),example.com/owner,!deprecated,region!=eu,version=2", selector.String())
+	}
+
+	selector, err = Parse("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, selector)
+
+	for _, invalid := range []string{"team=orders,", "=orders", "team=orders!", "env in (prod", "-team", "Example.com/team", "team==="} {
+		_, err = Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	labels := map[string]string{"team": "orders", "env": "prod"}
+	for text, expected := range map[string]bool{
+		"":                           true,
+		"team=orders":                true,
+		"team=payments":              false,
+		"team!=payments":             true,
+		"owner!=ann":                 true,
+		"env in (staging,prod)":      true,
+		"env notin (staging,prod)":   false,
+		"owner notin (ann)":          true,
+		"owner in (ann)":             false,
+		"team":                       true,
+		"owner":                      false,
+		"!owner":                     true,
+		"!team":                      false,
+		"team=orders,env in (stage)": false,
+	} {
+		selector, err := Parse(text)
+		if assert.NoError(t, err, text) {
+			assert.Equal(t, expected, selector.Matches(labels), text)
+		}
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	assert.NoError(t, ValidateKey("team"))
+	assert.NoError(t, ValidateKey("getmeconf.example.com/owner_team.v2"))
+	assert.Error(t, ValidateKey(""))
+	assert.Error(t, ValidateKey("/team"))
+	assert.Error(t, ValidateKey("team-"))
+	assert.Error(t, ValidateKey("team name"))
+	assert.Error(t, ValidateValue("orders team"))
+	assert.NoError(t, ValidateValue(""))
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/YAWAL/GetMeConf/logging"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/net/context"
+)
+
+//adminServer implements RPCs operating the service rather than configs
+type adminServer struct {
+	configCache *cache.Cache
+	repos       repository.ConfigRepos
+}
+
+//SetLogLevel changes the level of logged messages at runtime, the level is one of debug, info, warn and error
+func (a *adminServer) SetLogLevel(ctx context.Context, levelRequest *pb.SetLogLevelRequest) (*pb.SetLogLevelResponce, error) {
+	previous, err := logging.SetLevel(levelRequest.Level)
+	if err != nil {
+		return nil, invalidArgument("level", err.Error())
+	}
+	slog.InfoContext(ctx, "log level changed", "level", logging.Level(), "previousLevel", previous)
+	return &pb.SetLogLevelResponce{Level: logging.Level(), PreviousLevel: previous}, nil
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/logging"
+	"github.com/YAWAL/GetMeConf/repository"
+	"github.com/YAWAL/GetMeConf/settings"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+)
+
+//commands contains subcommands of the service binary, the gRPC server is started when no subcommand is given
+var commands = map[string]func(args []string) error{
+	"export":  exportCommand,
+	"import":  importCommand,
+	"config":  configCommand,
+	"migrate": migrateCommand,
+	"seed":    seedCommand,
+	"check":   checkCommand,
+}
+
+func runCommand(name string, args []string) error {
+	command, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command: %s", name)
+	}
+	return command(args)
+}
+
+func exportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	types := flags.String("types", "", "comma separated config types to export, all types if empty")
+	format := flags.String("format", "", "archive format: json, yaml or toml, derived from the output file extension if empty")
+	out := flags.String("out", "", "output file, stdout if empty")
+	serviceSettings, err := loadSettings(flags, args)
+	if err != nil {
+		return err
+	}
+
+	dbConn, err := repository.InitPostgresDB(serviceSettings.Postgres)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	repos := repository.ConfigRepos{
+		MongoDB:     repository.NewMongoDBConfigRepo(dbConn),
+		TempConfig:  repository.NewTempConfigRepo(dbConn),
+		TsConfig:    repository.NewTsConfigRepo(dbConn),
+		FeatureFlag: repository.NewFeatureFlagRepo(dbConn),
+	}
+	archive, err := exportArchive(context.Background(), repos, splitList(*types))
+	if err != nil {
+		return err
+	}
+	data, err := encodeArchive(archive, archiveFormat(*format, *out))
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(*out, data, 0644)
+}
+
+func importCommand(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	types := flags.String("types", "", "comma separated config types to import, all types if empty")
+	format := flags.String("format", "", "archive format: json, yaml or toml, derived from the input file extension if empty")
+	in := flags.String("in", "", "input file, stdin if empty")
+	mode := flags.String("mode", importModeCreate, "import mode: create, upsert or replace")
+	dryRun := flags.Bool("dry-run", false, "report changes without writing them")
+	serviceSettings, err := loadSettings(flags, args)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if *in == "" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(*in)
+	}
+	if err != nil {
+		return err
+	}
+	archive, err := decodeArchive(data, archiveFormat(*format, *in))
+	if err != nil {
+		return err
+	}
+
+	dbConn, err := repository.InitPostgresDB(serviceSettings.Postgres)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	var changes []*pb.ConfigChange
+	err = repository.NewPostgresTransactor(dbConn).InTransaction(context.Background(), func(repos repository.ConfigRepos) error {
+		var err error
+		changes, err = importArchive(context.Background(), repos, archive, splitList(*types), *mode, *dryRun)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		fmt.Printf("%s\t%s\t%s\n", change.Action, change.ConfigType, change.ConfigName)
+	}
+	if *dryRun {
+		fmt.Println("dry run, nothing has been written")
+	}
+	return nil
+}
+
+//configCommand prints the effective settings, loaded like the server loads them, with secrets masked
+func configCommand(args []string) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: config print [flags]")
+	}
+	flags := flag.NewFlagSet("config print", flag.ExitOnError)
+	serviceSettings, err := loadSettings(flags, args[1:])
+	if err != nil {
+		return err
+	}
+	data, err := serviceSettings.Print()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+//migrateCommand applies, reverts or lists the migrations of the database schema. Concurrent runs, also by starting servers,
+//wait for each other
+func migrateCommand(args []string) error {
+	usage := errors.New("usage: migrate up|down [N]|status|redo [flags]")
+	if len(args) == 0 {
+		return usage
+	}
+	operation, args := args[0], args[1:]
+	n := 1
+	switch operation {
+	case "up", "status", "redo":
+	case "down":
+		if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+				return fmt.Errorf("migrate down: %q is not a positive number", args[0])
+			}
+			args = args[1:]
+		}
+	default:
+		return usage
+	}
+	flags := flag.NewFlagSet("migrate "+operation, flag.ExitOnError)
+	serviceSettings, err := loadSettings(flags, args)
+	if err != nil {
+		return err
+	}
+
+	dbConn, err := repository.OpenPostgresDB(serviceSettings.Postgres, serviceSettings.Postgres.CredentialsProvider())
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	migrator, err := repository.NewMigrator(dbConn.DB())
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	var migrations []repository.Migration
+	switch operation {
+	case "up":
+		migrations, err = migrator.Up(ctx)
+	case "down":
+		migrations, err = migrator.Down(ctx, n)
+	case "redo":
+		var redone *repository.Migration
+		if redone, err = migrator.Redo(ctx); redone != nil {
+			migrations = append(migrations, *redone)
+		}
+	case "status":
+		var statuses []repository.MigrationStatus
+		if statuses, err = migrator.Status(ctx); err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if !status.AppliedAt.IsZero() {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return nil
+	}
+	for _, migration := range migrations {
+		fmt.Printf("%s\t%04d\t%s\n", operation, migration.Version, migration.Name)
+	}
+	if err == nil && len(migrations) == 0 {
+		fmt.Println("nothing to migrate")
+	}
+	return err
+}
+
+//checkCommand scans the stored configs for integrity problems and prints them as JSON or as tab separated lines.
+//Migrations are not applied, so databases which can not be migrated yet can be checked. It fails if problems remain unfixed
+func checkCommand(args []string) error {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	types := flags.String("types", "", "comma separated config types to check, all types if empty")
+	fix := flags.Bool("fix", false, "apply safe repairs, like removing blanks around hosts and ports")
+	format := flags.String("format", formatJSON, "output format: json or text")
+	serviceSettings, err := loadSettings(flags, args)
+	if err != nil {
+		return err
+	}
+	if *format != formatJSON && *format != "text" {
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+
+	dbConn, err := repository.OpenPostgresDB(serviceSettings.Postgres, serviceSettings.Postgres.CredentialsProvider())
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	repos := repository.ConfigRepos{
+		MongoDB:     repository.NewMongoDBConfigRepo(dbConn),
+		TempConfig:  repository.NewTempConfigRepo(dbConn),
+		TsConfig:    repository.NewTsConfigRepo(dbConn),
+		FeatureFlag: repository.NewFeatureFlagRepo(dbConn),
+	}
+	report, err := checkIntegrity(context.Background(), repos, splitList(*types), *fix)
+	if err != nil {
+		return err
+	}
+	if *format == formatJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, issue := range report.Issues {
+			fmt.Printf("%s\t%s\t%s\t%s\t%t\t%s\n", issue.Problem, issue.ConfigType, issue.ConfigName, issue.Field, issue.Fixed, issue.Description)
+		}
+	}
+	unfixed := 0
+	for _, issue := range report.Issues {
+		if !issue.Fixed {
+			unfixed++
+		}
+	}
+	if unfixed > 0 {
+		return fmt.Errorf("%d unfixed problem(s) found in %d configs", unfixed, report.Scanned)
+	}
+	return nil
+}
+
+//loadSettings parses the flags of a command together with the flags of the settings and applies the loaded log level
+func loadSettings(flags *flag.FlagSet, args []string) (*settings.Settings, error) {
+	loader := settings.NewLoader(flags)
+	flags.Parse(args)
+	serviceSettings, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	if _, err = logging.SetLevel(serviceSettings.Server.LogLevel); err != nil {
+		return nil, err
+	}
+	return serviceSettings, nil
+}
+
+//archiveFormat returns the explicitly given format or derives it from the file extension
+func archiveFormat(format, fileName string) string {
+	if format != "" {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	case ".toml":
+		return formatTOML
+	default:
+		return formatJSON
+	}
+}
+
+func splitList(list string) []string {
+	if list == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
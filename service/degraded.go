@@ -0,0 +1,294 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	"golang.org/x/net/context"
+)
+
+//errDatabaseNotConnected fails health checks of a server started before its database was connected
+var errDatabaseNotConnected = errors.New("database is not connected yet")
+
+//connectUntilConnected calls connect until it succeeds and waits backoff(failures) after every failed call.
+//It does not give up, a server started before its database is connected stays not ready and serves the snapshot meanwhile
+func connectUntilConnected(connect func() error, backoff func(failures int) time.Duration) {
+	for failures := 1; ; failures++ {
+		err := connect()
+		if err == nil {
+			return
+		}
+		delay := backoff(failures)
+		slog.Error("failed to init postgres db, retrying", "failures", failures, "delay", delay, "error", err)
+		time.Sleep(delay)
+	}
+}
+
+//configRepo is implemented by the repositories of every config type, T is the config entity
+type configRepo[T any] interface {
+	Find(ctx context.Context, configName string) (*T, error)
+	FindAll(ctx context.Context) ([]T, error)
+	Iterate(ctx context.Context, options repository.ListOptions, fn func(config *T) error) (string, error)
+	Count(ctx context.Context) (int, error)
+	Update(ctx context.Context, config *T) (string, error)
+	Patch(ctx context.Context, config *T, fields []string) (string, error)
+	Save(ctx context.Context, config *T) (string, error)
+	Delete(ctx context.Context, configName string, expectedRevision int64) (string, error)
+}
+
+//snapshotRepo forwards to the repository of the database once it is connected. Before that and while the database is unavailable,
+//Find, FindAll, Iterate and Count read a snapshot of the last known configs, other methods fail with repository.ErrUnavailable.
+//The snapshot is loaded from a file and updated by every successful read
+type snapshotRepo[T any] struct {
+	configType string
+	name       func(config *T) string
+	repo       atomic.Pointer[configRepo[T]]
+
+	mu       sync.RWMutex
+	snapshot map[string]T
+}
+
+func newSnapshotRepo[T any](configType string, name func(config *T) string) *snapshotRepo[T] {
+	return &snapshotRepo[T]{configType: configType, name: name, snapshot: make(map[string]T)}
+}
+
+//connect makes r forward to repo
+func (r *snapshotRepo[T]) connect(repo configRepo[T]) {
+	r.repo.Store(&repo)
+}
+
+func (r *snapshotRepo[T]) current() (configRepo[T], error) {
+	repo := r.repo.Load()
+	if repo == nil {
+		return nil, repository.ErrUnavailable
+	}
+	return *repo, nil
+}
+
+func (r *snapshotRepo[T]) remember(configs ...T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range configs {
+		r.snapshot[r.name(&configs[i])] = configs[i]
+	}
+}
+
+func (r *snapshotRepo[T]) forget(configName string) {
+	r.mu.Lock()
+	delete(r.snapshot, configName)
+	r.mu.Unlock()
+}
+
+func (r *snapshotRepo[T]) Find(ctx context.Context, configName string) (*T, error) {
+	repo, err := r.current()
+	if err == nil {
+		config, findErr := repo.Find(ctx, configName)
+		if findErr == nil {
+			r.remember(*config)
+			return config, nil
+		}
+		if findErr != repository.ErrUnavailable {
+			return nil, findErr
+		}
+		err = findErr
+	}
+	r.mu.RLock()
+	config, ok := r.snapshot[configName]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, err
+	}
+	snapshotReads.WithLabelValues(r.configType).Inc()
+	slog.WarnContext(ctx, "database is unavailable, config is read from the snapshot", "type", r.configType, "name", configName)
+	return &config, nil
+}
+
+func (r *snapshotRepo[T]) FindAll(ctx context.Context) ([]T, error) {
+	repo, err := r.current()
+	if err == nil {
+		configs, findErr := repo.FindAll(ctx)
+		if findErr == nil {
+			snapshot := make(map[string]T, len(configs))
+			for i := range configs {
+				snapshot[r.name(&configs[i])] = configs[i]
+			}
+			r.mu.Lock()
+			r.snapshot = snapshot
+			r.mu.Unlock()
+			return configs, nil
+		}
+		if findErr != repository.ErrUnavailable {
+			return nil, findErr
+		}
+		err = findErr
+	}
+	r.mu.RLock()
+	if len(r.snapshot) == 0 {
+		r.mu.RUnlock()
+		return nil, err
+	}
+	configs := make([]T, 0, len(r.snapshot))
+	for _, config := range r.snapshot {
+		configs = append(configs, config)
+	}
+	r.mu.RUnlock()
+	sort.Slice(configs, func(i, j int) bool {
+		return r.name(&configs[i]) < r.name(&configs[j])
+	})
+	snapshotReads.WithLabelValues(r.configType).Inc()
+	slog.WarnContext(ctx, "database is unavailable, configs are read from the snapshot", "type", r.configType)
+	return configs, nil
+}
+
+//Iterate reads the snapshot with the filters, ordering and paging of options when the database is unavailable before the first config is read
+func (r *snapshotRepo[T]) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *T) error) (string, error) {
+	repo, err := r.current()
+	if err == nil {
+		read := false
+		nextPageToken, iterateErr := repo.Iterate(ctx, options, func(config *T) error {
+			read = true
+			return fn(config)
+		})
+		if iterateErr != repository.ErrUnavailable || read {
+			return nextPageToken, iterateErr
+		}
+		err = iterateErr
+	}
+	r.mu.RLock()
+	if len(r.snapshot) == 0 {
+		r.mu.RUnlock()
+		return "", err
+	}
+	configs := make([]T, 0, len(r.snapshot))
+	for _, config := range r.snapshot {
+		configs = append(configs, config)
+	}
+	r.mu.RUnlock()
+	snapshotReads.WithLabelValues(r.configType).Inc()
+	slog.WarnContext(ctx, "database is unavailable, configs are listed from the snapshot", "type", r.configType)
+	return repository.IterateSlice(configs, options, fn)
+}
+
+func (r *snapshotRepo[T]) Count(ctx context.Context) (int, error) {
+	repo, err := r.current()
+	if err == nil {
+		count, countErr := repo.Count(ctx)
+		if countErr != repository.ErrUnavailable {
+			return count, countErr
+		}
+		err = countErr
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.snapshot) == 0 {
+		return 0, err
+	}
+	return len(r.snapshot), nil
+}
+
+func (r *snapshotRepo[T]) Update(ctx context.Context, config *T) (string, error) {
+	repo, err := r.current()
+	if err != nil {
+		return "", err
+	}
+	defer r.forget(r.name(config))
+	return repo.Update(ctx, config)
+}
+
+func (r *snapshotRepo[T]) Patch(ctx context.Context, config *T, fields []string) (string, error) {
+	repo, err := r.current()
+	if err != nil {
+		return "", err
+	}
+	defer r.forget(r.name(config))
+	return repo.Patch(ctx, config, fields)
+}
+
+func (r *snapshotRepo[T]) Save(ctx context.Context, config *T) (string, error) {
+	repo, err := r.current()
+	if err != nil {
+		return "", err
+	}
+	defer r.forget(r.name(config))
+	return repo.Save(ctx, config)
+}
+
+func (r *snapshotRepo[T]) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
+	repo, err := r.current()
+	if err != nil {
+		return "", err
+	}
+	defer r.forget(configName)
+	return repo.Delete(ctx, configName, expectedRevision)
+}
+
+//pendingTransactor fails with repository.ErrUnavailable until the database is connected
+type pendingTransactor struct {
+	transactor atomic.Pointer[repository.Transactor]
+}
+
+func (t *pendingTransactor) connect(transactor repository.Transactor) {
+	t.transactor.Store(&transactor)
+}
+
+func (t *pendingTransactor) InTransaction(ctx context.Context, fn func(repos repository.ConfigRepos) error) error {
+	transactor := t.transactor.Load()
+	if transactor == nil {
+		return repository.ErrUnavailable
+	}
+	return (*transactor).InTransaction(ctx, fn)
+}
+
+//snapshotRepos are the repositories of all config types used by the server, they are connected to the database by connect
+type snapshotRepos struct {
+	mongoDB     *snapshotRepo[entitie.Mongodb]
+	tempConfig  *snapshotRepo[entitie.Tempconfig]
+	tsConfig    *snapshotRepo[entitie.Tsconfig]
+	featureFlag *snapshotRepo[entitie.Featureflag]
+	transactor  *pendingTransactor
+}
+
+func newSnapshotRepos() *snapshotRepos {
+	return &snapshotRepos{
+		mongoDB:     newSnapshotRepo(mongodb, func(config *entitie.Mongodb) string { return config.Domain }),
+		tempConfig:  newSnapshotRepo(tempconfig, func(config *entitie.Tempconfig) string { return config.RestApiRoot }),
+		tsConfig:    newSnapshotRepo(tsconfig, func(config *entitie.Tsconfig) string { return config.Module }),
+		featureFlag: newSnapshotRepo(featureflag, func(config *entitie.Featureflag) string { return config.Key }),
+		transactor:  &pendingTransactor{},
+	}
+}
+
+//load adds the configs of an archive file to the snapshots, the format is derived from the file extension
+func (r *snapshotRepos) load(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	archive, err := decodeArchive(data, archiveFormat("", file))
+	if err != nil {
+		return err
+	}
+	r.mongoDB.remember(archive.Mongodbs...)
+	r.tempConfig.remember(archive.Tempconfigs...)
+	r.tsConfig.remember(archive.Tsconfigs...)
+	r.featureFlag.remember(archive.Featureflags...)
+	slog.Info("snapshot has been loaded", "file", file, "mongodbs", len(archive.Mongodbs), "tempconfigs", len(archive.Tempconfigs), "tsconfigs", len(archive.Tsconfigs),
+		"featureflags", len(archive.Featureflags))
+	return nil
+}
+
+//connect makes all repositories use the given repositories of the database
+func (r *snapshotRepos) connect(repos repository.ConfigRepos, transactor repository.Transactor) {
+	r.mongoDB.connect(repos.MongoDB)
+	r.tempConfig.connect(repos.TempConfig)
+	r.tsConfig.connect(repos.TsConfig)
+	r.featureFlag.connect(repos.FeatureFlag)
+	r.transactor.connect(transactor)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type mockUnavailableMongoDBConfigRepo struct {
+	mockErrorMongoDBConfigRepo
+}
+
+func (m *mockUnavailableMongoDBConfigRepo) Find(ctx context.Context, configName string) (*entitie.Mongodb, error) {
+	return nil, repository.ErrUnavailable
+}
+
+func (m *mockUnavailableMongoDBConfigRepo) FindAll(ctx context.Context) ([]entitie.Mongodb, error) {
+	return nil, repository.ErrUnavailable
+}
+
+func (m *mockUnavailableMongoDBConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Mongodb) error) (string, error) {
+	return "", repository.ErrUnavailable
+}
+
+func (m *mockUnavailableMongoDBConfigRepo) Count(ctx context.Context) (int, error) {
+	return 0, repository.ErrUnavailable
+}
+
+func TestSnapshotRepos(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "snapshot.yaml")
+	if err := ioutil.WriteFile(file, []byte("mongodbs:\n- domain: fromFile\n  host: fileHost\n"), 0600); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	repos := newSnapshotRepos()
+	if err := repos.load(file); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	server := &configServer{configCache: cache.New(time.Minute, time.Minute), mongoDBConfigRepo: repos.mongoDB, tempConfigRepo: repos.tempConfig, tsConfigRepo: repos.tsConfig, transactor: repos.transactor}
+	ctx := context.Background()
+	readsBefore := testutil.ToFloat64(snapshotReads.WithLabelValues(mongodb))
+
+	res, err := server.GetConfigByName(ctx, &pb.GetConfigByNameRequest{ConfigType: mongodb, ConfigName: "fromFile"})
+	if assert.NoError(t, err, "configs of the snapshot are served before the database is connected") {
+		assert.Contains(t, string(res.Config), "fileHost")
+	}
+	assert.Equal(t, readsBefore+1, testutil.ToFloat64(snapshotReads.WithLabelValues(mongodb)))
+	_, err = server.GetConfigByName(ctx, &pb.GetConfigByNameRequest{ConfigType: mongodb, ConfigName: "testName"})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = repos.mongoDB.Save(ctx, &entitie.Mongodb{Domain: "testName"})
+	assert.Equal(t, repository.ErrUnavailable, err, "writes need the database")
+	assert.Equal(t, repository.ErrUnavailable, repos.transactor.InTransaction(ctx, func(repository.ConfigRepos) error { return nil }))
+
+	repos.connect(repository.ConfigRepos{MongoDB: &mockMongoDBConfigRepo{}, TempConfig: &mockTempConfigRepo{}, TsConfig: &mockTsConfigRepo{}, FeatureFlag: &mockFeatureFlagRepo{}}, &mockTransactor{})
+	_, err = repos.mongoDB.Find(ctx, "testName")
+	assert.NoError(t, err)
+	assert.NoError(t, repos.transactor.InTransaction(ctx, func(repository.ConfigRepos) error { return nil }))
+
+	repos.mongoDB.connect(&mockUnavailableMongoDBConfigRepo{})
+	config, err := repos.mongoDB.Find(ctx, "testName")
+	if assert.NoError(t, err, "configs read before the database became unavailable are served") {
+		assert.Equal(t, "testHost", config.Host)
+	}
+	configs, err := repos.mongoDB.FindAll(ctx)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"fromFile", "testName"}, []string{configs[0].Domain, configs[1].Domain})
+	}
+	var names []string
+	nextPageToken, err := repos.mongoDB.Iterate(ctx, repository.ListOptions{PageSize: 1, Descending: true}, func(config *entitie.Mongodb) error {
+		names = append(names, config.Domain)
+		return nil
+	})
+	if assert.NoError(t, err, "configs are listed from the snapshot") {
+		assert.Equal(t, []string{"testName"}, names)
+		assert.NotEmpty(t, nextPageToken)
+	}
+	count, err := repos.mongoDB.Count(ctx)
+	if assert.NoError(t, err, "configs of the snapshot are counted") {
+		assert.Equal(t, 2, count)
+	}
+	_, err = repos.tsConfig.FindAll(ctx)
+	assert.NoError(t, err)
+}
+
+func TestConnectUntilConnected(t *testing.T) {
+	calls := 0
+	var delays []int
+	connectUntilConnected(func() error {
+		calls++
+		if calls < 3 {
+			return repository.ErrUnavailable
+		}
+		return nil
+	}, func(failures int) time.Duration {
+		delays = append(delays, failures)
+		return 0
+	})
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{1, 2}, delays)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/YAWAL/GetMeConf/repository"
+	"golang.org/x/net/context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//statusError converts an error of a repository into a gRPC status error with details clients can branch on, ResourceInfo names the affected config.
+//Context errors become Canceled and DeadlineExceeded, status errors and unknown errors are returned unchanged
+func statusError(err error, configType, configName string) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch err {
+	case context.Canceled:
+		return status.Error(codes.Canceled, err.Error())
+	case context.DeadlineExceeded:
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	switch e := err.(type) {
+	case *repository.FieldError:
+		return invalidArgument(e.Field, e.Description)
+	}
+	var code codes.Code
+	switch err {
+	case repository.ErrNotFound:
+		code = codes.NotFound
+	case repository.ErrAlreadyExists:
+		code = codes.AlreadyExists
+	case repository.ErrRevisionMismatch:
+		//clients should read the config again and retry
+		code = codes.Aborted
+	case repository.ErrUnavailable:
+		code = codes.Unavailable
+	default:
+		return err
+	}
+	return resourceError(code, err, configType, configName)
+}
+
+//resourceError returns a status error with a ResourceInfo detail naming the config the error is about, errors about no particular config type have no details
+func resourceError(code codes.Code, err error, configType, configName string) error {
+	if configType == "" {
+		return status.Error(code, err.Error())
+	}
+	st := status.New(code, fmt.Sprintf("%s config %q: %v", configType, configName, err))
+	detailed, detailsErr := st.WithDetails(&errdetails.ResourceInfo{ResourceType: configType, ResourceName: configName, Description: err.Error()})
+	if detailsErr != nil {
+		slog.Error("error during adding status details", "error", detailsErr)
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+//invalidArgument returns an InvalidArgument status error with a BadRequest detail describing the invalid field of the request
+func invalidArgument(field, description string) error {
+	st := status.New(codes.InvalidArgument, field+": "+description)
+	detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: description}}})
+	if detailsErr != nil {
+		slog.Error("error during adding status details", "error", detailsErr)
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func unexpectedTypeError(configType string) error {
+	return invalidArgument("configType", fmt.Sprintf("unexpected type %q", configType))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/repository"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusError(t *testing.T) {
+	for err, code := range map[error]codes.Code{
+		repository.ErrNotFound:         codes.NotFound,
+		repository.ErrAlreadyExists:    codes.AlreadyExists,
+		repository.ErrRevisionMismatch: codes.Aborted,
+		repository.ErrUnavailable:      codes.Unavailable,
+	} {
+		st, ok := status.FromError(statusError(err, "mongodb", "testName"))
+		if assert.True(t, ok, "%v", err) {
+			assert.Equal(t, code, st.Code())
+			if assert.Equal(t, 1, len(st.Details())) {
+				info := st.Details()[0].(*errdetails.ResourceInfo)
+				assert.Equal(t, "mongodb", info.ResourceType)
+				assert.Equal(t, "testName", info.ResourceName)
+			}
+		}
+	}
+
+	st, ok := status.FromError(statusError(&repository.FieldError{Field: "host", Description: "must not be empty"}, "mongodb", "testName"))
+	if assert.True(t, ok) {
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		if assert.Equal(t, 1, len(st.Details())) {
+			violations := st.Details()[0].(*errdetails.BadRequest).FieldViolations
+			if assert.Equal(t, 1, len(violations)) {
+				assert.Equal(t, "host", violations[0].Field)
+				assert.Equal(t, "must not be empty", violations[0].Description)
+			}
+		}
+	}
+
+	st, ok = status.FromError(statusError(repository.ErrUnavailable, "", ""))
+	if assert.True(t, ok) {
+		assert.Equal(t, codes.Unavailable, st.Code())
+		assert.Empty(t, st.Details())
+	}
+
+	statusErr := status.Error(codes.FailedPrecondition, "precondition")
+	assert.Equal(t, statusErr, statusError(statusErr, "mongodb", "testName"))
+	unknownErr := errors.New("error from database querying")
+	assert.Equal(t, unknownErr, statusError(unknownErr, "mongodb", "testName"))
+	assert.Nil(t, statusError(nil, "mongodb", "testName"))
+}
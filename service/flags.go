@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/flags"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"golang.org/x/net/context"
+)
+
+//EvaluateFlags returns the variants of the requested feature flags (of every flag if none is requested) served to the client described by the request.
+//A missing flag does not fail the request, its evaluation has the error reason. Evaluations are not cached since they depend on the client
+func (s *configServer) EvaluateFlags(ctx context.Context, evaluateRequest *pb.EvaluateFlagsRequest) (*pb.EvaluateFlagsResponce, error) {
+	client := flags.Context{TargetingKey: evaluateRequest.TargetingKey, Attributes: evaluateRequest.Attributes}
+	response := &pb.EvaluateFlagsResponce{}
+	if len(evaluateRequest.FlagKeys) == 0 {
+		all, err := s.featureFlagRepo.FindAll(ctx)
+		if err != nil {
+			return nil, statusError(err, featureflag, "")
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i].Key < all[j].Key })
+		for i := range all {
+			response.Flags = append(response.Flags, flagEvaluation(&all[i], client))
+		}
+		return response, nil
+	}
+	for _, key := range evaluateRequest.FlagKeys {
+		flag, err := s.featureFlagRepo.Find(ctx, key)
+		if err == repository.ErrNotFound {
+			response.Flags = append(response.Flags, &pb.FlagEvaluation{Key: key, Reason: flags.ReasonError, Error: "flag not found"})
+			continue
+		}
+		if err != nil {
+			return nil, statusError(err, featureflag, key)
+		}
+		response.Flags = append(response.Flags, flagEvaluation(flag, client))
+	}
+	return response, nil
+}
+
+//flagEvaluation evaluates flag for client, the value of the variant is sent as JSON
+func flagEvaluation(flag *entitie.Featureflag, client flags.Context) *pb.FlagEvaluation {
+	evaluation := flags.Evaluate(flag, client)
+	if evaluation.Reason == flags.ReasonError {
+		return &pb.FlagEvaluation{Key: flag.Key, Variant: evaluation.Variant, Reason: evaluation.Reason, Error: "variant is not one of the variants of the flag"}
+	}
+	value, err := json.Marshal(evaluation.Value)
+	if err != nil {
+		return &pb.FlagEvaluation{Key: flag.Key, Variant: evaluation.Variant, Reason: flags.ReasonError, Error: err.Error()}
+	}
+	return &pb.FlagEvaluation{Key: flag.Key, Variant: evaluation.Variant, Value: value, Reason: evaluation.Reason}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/flags"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type storedFeatureFlagRepo struct {
+	mockFeatureFlagRepo
+	configs []entitie.Featureflag
+	err     error
+}
+
+func (m *storedFeatureFlagRepo) Find(ctx context.Context, configName string) (*entitie.Featureflag, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	for i := range m.configs {
+		if m.configs[i].Key == configName {
+			return &m.configs[i], nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (m *storedFeatureFlagRepo) FindAll(ctx context.Context) ([]entitie.Featureflag, error) {
+	return append([]entitie.Featureflag(nil), m.configs...), m.err
+}
+
+func TestEvaluateFlags(t *testing.T) {
+	repo := &storedFeatureFlagRepo{configs: []entitie.Featureflag{
+		{Key: "search-ranking", Enabled: true, Variants: entitie.JSONMap{"classic": map[string]interface{}{"algorithm": "bm25"}, "semantic": map[string]interface{}{"algorithm": "embeddings"}},
+			OffVariant: "classic", DefaultVariant: "classic",
+			Rules: entitie.FlagRules{{Conditions: []entitie.FlagCondition{{Attribute: "country", Operator: entitie.FlagOperatorIn, Values: entitie.StringList{"UA"}}}, Variant: "semantic"}}},
+		{Key: "maintenance-banner", Variants: entitie.JSONMap{"hidden": "", "shown": "maintenance"}, OffVariant: "hidden", DefaultVariant: "shown"},
+	}}
+	mock := &mockConfigServer{}
+	mock.featureFlagRepo = repo
+
+	res, err := mock.EvaluateFlags(context.Background(), &pb.EvaluateFlagsRequest{FlagKeys: []string{"search-ranking", "missing"}, TargetingKey: "user-1", Attributes: map[string]string{"country": "UA"}})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []*pb.FlagEvaluation{
+		{Key: "search-ranking", Variant: "semantic", Value: []byte(`{"algorithm":"embeddings"}`), Reason: flags.ReasonTargetingMatch},
+		{Key: "missing", Reason: flags.ReasonError, Error: "flag not found"},
+	}, res.Flags, "evaluations are in the order of the requested keys")
+
+	res, err = mock.EvaluateFlags(context.Background(), &pb.EvaluateFlagsRequest{})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []*pb.FlagEvaluation{
+		{Key: "maintenance-banner", Variant: "hidden", Value: []byte(`""`), Reason: flags.ReasonDisabled},
+		{Key: "search-ranking", Variant: "classic", Value: []byte(`{"algorithm":"bm25"}`), Reason: flags.ReasonDefault},
+	}, res.Flags, "all flags are evaluated by key when none is requested")
+
+	repo.err = repository.ErrUnavailable
+	_, err = mock.EvaluateFlags(context.Background(), &pb.EvaluateFlagsRequest{FlagKeys: []string{"search-ranking"}})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	repo.err = errors.New("error from database querying")
+	_, err = mock.EvaluateFlags(context.Background(), &pb.EvaluateFlagsRequest{})
+	assert.Equal(t, repo.err, err)
+}
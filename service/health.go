@@ -0,0 +1,104 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	//livenessService is checked by liveness probes, it is serving as long as the server runs
+	livenessService = "liveness"
+	//readinessService is checked by readiness probes, it is serving while the database is reachable and migrated.
+	//The status of the whole server, checked with an empty service name, is the readiness
+	readinessService = "readiness"
+)
+
+//healthChecker keeps the statuses of the gRPC health service up to date with periodic database checks.
+//While checks fail they are retried after backoff, but at least once every interval
+type healthChecker struct {
+	server   *health.Server
+	check    func(ctx context.Context) error
+	interval time.Duration
+	backoff  func(failures int) time.Duration
+
+	mu       sync.Mutex
+	ready    bool
+	stopping bool
+}
+
+//newHealthChecker reports the server as alive, but not ready until the first successful check
+func newHealthChecker(server *health.Server, check func(ctx context.Context) error, interval time.Duration, backoff func(failures int) time.Duration) *healthChecker {
+	server.SetServingStatus(livenessService, healthpb.HealthCheckResponse_SERVING)
+	server.SetServingStatus(readinessService, healthpb.HealthCheckResponse_NOT_SERVING)
+	server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return &healthChecker{server: server, check: check, interval: interval, backoff: backoff}
+}
+
+//update runs the check once and returns if it succeeded, it may take up to one interval
+func (h *healthChecker) update(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, h.interval)
+	defer cancel()
+	err := h.check(ctx)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stopping {
+		return false
+	}
+	ready := err == nil
+	if ready != h.ready {
+		slog.Info("readiness changed", "ready", ready, "error", err)
+	}
+	h.ready = ready
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	h.server.SetServingStatus(readinessService, status)
+	h.server.SetServingStatus("", status)
+	return ready
+}
+
+//run updates the statuses until stop is closed
+func (h *healthChecker) run(stop <-chan struct{}) {
+	failures := 0
+	for {
+		failures++
+		if h.update(context.Background()) {
+			failures = 0
+		}
+		timer := time.NewTimer(h.nextCheck(failures))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+//nextCheck returns the delay of the next check after the given number of failed checks in a row
+func (h *healthChecker) nextCheck(failures int) time.Duration {
+	if failures == 0 || h.backoff == nil {
+		return h.interval
+	}
+	if delay := h.backoff(failures); delay < h.interval {
+		return delay
+	}
+	return h.interval
+}
+
+//shutdown reports all services as not serving, so load balancers stop sending requests before the server stops
+func (h *healthChecker) shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stopping = true
+	for _, service := range []string{"", livenessService, readinessService} {
+		h.server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
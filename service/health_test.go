@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func servingStatus(t *testing.T, server *health.Server, service string) healthpb.HealthCheckResponse_ServingStatus {
+	res, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+		return healthpb.HealthCheckResponse_UNKNOWN
+	}
+	return res.Status
+}
+
+func TestHealthChecker(t *testing.T) {
+	server := health.NewServer()
+	var checkErr error
+	checker := newHealthChecker(server, func(ctx context.Context) error {
+		return checkErr
+	}, time.Second, func(failures int) time.Duration {
+		return time.Duration(failures) * 300 * time.Millisecond
+	})
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, server, livenessService))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, readinessService), "not ready before the first check")
+
+	checker.update(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, server, readinessService))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, server, ""))
+
+	checkErr = errors.New("database is unavailable")
+	checker.update(context.Background())
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, readinessService))
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, ""))
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, servingStatus(t, server, livenessService), "a failing database does not make the server dead")
+
+	assert.Equal(t, 600*time.Millisecond, checker.nextCheck(2), "failed checks are retried after backoff")
+	assert.Equal(t, time.Second, checker.nextCheck(4), "failed checks are retried at least every interval")
+	assert.Equal(t, time.Second, checker.nextCheck(0))
+
+	checkErr = nil
+	checker.shutdown()
+	checker.update(context.Background())
+	for _, service := range []string{"", livenessService, readinessService} {
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, servingStatus(t, server, service), service)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/native"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"golang.org/x/net/context"
+)
+
+//problems found by the integrity scan
+const (
+	problemDuplicateName   = "duplicate_name"
+	problemEmptyName       = "empty_name"
+	problemInvalidPort     = "invalid_port"
+	problemInvalidHost     = "invalid_host"
+	problemSchemaViolation = "schema_violation"
+)
+
+//CheckIntegrity scans all stored configs of the requested types (of every type if none is given) for problems.
+//With fix, problems having a safe repair are repaired with a conditional write, so configs changed meanwhile are left alone
+func (a *adminServer) CheckIntegrity(ctx context.Context, checkRequest *pb.CheckIntegrityRequest) (*pb.CheckIntegrityResponce, error) {
+	report, err := checkIntegrity(ctx, a.repos, checkRequest.ConfigTypes, checkRequest.Fix)
+	if err != nil {
+		return nil, statusError(err, "", "")
+	}
+	repaired := make(map[string]bool)
+	for _, issue := range report.Issues {
+		if issue.Fixed && !repaired[issue.ConfigType+"/"+issue.ConfigName] {
+			repaired[issue.ConfigType+"/"+issue.ConfigName] = true
+			countWrite(issue.ConfigType, actionUpdate)
+		}
+	}
+	if len(repaired) > 0 {
+		a.configCache.Flush()
+	}
+	return report, nil
+}
+
+//configIssues are the issues of one config, repairs holds the repaired values of fields by their JSON names
+type configIssues struct {
+	configType string
+	configName string
+	issues     []*pb.IntegrityIssue
+	repairs    map[string]string
+}
+
+func newConfigIssues(configType, configName string) *configIssues {
+	return &configIssues{configType: configType, configName: configName, repairs: make(map[string]string)}
+}
+
+func (c *configIssues) add(field, problem, description string) {
+	c.issues = append(c.issues, &pb.IntegrityIssue{ConfigType: c.configType, ConfigName: c.configName, Field: field, Problem: problem, Description: description})
+}
+
+//checkName reports empty and duplicate names, configs without a unique name can not be repaired since writes address them by name
+func (c *configIssues) checkName(field string, count int) bool {
+	switch {
+	case c.configName == "":
+		c.add(field, problemEmptyName, "name must not be empty")
+		return false
+	case count > 1:
+		c.add(field, problemDuplicateName, fmt.Sprintf("%d configs have this name", count))
+		return false
+	}
+	return true
+}
+
+//checkAddress reports invalid hosts and ports, with the repair of hosts which are only padded by blanks or end with a dot.
+//Ports are read as numbers, blanks and leading zeros of ports stored as text by former versions are ignored
+func (c *configIssues) checkAddress(host string, port entitie.Port) {
+	if err := repository.ValidateHost(host); err != nil {
+		repaired := strings.TrimSuffix(strings.TrimSpace(host), ".")
+		if repository.ValidateHost(repaired) == nil {
+			c.repairs["host"] = repaired
+			err = fmt.Errorf("%v, can be repaired to %q", err, repaired)
+		}
+		c.add("host", problemInvalidHost, err.Error())
+	}
+	if err := repository.ValidatePort(port); err != nil {
+		c.add("port", problemInvalidPort, err.Error())
+	}
+}
+
+//checkSchema reports all fields of a config breaking the rules of its type, except the fields which are checked separately
+func (c *configIssues) checkSchema(fieldErrs []*repository.FieldError, checked ...string) {
+	for _, fieldErr := range fieldErrs {
+		if !slices.Contains(checked, fieldErr.Field) {
+			c.add(fieldErr.Field, problemSchemaViolation, fieldErr.Description)
+		}
+	}
+}
+
+//repair writes the repaired fields with patch and marks their issues fixed, failures are logged and leave the issues unfixed
+func (c *configIssues) repair(ctx context.Context, patch func(repairs map[string]string, fields []string) error) {
+	if len(c.repairs) == 0 {
+		return
+	}
+	fields := make([]string, 0, len(c.repairs))
+	for field := range c.repairs {
+		fields = append(fields, field)
+	}
+	if err := patch(c.repairs, fields); err != nil {
+		slog.WarnContext(ctx, "error during repairing config", "configType", c.configType, "configName", c.configName, "error", err)
+		return
+	}
+	for _, issue := range c.issues {
+		if _, ok := c.repairs[issue.Field]; ok {
+			issue.Fixed = true
+		}
+	}
+	slog.InfoContext(ctx, "config has been repaired", "configType", c.configType, "configName", c.configName, "fields", fields)
+}
+
+//countNames counts how often each name occurs
+func countNames(n int, name func(i int) string) map[string]int {
+	counts := make(map[string]int, n)
+	for i := 0; i < n; i++ {
+		counts[name(i)]++
+	}
+	return counts
+}
+
+//checkIntegrity scans the stored configs of the requested types for empty and duplicate names, invalid hosts and ports
+//and violated rules of their types. With fix, repairable configs are patched at their current revision
+func checkIntegrity(ctx context.Context, repos repository.ConfigRepos, requestedTypes []string, fix bool) (*pb.CheckIntegrityResponce, error) {
+	selected, err := selectConfigTypes(requestedTypes)
+	if err != nil {
+		return nil, err
+	}
+	report := new(pb.CheckIntegrityResponce)
+	if selected[mongodb] {
+		configs, err := repos.MongoDB.FindAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		names := countNames(len(configs), func(i int) string { return configs[i].Domain })
+		reported := make(map[string]bool)
+		for _, config := range configs {
+			issues := newConfigIssues(mongodb, config.Domain)
+			if issues.checkName("domain", names[config.Domain]) {
+				issues.checkAddress(config.Host, config.Port)
+				issues.checkSchema(repository.MongodbFieldErrors(&config), "domain", "host", "port")
+				if fix {
+					issues.repair(ctx, func(repairs map[string]string, fields []string) error {
+						repaired := config
+						repaired.Host = repairs["host"]
+						_, err := repos.MongoDB.Patch(ctx, &repaired, fields)
+						return err
+					})
+				}
+			} else if reported[config.Domain] {
+				continue
+			}
+			reported[config.Domain] = true
+			report.Issues = append(report.Issues, issues.issues...)
+		}
+		report.Scanned += int64(len(configs))
+	}
+	if selected[tempconfig] {
+		configs, err := repos.TempConfig.FindAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		names := countNames(len(configs), func(i int) string { return configs[i].RestApiRoot })
+		reported := make(map[string]bool)
+		for _, config := range configs {
+			issues := newConfigIssues(tempconfig, config.RestApiRoot)
+			if issues.checkName("restApiRoot", names[config.RestApiRoot]) {
+				issues.checkAddress(config.Host, config.Port)
+				issues.checkSchema(repository.TempconfigFieldErrors(&config), "restApiRoot", "host", "port")
+				if fix {
+					issues.repair(ctx, func(repairs map[string]string, fields []string) error {
+						repaired := config
+						repaired.Host = repairs["host"]
+						_, err := repos.TempConfig.Patch(ctx, &repaired, fields)
+						return err
+					})
+				}
+			} else if reported[config.RestApiRoot] {
+				continue
+			}
+			reported[config.RestApiRoot] = true
+			report.Issues = append(report.Issues, issues.issues...)
+		}
+		report.Scanned += int64(len(configs))
+	}
+	if selected[tsconfig] {
+		configs, err := repos.TsConfig.FindAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		names := countNames(len(configs), func(i int) string { return configs[i].Module })
+		stored := make(map[string]*entitie.Tsconfig, len(configs))
+		for i := range configs {
+			stored[configs[i].Module] = &configs[i]
+		}
+		find := func(name string) (*entitie.Tsconfig, error) {
+			if config, ok := stored[name]; ok {
+				return config, nil
+			}
+			return nil, repository.ErrNotFound
+		}
+		reported := make(map[string]bool)
+		for i := range configs {
+			config := &configs[i]
+			issues := newConfigIssues(tsconfig, config.Module)
+			if issues.checkName("module", names[config.Module]) {
+				issues.checkSchema(repository.TsconfigFieldErrors(config), "module")
+				if _, err := native.ResolveTsconfig(config, find); err != nil {
+					issues.add("extends", problemSchemaViolation, err.Error())
+				}
+			} else if reported[config.Module] {
+				continue
+			}
+			reported[config.Module] = true
+			report.Issues = append(report.Issues, issues.issues...)
+		}
+		report.Scanned += int64(len(configs))
+	}
+	if selected[featureflag] {
+		configs, err := repos.FeatureFlag.FindAll(ctx)
+		if err != nil {
+			return nil, err
+		}
+		names := countNames(len(configs), func(i int) string { return configs[i].Key })
+		reported := make(map[string]bool)
+		for i := range configs {
+			config := &configs[i]
+			issues := newConfigIssues(featureflag, config.Key)
+			if issues.checkName("key", names[config.Key]) {
+				issues.checkSchema(repository.FeatureflagFieldErrors(config), "key")
+			} else if reported[config.Key] {
+				continue
+			}
+			reported[config.Key] = true
+			report.Issues = append(report.Issues, issues.issues...)
+		}
+		report.Scanned += int64(len(configs))
+	}
+	return report, nil
+}
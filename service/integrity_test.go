@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockStoredMongoDBConfigRepo struct {
+	mockMongoDBConfigRepo
+	configs []entitie.Mongodb
+	patched map[string][]string
+}
+
+func (m *mockStoredMongoDBConfigRepo) FindAll(ctx context.Context) ([]entitie.Mongodb, error) {
+	return m.configs, nil
+}
+
+func (m *mockStoredMongoDBConfigRepo) Patch(ctx context.Context, config *entitie.Mongodb, fields []string) (string, error) {
+	if config.Revision != 3 {
+		return "", repository.ErrRevisionMismatch
+	}
+	m.patched[config.Domain+" "+config.Host+":"+config.Port.String()] = fields
+	return "OK", nil
+}
+
+type mockStoredTsConfigRepo struct {
+	mockTsConfigRepo
+	configs []entitie.Tsconfig
+}
+
+func (m *mockStoredTsConfigRepo) FindAll(ctx context.Context) ([]entitie.Tsconfig, error) {
+	return m.configs, nil
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	mongoDBs := &mockStoredMongoDBConfigRepo{patched: make(map[string][]string), configs: []entitie.Mongodb{
+		{Domain: "valid", Host: "db.example.com", Port: 27017, Revision: 3},
+		{Domain: "padded", Host: " localhost ", Port: 8080, Revision: 3},
+		{Domain: "broken", Host: "http://db", Port: 70000, ReadPreference: "fastest", Revision: 3},
+		{Domain: "changed", Host: "localhost.", Port: 8080, Revision: 4},
+		{Domain: "twice", Host: "localhost", Port: 8080},
+		{Domain: "twice", Host: " localhost", Port: 8080},
+		{Domain: "", Host: "localhost", Port: 8080},
+	}}
+	tsConfigs := &mockStoredTsConfigRepo{configs: []entitie.Tsconfig{
+		{Module: "base", Target: "es2017"},
+		{Module: "app", Target: "es2017", Extends: "./missing.json"},
+		{Module: "untargeted", Labels: entitie.Labels{"team name": "web"}},
+	}}
+	repos := repository.ConfigRepos{MongoDB: mongoDBs, TempConfig: &mockTempConfigRepo{}, TsConfig: tsConfigs, FeatureFlag: &mockFeatureFlagRepo{}}
+
+	report, err := checkIntegrity(context.Background(), repos, []string{mongodb, tsconfig}, false)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Equal(t, int64(10), report.Scanned)
+	var problems []string
+	for _, issue := range report.Issues {
+		assert.False(t, issue.Fixed, "nothing is fixed without fix")
+		problems = append(problems, issue.Problem+" "+issue.ConfigType+"/"+issue.ConfigName+"."+issue.Field)
+	}
+	assert.Equal(t, []string{
+		"invalid_host mongodb/padded.host",
+		"invalid_host mongodb/broken.host",
+		"invalid_port mongodb/broken.port",
+		"schema_violation mongodb/broken.readPreference",
+		"invalid_host mongodb/changed.host",
+		"duplicate_name mongodb/twice.domain",
+		"empty_name mongodb/.domain",
+		"schema_violation tsconfig/app.extends",
+		"schema_violation tsconfig/untargeted.target",
+		"schema_violation tsconfig/untargeted.labels",
+	}, problems, "all fields breaking the rules are reported")
+	assert.Empty(t, mongoDBs.patched)
+
+	report, err = checkIntegrity(context.Background(), repos, []string{mongodb}, true)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Equal(t, map[string][]string{"padded localhost:8080": {"host"}}, sortedFields(mongoDBs.patched),
+		"only padded hosts are repaired, configs changed meanwhile and configs without a unique name are left alone")
+	fixed := 0
+	for _, issue := range report.Issues {
+		if issue.Fixed {
+			fixed++
+			assert.Equal(t, "padded", issue.ConfigName)
+		}
+	}
+	assert.Equal(t, 1, fixed)
+
+	_, err = checkIntegrity(context.Background(), repos, []string{"unknown"}, false)
+	assert.Error(t, err)
+}
+
+func TestCheckIntegrityRPC(t *testing.T) {
+	configCache := cache.New(cache.NoExpiration, cache.NoExpiration)
+	configCache.Set(mongodb+"padded", []byte("stale"), cache.NoExpiration)
+	mongoDBs := &mockStoredMongoDBConfigRepo{patched: make(map[string][]string), configs: []entitie.Mongodb{{Domain: "padded", Host: "localhost.", Port: 8080, Revision: 3}}}
+	admin := &adminServer{configCache: configCache, repos: repository.ConfigRepos{MongoDB: mongoDBs, TempConfig: &mockTempConfigRepo{}, TsConfig: &mockTsConfigRepo{}, FeatureFlag: &mockFeatureFlagRepo{}}}
+
+	report, err := admin.CheckIntegrity(context.Background(), &pb.CheckIntegrityRequest{ConfigTypes: []string{mongodb}, Fix: true})
+	if assert.NoError(t, err) && assert.Len(t, report.Issues, 1) {
+		assert.True(t, report.Issues[0].Fixed)
+	}
+	assert.Equal(t, 0, configCache.ItemCount(), "repaired configs are not served from the cache")
+}
+
+func sortedFields(patched map[string][]string) map[string][]string {
+	for _, fields := range patched {
+		sort.Strings(fields)
+	}
+	return patched
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+//contextUnaryInterceptor gives requests without a deadline the default timeout, so slow queries can not pile up behind the database connection pool.
+//Requests failed by their context are counted in the requestsCancelled metric
+func contextUnaryInterceptor(defaultTimeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, cancel := withDefaultTimeout(ctx, defaultTimeout)
+		defer cancel()
+		resp, err := handler(ctx, req)
+		if err != nil {
+			countCancellation(ctx, info.FullMethod)
+		}
+		return resp, err
+	}
+}
+
+//contextStreamInterceptor is contextUnaryInterceptor for streaming RPCs, streams of all configs of a type to slow consumers
+//outlast unary requests, so their default timeout is a setting of its own
+func contextStreamInterceptor(defaultTimeout time.Duration) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, cancel := withDefaultTimeout(stream.Context(), defaultTimeout)
+		defer cancel()
+		err := handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+		if err != nil {
+			countCancellation(ctx, info.FullMethod)
+		}
+		return err
+	}
+}
+
+//metricsUnaryInterceptor records the status code and the duration of every RPC
+func metricsUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	started := time.Now()
+	resp, err := handler(ctx, req)
+	observeRequest(info.FullMethod, started, err)
+	return resp, err
+}
+
+//metricsStreamInterceptor is metricsUnaryInterceptor for streaming RPCs
+func metricsStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	started := time.Now()
+	err := handler(srv, stream)
+	observeRequest(info.FullMethod, started, err)
+	return err
+}
+
+//chainUnaryInterceptors combines interceptors into one, as a server accepts only one. The first interceptor is the outermost
+func chainUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		chained := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], chained
+			chained = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return chained(ctx, req)
+	}
+}
+
+//chainStreamInterceptors is chainUnaryInterceptors for streaming RPCs
+func chainStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		chained := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], chained
+			chained = func(srv interface{}, stream grpc.ServerStream) error {
+				return interceptor(srv, stream, info, next)
+			}
+		}
+		return chained(srv, stream)
+	}
+}
+
+//withDefaultTimeout applies the timeout unless the client has set a deadline, a zero timeout leaves the context unchanged
+func withDefaultTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+//contextServerStream replaces the context of a server stream
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+func TestContextUnaryInterceptor(t *testing.T) {
+	interceptor := contextUnaryInterceptor(time.Minute)
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.ConfigService/GetConfigByName"}
+
+	_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, ok := ctx.Deadline()
+		if assert.True(t, ok, "the default deadline is set") {
+			assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+		}
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	clientCtx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	clientDeadline, _ := clientCtx.Deadline()
+	_, err = interceptor(clientCtx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		deadline, _ := ctx.Deadline()
+		assert.Equal(t, clientDeadline, deadline, "the deadline of the client is kept")
+		return nil, nil
+	})
+	assert.NoError(t, err)
+
+	cancelled := requestsCancelled.WithLabelValues(info.FullMethod, reasonDeadlineExceeded)
+	before := testutil.ToFloat64(cancelled)
+	_, err = contextUnaryInterceptor(time.Nanosecond)(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, statusError(ctx.Err(), "", "")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(cancelled))
+}
+
+func TestContextStreamInterceptor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &mockConfigServer{ctx: ctx}
+	info := &grpc.StreamServerInfo{FullMethod: "/api.ConfigService/GetConfigsByType"}
+	cancelled := requestsCancelled.WithLabelValues(info.FullMethod, reasonCanceled)
+	before := testutil.ToFloat64(cancelled)
+
+	err := contextStreamInterceptor(time.Minute)(nil, stream, info, func(srv interface{}, stream grpc.ServerStream) error {
+		_, ok := stream.Context().Deadline()
+		assert.True(t, ok, "the default deadline is set")
+		cancel()
+		return statusError(stream.Context().Err(), "", "")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(cancelled))
+
+	err = contextStreamInterceptor(0)(nil, &mockConfigServer{ctx: context.Background()}, info, func(srv interface{}, stream grpc.ServerStream) error {
+		_, ok := stream.Context().Deadline()
+		assert.False(t, ok, "streams have no deadline by default")
+		select {
+		case <-stream.Context().Done():
+			return statusError(stream.Context().Err(), "", "")
+		case <-time.After(50 * time.Millisecond):
+			return nil
+		}
+	})
+	assert.NoError(t, err, "a stream outlasting the request timeout is not cancelled")
+}
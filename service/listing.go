@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+
+	"github.com/YAWAL/GetMeConf/repository"
+	"github.com/YAWAL/GetMeConf/selector"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+)
+
+//nextPageTokenKey is the trailer which carries the token of the next page of GetConfigsByType
+const nextPageTokenKey = "next-page-token"
+
+//listOptions converts paging, filtering and ordering of a GetConfigsByType request into repository list options.
+//OrderBy is a field name optionally followed by "asc" or "desc", LabelSelector is a label selector like "team=orders,env in (prod,staging)"
+func listOptions(typeRequest *pb.GetConfigsByTypeRequest) (repository.ListOptions, error) {
+	options := repository.ListOptions{
+		PageSize:  int(typeRequest.PageSize),
+		PageToken: typeRequest.PageToken,
+	}
+	if typeRequest.PageSize < 0 {
+		return options, invalidArgument("pageSize", fmt.Sprintf("negative page size %d", typeRequest.PageSize))
+	}
+	for _, filter := range typeRequest.Filters {
+		options.Filters = append(options.Filters, repository.Filter{Field: filter.Field, Operator: filter.Operator, Value: filter.Value})
+	}
+	labelSelector, err := selector.Parse(typeRequest.LabelSelector)
+	if err != nil {
+		return options, invalidArgument("labelSelector", err.Error())
+	}
+	options.Selector = labelSelector
+	orderBy := strings.Fields(typeRequest.OrderBy)
+	switch {
+	case len(orderBy) == 0:
+	case len(orderBy) == 1 || len(orderBy) == 2 && strings.EqualFold(orderBy[1], "asc"):
+		options.OrderBy = orderBy[0]
+	case len(orderBy) == 2 && strings.EqualFold(orderBy[1], "desc"):
+		options.OrderBy = orderBy[0]
+		options.Descending = true
+	default:
+		return options, invalidArgument("orderBy", "invalid order "+typeRequest.OrderBy)
+	}
+	return options, nil
+}
+
+//sendConfig sends a config to the GetConfigsByType stream. Send blocks while the client is not ready to receive, a cancelled stream stops the iteration over database rows
+func sendConfig(stream pb.ConfigService_GetConfigsByTypeServer, config entitie.ConfigInterface) error {
+	if err := stream.Context().Err(); err != nil {
+		return err
+	}
+	byteRes, err := json.Marshal(withoutVariants(config))
+	if err != nil {
+		return err
+	}
+	return stream.Send(&pb.GetConfigResponce{Config: byteRes})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	"github.com/YAWAL/GetMeConf/selector"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type pagingMongoDBConfigRepo struct {
+	mockMongoDBConfigRepo
+	options repository.ListOptions
+}
+
+func (m *pagingMongoDBConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Mongodb) error) (string, error) {
+	m.options = options
+	for i := 0; i < 3; i++ {
+		if err := fn(&entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080,
+			Variants: entitie.ConfigVariants{{Name: "eu", Match: entitie.Labels{"region": "eu"}, Config: entitie.JSONMap{"host": "mongo-eu"}}}}); err != nil {
+			return "", err
+		}
+	}
+	return "nextToken", nil
+}
+
+func TestGetConfigsByType_Paging(t *testing.T) {
+	repo := &pagingMongoDBConfigRepo{}
+	mock := &mockConfigServer{}
+	mock.mongoDBConfigRepo = repo
+
+	err := mock.GetConfigsByType(&pb.GetConfigsByTypeRequest{
+		ConfigType: "mongodb",
+		PageSize:   1,
+		PageToken:  "token",
+		Filters:    []*pb.Filter{{Field: "host", Operator: "=", Value: "localhost"}, {Field: "name", Operator: "prefix", Value: "/home"}},
+		OrderBy:    "port desc",
+	}, mock)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, repository.ListOptions{
+		PageSize:   1,
+		PageToken:  "token",
+		Filters:    []repository.Filter{{Field: "host", Operator: "=", Value: "localhost"}, {Field: "name", Operator: "prefix", Value: "/home"}},
+		OrderBy:    "port",
+		Descending: true,
+	}, repo.options)
+	assert.Equal(t, 3, len(mock.Results))
+	assert.JSONEq(t, `{"domain":"testName","host":"testHost","port":8080,"revision":0}`, string(mock.Results[0].Config), "configs are listed without their variants")
+	assert.Equal(t, []string{"nextToken"}, mock.Trailer.Get(nextPageTokenKey))
+}
+
+func TestGetConfigsByType_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mock := &mockConfigServer{ctx: ctx}
+	mock.mongoDBConfigRepo = &pagingMongoDBConfigRepo{}
+
+	err := mock.GetConfigsByType(&pb.GetConfigsByTypeRequest{ConfigType: "mongodb"}, mock)
+	if assert.Error(t, err) {
+		assert.Equal(t, codes.Canceled, status.Code(err))
+	}
+	assert.Empty(t, mock.Results)
+	assert.Empty(t, mock.Trailer)
+}
+
+func TestListOptions(t *testing.T) {
+	options, err := listOptions(&pb.GetConfigsByTypeRequest{OrderBy: "host ASC"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, repository.ListOptions{OrderBy: "host"}, options)
+
+	_, err = listOptions(&pb.GetConfigsByTypeRequest{OrderBy: "host sideways"})
+	assert.Error(t, err)
+	_, err = listOptions(&pb.GetConfigsByTypeRequest{PageSize: -1})
+	assert.Error(t, err)
+
+	options, err = listOptions(&pb.GetConfigsByTypeRequest{LabelSelector: "team=orders,!deprecated"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, repository.ListOptions{Selector: selector.Selector{
+		{Key: "team", Operator: selector.OperatorEquals, Values: []string{"orders"}},
+		{Key: "deprecated", Operator: selector.OperatorDoesNotExist},
+	}}, options)
+	_, err = listOptions(&pb.GetConfigsByTypeRequest{LabelSelector: "team in (orders"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
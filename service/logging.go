@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/logging"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+//requestIDHeader is the metadata key of the request ID, both in requests and in response headers
+const requestIDHeader = "x-request-id"
+
+//maxRequestIDLength limits request IDs sent by clients, longer IDs are replaced by generated ones
+const maxRequestIDLength = 128
+
+//requestID returns the request ID sent by the client or generates a new one
+func requestID(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestIDHeader); len(ids) > 0 && ids[0] != "" && len(ids[0]) <= maxRequestIDLength {
+			return ids[0]
+		}
+	}
+	return logging.NewRequestID()
+}
+
+//logRequest logs a finished RPC, failures caused by the service itself are errors, failures caused by requests are warnings
+func logRequest(ctx context.Context, method string, started time.Time, err error) {
+	level := slog.LevelInfo
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+	attrs := []slog.Attr{slog.String("method", method), slog.String("code", status.Code(err).String()), slog.Duration("duration", time.Since(started))}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slog.LogAttrs(ctx, level, "request handled", attrs...)
+}
+
+//loggingUnaryInterceptor attaches a request ID to the context, so it is added to every log line of the request, returns it in the response headers
+//and logs the finished request
+func loggingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	started := time.Now()
+	id := requestID(ctx)
+	ctx = logging.WithRequestID(ctx, id)
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, id)); err != nil {
+		slog.WarnContext(ctx, "error during setting the request ID header", "error", err)
+	}
+	resp, err := handler(ctx, req)
+	logRequest(ctx, info.FullMethod, started, err)
+	return resp, err
+}
+
+//loggingStreamInterceptor is loggingUnaryInterceptor for streaming RPCs
+func loggingStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	started := time.Now()
+	id := requestID(stream.Context())
+	ctx := logging.WithRequestID(stream.Context(), id)
+	if err := stream.SetHeader(metadata.Pairs(requestIDHeader, id)); err != nil {
+		slog.WarnContext(ctx, "error during setting the request ID header", "error", err)
+	}
+	err := handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+	logRequest(ctx, info.FullMethod, started, err)
+	return err
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/logging"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoggingStreamInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	logging.Init(&buf)
+	info := &grpc.StreamServerInfo{FullMethod: "/api.ConfigService/GetConfigsByType"}
+
+	mock := &mockConfigServer{ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDHeader, "clientID"))}
+	err := loggingStreamInterceptor(nil, mock, info, func(srv interface{}, stream grpc.ServerStream) error {
+		assert.Equal(t, "clientID", logging.RequestID(stream.Context()))
+		return status.Error(codes.NotFound, "config not found")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, []string{"clientID"}, mock.Header.Get(requestIDHeader))
+	assert.Contains(t, buf.String(), `"request_id":"clientID"`)
+	assert.Contains(t, buf.String(), `"level":"WARN","msg":"request handled"`)
+
+	mock = &mockConfigServer{}
+	err = loggingStreamInterceptor(nil, mock, info, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, mock.Header.Get(requestIDHeader), 1) {
+		assert.Len(t, mock.Header.Get(requestIDHeader)[0], 32, "a request ID is generated when the client sends none")
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	admin := &adminServer{}
+	res, err := admin.SetLogLevel(context.Background(), &pb.SetLogLevelRequest{Level: "debug"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, &pb.SetLogLevelResponce{Level: "DEBUG", PreviousLevel: "INFO"}, res)
+
+	_, err = admin.SetLogLevel(context.Background(), &pb.SetLogLevelRequest{Level: "verbose"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, "DEBUG", logging.Level())
+	logging.SetLevel("info")
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/repository"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	reasonCanceled         = "canceled"
+	reasonDeadlineExceeded = "deadline_exceeded"
+)
+
+//operationPatch is the write operation of PatchConfig, other operations are named by the import actions
+const operationPatch = "patch"
+
+//requestsCancelled counts failed requests whose context was cancelled by the client or ran past its deadline
+var requestsCancelled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "getmeconf_requests_cancelled_total",
+	Help: "Number of requests failed because they were cancelled or their deadline was exceeded.",
+}, []string{"method", "reason"})
+
+//requestsHandled counts finished RPCs by their status code
+var requestsHandled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "getmeconf_grpc_requests_total",
+	Help: "Number of finished RPCs by method and status code.",
+}, []string{"method", "code"})
+
+//requestDuration observes how long RPCs take, streaming RPCs are observed until the last message is sent
+var requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "getmeconf_grpc_request_duration_seconds",
+	Help:    "Duration of RPCs by method.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"method"})
+
+var (
+	cacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "getmeconf_cache_hits_total",
+		Help: "Number of config responses served from the cache.",
+	})
+	cacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "getmeconf_cache_misses_total",
+		Help: "Number of config responses missing in the cache.",
+	})
+	cacheEvictions = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "getmeconf_cache_evictions_total",
+		Help: "Number of expired config responses removed from the cache.",
+	})
+)
+
+//configWrites counts successful writes by config type and operation, their rate is the write rate of a type
+var configWrites = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "getmeconf_config_writes_total",
+	Help: "Number of written configs by config type and operation.",
+}, []string{"type", "operation"})
+
+//snapshotReads counts reads served from the snapshot because the database was unavailable
+var snapshotReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "getmeconf_snapshot_reads_total",
+	Help: "Number of reads served from the snapshot of configs while the database was unavailable, by config type.",
+}, []string{"type"})
+
+func init() {
+	prometheus.MustRegister(requestsCancelled, requestsHandled, requestDuration, cacheHits, cacheMisses, cacheEvictions, configWrites, snapshotReads)
+}
+
+func countCancellation(ctx context.Context, method string) {
+	switch ctx.Err() {
+	case context.Canceled:
+		requestsCancelled.WithLabelValues(method, reasonCanceled).Inc()
+	case context.DeadlineExceeded:
+		requestsCancelled.WithLabelValues(method, reasonDeadlineExceeded).Inc()
+	}
+}
+
+//observeRequest records the status code and the duration of a finished RPC
+func observeRequest(method string, started time.Time, err error) {
+	requestsHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+	requestDuration.WithLabelValues(method).Observe(time.Since(started).Seconds())
+}
+
+func countWrite(configType, operation string) {
+	configWrites.WithLabelValues(configType, operation).Inc()
+}
+
+//cacheSizeGauge counts evictions of the cache and returns a gauge of its size. Hits and misses are counted where the cache is read
+func cacheSizeGauge(configCache *cache.Cache) prometheus.GaugeFunc {
+	configCache.OnEvicted(func(string, interface{}) {
+		cacheEvictions.Inc()
+	})
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "getmeconf_cache_items",
+		Help: "Number of config responses in the cache, including expired ones not yet evicted.",
+	}, func() float64 {
+		return float64(configCache.ItemCount())
+	})
+}
+
+//dbStatsCollector reports the connection pool statistics of a database
+type dbStatsCollector struct {
+	db *sql.DB
+
+	maxOpen      *prometheus.Desc
+	open         *prometheus.Desc
+	inUse        *prometheus.Desc
+	idle         *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+}
+
+func newDBStatsCollector(db *sql.DB) *dbStatsCollector {
+	return &dbStatsCollector{
+		db:           db,
+		maxOpen:      prometheus.NewDesc("getmeconf_db_max_open_connections", "Maximum number of open connections to the database, 0 is unlimited.", nil, nil),
+		open:         prometheus.NewDesc("getmeconf_db_open_connections", "Number of established connections, both in use and idle.", nil, nil),
+		inUse:        prometheus.NewDesc("getmeconf_db_in_use_connections", "Number of connections currently in use.", nil, nil),
+		idle:         prometheus.NewDesc("getmeconf_db_idle_connections", "Number of idle connections.", nil, nil),
+		waitCount:    prometheus.NewDesc("getmeconf_db_wait_count_total", "Number of connections waited for.", nil, nil),
+		waitDuration: prometheus.NewDesc("getmeconf_db_wait_duration_seconds_total", "Time blocked waiting for a new connection.", nil, nil),
+	}
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxOpen
+	ch <- c.open
+	ch <- c.inUse
+	ch <- c.idle
+	ch <- c.waitCount
+	ch <- c.waitDuration
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.db.Stats()
+	ch <- prometheus.MustNewConstMetric(c.maxOpen, prometheus.GaugeValue, float64(stats.MaxOpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.open, prometheus.GaugeValue, float64(stats.OpenConnections))
+	ch <- prometheus.MustNewConstMetric(c.inUse, prometheus.GaugeValue, float64(stats.InUse))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stats.Idle))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, stats.WaitDuration.Seconds())
+}
+
+//configCountCollector reports the number of stored configs of every type. Configs are counted by the repositories on every scrape,
+//the database counts them without reading the records. Types which can not be counted within the timeout are left out of the scrape
+type configCountCollector struct {
+	repos   repository.ConfigRepos
+	timeout time.Duration
+	count   *prometheus.Desc
+}
+
+func newConfigCountCollector(repos repository.ConfigRepos, timeout time.Duration) *configCountCollector {
+	return &configCountCollector{
+		repos:   repos,
+		timeout: timeout,
+		count:   prometheus.NewDesc("getmeconf_configs", "Number of stored configs by config type.", []string{"type"}, nil),
+	}
+}
+
+func (c *configCountCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.count
+}
+
+func (c *configCountCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	for _, configType := range configTypes {
+		count, err := countConfigs(ctx, c.repos, configType)
+		if err != nil {
+			slog.Warn("error during counting configs", "type", configType, "error", err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.count, prometheus.GaugeValue, float64(count), configType)
+	}
+}
+
+func countConfigs(ctx context.Context, repos repository.ConfigRepos, configType string) (int, error) {
+	switch configType {
+	case mongodb:
+		return repos.MongoDB.Count(ctx)
+	case tempconfig:
+		return repos.TempConfig.Count(ctx)
+	case tsconfig:
+		return repos.TsConfig.Count(ctx)
+	case featureflag:
+		return repos.FeatureFlag.Count(ctx)
+	}
+	return 0, unexpectedTypeError(configType)
+}
+
+//serveMetrics exposes registered metrics for Prometheus at /metrics of the given address
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			slog.Error("error during serving metrics", "error", err)
+		}
+	}()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestMetricsUnaryInterceptor(t *testing.T) {
+	method := "/api.ConfigService/GetConfigByName"
+	ok := requestsHandled.WithLabelValues(method, codes.OK.String())
+	notFound := requestsHandled.WithLabelValues(method, codes.NotFound.String())
+	okBefore, notFoundBefore := testutil.ToFloat64(ok), testutil.ToFloat64(notFound)
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+
+	_, err := metricsUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.NoError(t, err)
+	_, err = metricsUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "config not found")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, okBefore+1, testutil.ToFloat64(ok))
+	assert.Equal(t, notFoundBefore+1, testutil.ToFloat64(notFound))
+}
+
+func TestChainUnaryInterceptors(t *testing.T) {
+	var calls []string
+	interceptor := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			calls = append(calls, name)
+			return handler(ctx, req)
+		}
+	}
+	resp, err := chainUnaryInterceptors(interceptor("first"), interceptor("second"))(context.Background(), "request", &grpc.UnaryServerInfo{},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls = append(calls, "handler")
+			return req, nil
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, "request", resp)
+	assert.Equal(t, []string{"first", "second", "handler"}, calls)
+}
+
+func TestCacheMetrics(t *testing.T) {
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(cache.NoExpiration, 0)
+	mock.mongoDBConfigRepo = &mockMongoDBConfigRepo{}
+	size := cacheSizeGauge(mock.configCache)
+	hitsBefore, missesBefore, evictionsBefore := testutil.ToFloat64(cacheHits), testutil.ToFloat64(cacheMisses), testutil.ToFloat64(cacheEvictions)
+
+	for i := 0; i < 2; i++ {
+		if _, err := mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigName: "testName", ConfigType: "mongodb"}); err != nil {
+			t.Error("error during unit testing: ", err)
+		}
+	}
+	assert.Equal(t, hitsBefore+1, testutil.ToFloat64(cacheHits))
+	assert.Equal(t, missesBefore+1, testutil.ToFloat64(cacheMisses))
+	assert.Equal(t, float64(1), testutil.ToFloat64(size))
+
+	mock.configCache.Set("expired", nil, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	mock.configCache.DeleteExpired()
+	assert.Equal(t, evictionsBefore+1, testutil.ToFloat64(cacheEvictions))
+}
+
+func TestDBStatsCollector(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	defer db.Close()
+	assert.Equal(t, 6, testutil.CollectAndCount(newDBStatsCollector(db)))
+}
+
+func TestConfigCountCollector(t *testing.T) {
+	repos := repository.ConfigRepos{MongoDB: &mockMongoDBConfigRepo{}, TempConfig: &mockTempConfigRepo{}, TsConfig: &mockTsConfigRepo{}, FeatureFlag: &mockFeatureFlagRepo{}}
+	assert.Equal(t, 4, testutil.CollectAndCount(newConfigCountCollector(repos, time.Second)))
+	count, err := countConfigs(context.Background(), repos, mongodb)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	repos.TsConfig = &mockErrorTsConfigRepo{}
+	assert.Equal(t, 3, testutil.CollectAndCount(newConfigCountCollector(repos, time.Second)), "types which can not be counted are left out")
+	_, err = countConfigs(context.Background(), repos, tsconfig)
+	assert.Equal(t, errors.New("error from database querying"), err)
+	_, err = countConfigs(context.Background(), repos, "unknown")
+	assert.Error(t, err)
+}
+
+func TestCountWrite(t *testing.T) {
+	writes := configWrites.WithLabelValues(mongodb, actionDelete)
+	before := testutil.ToFloat64(writes)
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(cache.NoExpiration, 0)
+	mock.mongoDBConfigRepo = &mockMongoDBConfigRepo{}
+	if _, err := mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigName: "testName", ConfigType: "mongodb", Unconditional: true}); err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, before+1, testutil.ToFloat64(writes))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/native"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+)
+
+//formatNative is the format of the tool a config is meant for, e.g. tsconfig.json for tsconfigs, a mongodb:// connection string for MongoDB configs
+//and config.json for the LoopBack server configs stored as tempconfigs
+const formatNative = "native"
+
+//marshalConfig renders a config found by GetConfigByName in the requested format
+func (s *configServer) marshalConfig(ctx context.Context, config entitie.ConfigInterface, format string) ([]byte, error) {
+	switch format {
+	case formatJSON, "":
+		return json.Marshal(config)
+	case formatNative:
+		switch c := config.(type) {
+		case *entitie.Tsconfig:
+			resolved, err := native.ResolveTsconfig(c, func(name string) (*entitie.Tsconfig, error) {
+				return s.tsConfigRepo.Find(ctx, name)
+			})
+			if err != nil {
+				//the stored extends chain of the tsconfig is broken
+				return nil, resourceError(codes.FailedPrecondition, err, tsconfig, c.Module)
+			}
+			return native.RenderTsconfig(resolved)
+		case *entitie.Mongodb:
+			return native.RenderMongodbURI(c), nil
+		case *entitie.Tempconfig:
+			return native.RenderLoopbackConfig(c)
+		default:
+			return nil, invalidArgument("format", fmt.Sprintf("native format is not supported for %T", config))
+		}
+	default:
+		return nil, invalidArgument("format", "unexpected config format "+format)
+	}
+}
+
+//unmarshalTsconfig reads a tsconfig sent to CreateConfig or UpdateConfig, either as the JSON of entitie.Tsconfig or as a tsconfig.json file named by ConfigName
+func unmarshalTsconfig(config *pb.Config) (*entitie.Tsconfig, error) {
+	switch config.Format {
+	case formatJSON, "":
+		configStr := entitie.Tsconfig{}
+		if err := json.Unmarshal(config.Config, &configStr); err != nil {
+			return nil, invalidArgument("config", err.Error())
+		}
+		return &configStr, nil
+	case formatNative:
+		configStr, err := native.ParseTsconfig(config.ConfigName, config.Config)
+		if err != nil {
+			return nil, invalidArgument("config", err.Error())
+		}
+		return configStr, nil
+	default:
+		return nil, invalidArgument("format", "unexpected config format "+config.Format)
+	}
+}
+
+//unmarshalTempconfig reads a tempconfig sent to CreateConfig or UpdateConfig, either as the JSON of entitie.Tempconfig or as the config.json file of a LoopBack server
+func unmarshalTempconfig(config *pb.Config) (*entitie.Tempconfig, error) {
+	switch config.Format {
+	case formatJSON, "":
+		configStr := entitie.Tempconfig{}
+		if err := json.Unmarshal(config.Config, &configStr); err != nil {
+			return nil, invalidArgument("config", err.Error())
+		}
+		return &configStr, nil
+	case formatNative:
+		configStr, err := native.ParseLoopbackConfig(config.ConfigName, config.Config)
+		if err != nil {
+			return nil, invalidArgument("config", err.Error())
+		}
+		return configStr, nil
+	default:
+		return nil, invalidArgument("format", "unexpected config format "+config.Format)
+	}
+}
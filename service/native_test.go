@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetConfigByName_Native(t *testing.T) {
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(5*time.Minute, 10*time.Minute)
+	mock.tsConfigRepo = &mockTsConfigRepo{}
+	mock.mongoDBConfigRepo = &mockMongoDBConfigRepo{}
+	mock.tempConfigRepo = &mockTempConfigRepo{}
+
+	res, err := mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigType: "tsconfig", ConfigName: "testModule", Format: "native"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	var file map[string]interface{}
+	if err = json.Unmarshal(res.Config, &file); err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, map[string]interface{}{"compilerOptions": map[string]interface{}{"target": "testTarget", "sourceMap": true}}, file)
+
+	res, err = mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigType: "tsconfig", ConfigName: "testModule"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.NotContains(t, string(res.Config), "compilerOptions", "json and native responses must be cached separately")
+
+	res, err = mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigType: "mongodb", ConfigName: "testName", Format: "native"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "mongodb://testHost:8080/", string(res.Config))
+	}
+	res, err = mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigType: "tempconfig", ConfigName: "testApiRoot", Format: "native"})
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"restApiRoot": "testApiRoot", "host": "testHost", "port": 8080, "remoting": {"context": true}, "legacyExplorer": true}`, string(res.Config))
+	}
+	_, err = mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigType: "mongodb", ConfigName: "testName", Format: "xml"})
+	assert.Error(t, err)
+}
+
+type savingTempConfigRepo struct {
+	mockTempConfigRepo
+	saved *entitie.Tempconfig
+}
+
+func (m *savingTempConfigRepo) Save(ctx context.Context, config *entitie.Tempconfig) (string, error) {
+	m.saved = config
+	return "OK", nil
+}
+
+func TestCreateConfig_Native(t *testing.T) {
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(5*time.Minute, 10*time.Minute)
+	mock.tsConfigRepo = &mockTsConfigRepo{}
+
+	tsconfigJSON := []byte(`{
+		// comments are allowed
+		"compilerOptions": {"target": "es2017"},
+	}`)
+	res, err := mock.CreateConfig(context.Background(), &pb.Config{ConfigType: "tsconfig", ConfigName: "app", Format: "native", Config: tsconfigJSON})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, &pb.Responce{Status: "OK"}, res)
+
+	_, err = mock.CreateConfig(context.Background(), &pb.Config{ConfigType: "tsconfig", Format: "native", Config: tsconfigJSON})
+	assert.Error(t, err)
+	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tsconfig", ConfigName: "app", Format: "native", Config: []byte("{"), Unconditional: true})
+	assert.Error(t, err)
+
+	tempConfigRepo := &savingTempConfigRepo{}
+	mock.tempConfigRepo = tempConfigRepo
+	configJSON := []byte(`{"restApiRoot": "/api", "host": "0.0.0.0", "port": 3000, "remoting": {"cors": false}, "legasyExplorer": true}`)
+	_, err = mock.CreateConfig(context.Background(), &pb.Config{ConfigType: "tempconfig", Format: "native", Config: configJSON})
+	if assert.NoError(t, err) {
+		assert.Equal(t, &entitie.Tempconfig{RestApiRoot: "/api", Host: "0.0.0.0", Port: 3000, Remoting: entitie.LoopbackRemoting{CORS: &entitie.LoopbackCORS{Disabled: true}},
+			LegacyExplorer: true}, tempConfigRepo.saved)
+	}
+	_, err = mock.CreateConfig(context.Background(), &pb.Config{ConfigType: "tempconfig", ConfigName: "/other", Format: "native", Config: configJSON})
+	assert.Error(t, err)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"golang.org/x/net/context"
+)
+
+//PatchConfig changes only some fields of a config, the patched config has to follow the rules of its type. With a field mask the named fields are taken from the patch and fields named in the mask,
+//but missing in the patch, are cleared to their zero values. Without a field mask the patch is an RFC 7396 JSON merge patch, where null clears a field
+func (s *configServer) PatchConfig(ctx context.Context, patchRequest *pb.PatchConfigRequest) (*pb.Responce, error) {
+	revision, err := expectedRevision(patchRequest.ExpectedRevision, 0, patchRequest.Unconditional)
+	if err != nil {
+		return nil, err
+	}
+	var status string
+	switch patchRequest.ConfigType {
+	case mongodb:
+		current, err := s.mongoDBConfigRepo.Find(ctx, patchRequest.ConfigName)
+		if err != nil {
+			return nil, statusError(err, mongodb, patchRequest.ConfigName)
+		}
+		patched := entitie.Mongodb{}
+		fields, err := applyPatch(current, patchRequest.Patch, patchRequest.FieldMask, &patched)
+		if err != nil {
+			return nil, err
+		}
+		patched.Domain, patched.Revision = current.Domain, revision
+		if err = repository.ValidateMongodb(&patched); err != nil {
+			return nil, statusError(err, patchRequest.ConfigType, patchRequest.ConfigName)
+		}
+		if status, err = s.mongoDBConfigRepo.Patch(ctx, &patched, fields); err != nil {
+			return nil, statusError(err, patchRequest.ConfigType, patchRequest.ConfigName)
+		}
+		revision = patched.Revision
+	case tempconfig:
+		current, err := s.tempConfigRepo.Find(ctx, patchRequest.ConfigName)
+		if err != nil {
+			return nil, statusError(err, tempconfig, patchRequest.ConfigName)
+		}
+		patch, fieldMask, err := renameField(patchRequest.Patch, patchRequest.FieldMask, "legasyExplorer", "legacyExplorer")
+		if err != nil {
+			return nil, err
+		}
+		patched := entitie.Tempconfig{}
+		fields, err := applyPatch(current, patch, fieldMask, &patched)
+		if err != nil {
+			return nil, err
+		}
+		patched.RestApiRoot, patched.Revision = current.RestApiRoot, revision
+		if err = repository.ValidateTempconfig(&patched); err != nil {
+			return nil, statusError(err, patchRequest.ConfigType, patchRequest.ConfigName)
+		}
+		if status, err = s.tempConfigRepo.Patch(ctx, &patched, fields); err != nil {
+			return nil, statusError(err, patchRequest.ConfigType, patchRequest.ConfigName)
+		}
+		revision = patched.Revision
+	case tsconfig:
+		current, err := s.tsConfigRepo.Find(ctx, patchRequest.ConfigName)
+		if err != nil {
+			return nil, statusError(err, tsconfig, patchRequest.ConfigName)
+		}
+		patched := entitie.Tsconfig{}
+		fields, err := applyPatch(current, patchRequest.Patch, patchRequest.FieldMask, &patched)
+		if err != nil {
+			return nil, err
+		}
+		patched.Module, patched.Revision = current.Module, revision
+		if err = repository.ValidateTsconfig(&patched); err != nil {
+			return nil, statusError(err, patchRequest.ConfigType, patchRequest.ConfigName)
+		}
+		if status, err = s.tsConfigRepo.Patch(ctx, &patched, fields); err != nil {
+			return nil, statusError(err, patchRequest.ConfigType, patchRequest.ConfigName)
+		}
+		revision = patched.Revision
+	case featureflag:
+		current, err := s.featureFlagRepo.Find(ctx, patchRequest.ConfigName)
+		if err != nil {
+			return nil, statusError(err, featureflag, patchRequest.ConfigName)
+		}
+		patched := entitie.Featureflag{}
+		fields, err := applyPatch(current, patchRequest.Patch, patchRequest.FieldMask, &patched)
+		if err != nil {
+			return nil, err
+		}
+		patched.Key, patched.Revision = current.Key, revision
+		if err = repository.ValidateFeatureflag(&patched); err != nil {
+			return nil, statusError(err, patchRequest.ConfigType, patchRequest.ConfigName)
+		}
+		if status, err = s.featureFlagRepo.Patch(ctx, &patched, fields); err != nil {
+			return nil, statusError(err, patchRequest.ConfigType, patchRequest.ConfigName)
+		}
+		revision = patched.Revision
+	default:
+		return nil, unexpectedTypeError(patchRequest.ConfigType)
+	}
+	countWrite(patchRequest.ConfigType, operationPatch)
+	s.configCache.Flush()
+	return &pb.Responce{Status: status, Revision: revision}, nil
+}
+
+//applyPatch stores current config with the patch applied into patched and returns the sorted JSON names of the patched fields.
+//Fields which end up missing in the patched document are left with their zero values
+func applyPatch(current entitie.ConfigInterface, patch []byte, fieldMask []string, patched entitie.ConfigInterface) ([]string, error) {
+	currentData, err := json.Marshal(current)
+	if err != nil {
+		return nil, err
+	}
+	var document map[string]interface{}
+	if err = json.Unmarshal(currentData, &document); err != nil {
+		return nil, err
+	}
+	patchDocument := make(map[string]interface{})
+	if len(patch) > 0 {
+		if err = json.Unmarshal(patch, &patchDocument); err != nil {
+			return nil, invalidArgument("patch", err.Error())
+		}
+	}
+
+	var fields []string
+	if len(fieldMask) > 0 {
+		masked := make(map[string]bool, len(fieldMask))
+		for _, field := range fieldMask {
+			if masked[field] {
+				continue
+			}
+			masked[field] = true
+			if value, ok := patchDocument[field]; ok && value != nil {
+				document[field] = value
+			} else {
+				delete(document, field)
+			}
+			fields = append(fields, field)
+		}
+	} else {
+		for field := range patchDocument {
+			fields = append(fields, field)
+		}
+		document = entitie.MergePatch(document, patchDocument).(map[string]interface{})
+	}
+	if len(fields) == 0 {
+		return nil, invalidArgument("patch", "no fields to patch")
+	}
+	sort.Strings(fields)
+
+	patchedData, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(patchedData, patched); err != nil {
+		return nil, invalidArgument("patch", err.Error())
+	}
+	return fields, nil
+}
+
+//renameField renames a field in a patch and its field mask, so fields can be patched under their former names
+func renameField(patch []byte, fieldMask []string, from, to string) ([]byte, []string, error) {
+	renamedMask := make([]string, len(fieldMask))
+	for i, field := range fieldMask {
+		if field == from {
+			field = to
+		}
+		renamedMask[i] = field
+	}
+	if len(patch) == 0 {
+		return patch, renamedMask, nil
+	}
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &document); err != nil {
+		return nil, nil, invalidArgument("patch", err.Error())
+	}
+	value, ok := document[from]
+	if !ok {
+		return patch, renamedMask, nil
+	}
+	if _, ok = document[to]; !ok {
+		document[to] = value
+	}
+	delete(document, from)
+	renamed, err := json.Marshal(document)
+	return renamed, renamedMask, err
+}
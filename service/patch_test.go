@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type patchingMongoDBConfigRepo struct {
+	mockMongoDBConfigRepo
+	patched entitie.Mongodb
+	fields  []string
+}
+
+func (m *patchingMongoDBConfigRepo) Patch(ctx context.Context, config *entitie.Mongodb, fields []string) (string, error) {
+	m.patched, m.fields = *config, fields
+	config.Revision = 8
+	return "OK", nil
+}
+
+type patchingTsConfigRepo struct {
+	mockTsConfigRepo
+	patched int
+}
+
+func (m *patchingTsConfigRepo) Patch(ctx context.Context, config *entitie.Tsconfig, fields []string) (string, error) {
+	m.patched++
+	return "OK", nil
+}
+
+func TestPatchConfig_TsconfigVariants(t *testing.T) {
+	repo := &patchingTsConfigRepo{}
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(cache.NoExpiration, 0)
+	mock.tsConfigRepo = repo
+
+	for _, patch := range []string{
+		`{"variants": [{"name": "node", "match": {"runtime": "node20"}}, {"name": "node", "match": {"runtime": "node22"}}]}`,
+		`{"variants": [{"name": "node"}]}`,
+		`{"variants": [{"name": "node", "match": {"runtime": "node20"}, "config": {"module": "esnext"}}]}`,
+		`{"labels": {"team name": "web"}}`,
+	} {
+		_, err := mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: tsconfig, ConfigName: "testModule", Patch: []byte(patch), Unconditional: true})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), patch)
+	}
+	assert.Equal(t, 0, repo.patched, "invalid tsconfigs are not patched")
+
+	_, err := mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: tsconfig, ConfigName: "testModule",
+		Patch: []byte(`{"variants": [{"name": "node", "match": {"runtime": "node20"}, "config": {"target": "es2022"}}]}`), Unconditional: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, repo.patched)
+}
+
+func TestPatchConfig(t *testing.T) {
+	repo := &patchingMongoDBConfigRepo{}
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(cache.NoExpiration, 0)
+	mock.mongoDBConfigRepo = repo
+
+	res, err := mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "mongodb", ConfigName: "testName",
+		Patch: []byte(`{"port": "8080", "replicaSet": null}`), ExpectedRevision: 7})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, &pb.Responce{Status: "OK", Revision: 8}, res)
+	assert.Equal(t, entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080, Revision: 7}, repo.patched)
+	assert.Equal(t, []string{"port", "replicaSet"}, repo.fields)
+
+	_, err = mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "mongodb", ConfigName: "testName",
+		Patch: []byte(`{"host": "newHost", "port": "8080"}`), FieldMask: []string{"host", "tls"}, Unconditional: true})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, entitie.Mongodb{Domain: "testName", Host: "newHost", Port: 8080}, repo.patched, "masked fields missing in the patch are cleared")
+	assert.Equal(t, []string{"host", "tls"}, repo.fields)
+
+	_, err = mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "mongodb", ConfigName: "testName",
+		Patch: []byte(`{"port": 70000}`), Unconditional: true})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "patched configs are validated")
+
+	_, err = mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "mongodb", ConfigName: "testName", Patch: []byte(`{"port": "8080"}`)})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "mongodb", ConfigName: "testName", Patch: []byte(`{}`), Unconditional: true})
+	assert.Error(t, err)
+
+	_, err = mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "unexpectedConfigType", Unconditional: true})
+	if assert.Error(t, err) {
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	mock.tsConfigRepo = &mockErrorTsConfigRepo{}
+	_, err = mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "tsconfig", ConfigName: "testName", Patch: []byte(`{"target": "es6"}`), Unconditional: true})
+	if assert.Error(t, err) {
+		assert.Equal(t, errors.New("error from database querying"), err)
+	}
+}
+
+type patchingTempConfigRepo struct {
+	mockTempConfigRepo
+	patched entitie.Tempconfig
+	fields  []string
+}
+
+func (m *patchingTempConfigRepo) Patch(ctx context.Context, config *entitie.Tempconfig, fields []string) (string, error) {
+	m.patched, m.fields = *config, fields
+	return "OK", nil
+}
+
+func TestPatchConfig_LegacyField(t *testing.T) {
+	repo := &patchingTempConfigRepo{}
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(cache.NoExpiration, 0)
+	mock.tempConfigRepo = repo
+
+	_, err := mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "tempconfig", ConfigName: "testApiRoot",
+		Patch: []byte(`{"legasyExplorer": false}`), Unconditional: true})
+	if assert.NoError(t, err) {
+		assert.False(t, repo.patched.LegacyExplorer)
+		assert.Equal(t, []string{"legacyExplorer"}, repo.fields, "legasyExplorer is patched as legacyExplorer")
+	}
+
+	_, err = mock.PatchConfig(context.Background(), &pb.PatchConfigRequest{ConfigType: "tempconfig", ConfigName: "testApiRoot",
+		Patch: []byte(`{}`), FieldMask: []string{"legasyExplorer"}, Unconditional: true})
+	if assert.NoError(t, err) {
+		assert.False(t, repo.patched.LegacyExplorer)
+		assert.Equal(t, []string{"legacyExplorer"}, repo.fields)
+	}
+}
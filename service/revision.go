@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/YAWAL/GetMeConf/entitie"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+//expectedRevision returns the revision a conditional write expects, requested explicitly or taken from the written config.
+//Zero means an unconditional write, which has to be asked for explicitly
+func expectedRevision(requested, inConfig int64, unconditional bool) (int64, error) {
+	switch {
+	case unconditional:
+		return 0, nil
+	case requested > 0:
+		return requested, nil
+	case inConfig > 0:
+		return inConfig, nil
+	default:
+		return 0, status.Error(codes.FailedPrecondition, "expected revision is not set, use an unconditional write to ignore revisions")
+	}
+}
+
+func configRevision(config entitie.ConfigInterface) int64 {
+	switch c := config.(type) {
+	case *entitie.Mongodb:
+		return c.Revision
+	case *entitie.Tempconfig:
+		return c.Revision
+	case *entitie.Tsconfig:
+		return c.Revision
+	case *entitie.Featureflag:
+		return c.Revision
+	default:
+		return 0
+	}
+}
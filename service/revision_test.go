@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type revisionMongoDBConfigRepo struct {
+	mockMongoDBConfigRepo
+	revision         int64
+	expectedRevision int64
+}
+
+func (m *revisionMongoDBConfigRepo) Update(ctx context.Context, config *entitie.Mongodb) (string, error) {
+	m.expectedRevision = config.Revision
+	if config.Revision > 0 && config.Revision != m.revision {
+		return "", repository.ErrRevisionMismatch
+	}
+	m.revision++
+	config.Revision = m.revision
+	return "OK", nil
+}
+
+func (m *revisionMongoDBConfigRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
+	m.expectedRevision = expectedRevision
+	if expectedRevision > 0 && expectedRevision != m.revision {
+		return "", repository.ErrRevisionMismatch
+	}
+	return "deleted 1 row(s)", nil
+}
+
+func TestUpdateConfig_Revision(t *testing.T) {
+	repo := &revisionMongoDBConfigRepo{revision: 2}
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(cache.NoExpiration, 0)
+	mock.mongoDBConfigRepo = repo
+
+	byteRes, err := json.Marshal(entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080, Revision: 2})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	res, err := mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "mongodb", Config: byteRes})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, &pb.Responce{Status: "OK", Revision: 3}, res)
+	assert.Equal(t, int64(2), repo.expectedRevision, "the revision of the config is expected when the request has none")
+
+	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "mongodb", Config: byteRes, ExpectedRevision: 2})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	res, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "mongodb", Config: byteRes, Unconditional: true})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, int64(0), repo.expectedRevision)
+	assert.Equal(t, int64(4), res.Revision)
+
+	byteRes, err = json.Marshal(entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "mongodb", Config: byteRes})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestDeleteConfig_Revision(t *testing.T) {
+	repo := &revisionMongoDBConfigRepo{revision: 2}
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(cache.NoExpiration, 0)
+	mock.mongoDBConfigRepo = repo
+
+	_, err := mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "mongodb", ConfigName: "testName"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "mongodb", ConfigName: "testName", ExpectedRevision: 1})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	res, err := mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "mongodb", ConfigName: "testName", ExpectedRevision: 2})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, &pb.Responce{Status: "deleted 1 row(s)"}, res)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	"github.com/YAWAL/GetMeConf/selector"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"golang.org/x/net/context"
+)
+
+const (
+	//defaultSearchPageSize is the page size of SearchConfigs requests without one, so every search is paged
+	defaultSearchPageSize = 100
+	//maxSearchPageSize limits the page size of SearchConfigs
+	maxSearchPageSize = 1000
+)
+
+//SearchConfigs returns a page of the configs of the requested types (of every type if none is given) whose labels match the label selector of the request,
+//ordered by type and name. An empty selector matches every config. The configs are selected by the database.
+//Pages hold up to 100 configs unless the request sets a page size, NextPageToken of the response requests the next page and is empty on the last page
+func (s *configServer) SearchConfigs(ctx context.Context, searchRequest *pb.SearchConfigsRequest) (*pb.SearchConfigsResponce, error) {
+	labelSelector, err := selector.Parse(searchRequest.LabelSelector)
+	if err != nil {
+		return nil, invalidArgument("labelSelector", err.Error())
+	}
+	selected, err := selectConfigTypes(searchRequest.ConfigTypes)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := int(searchRequest.PageSize)
+	switch {
+	case pageSize < 0:
+		return nil, invalidArgument("pageSize", fmt.Sprintf("negative page size %d", searchRequest.PageSize))
+	case pageSize == 0:
+		pageSize = defaultSearchPageSize
+	case pageSize > maxSearchPageSize:
+		pageSize = maxSearchPageSize
+	}
+	startType, startToken, err := decodeSearchPageToken(searchRequest.PageToken, selected)
+	if err != nil {
+		return nil, err
+	}
+	response := &pb.SearchConfigsResponce{}
+	match := func(configType, configName string, config entitie.ConfigInterface) error {
+		byteRes, err := json.Marshal(withoutVariants(config))
+		if err != nil {
+			return err
+		}
+		response.Configs = append(response.Configs, &pb.ConfigMatch{ConfigType: configType, ConfigName: configName, Config: byteRes, Revision: configRevision(config)})
+		return nil
+	}
+	for _, configType := range configTypes {
+		if !selected[configType] || startType != "" && configType != startType {
+			continue
+		}
+		if len(response.Configs) == pageSize {
+			response.NextPageToken = encodeSearchPageToken(configType, "")
+			return response, nil
+		}
+		options := repository.ListOptions{Selector: labelSelector, PageSize: pageSize - len(response.Configs)}
+		if configType == startType {
+			options.PageToken = startToken
+			startType = ""
+		}
+		var nextPageToken string
+		switch configType {
+		case mongodb:
+			nextPageToken, err = s.mongoDBConfigRepo.Iterate(ctx, options, func(config *entitie.Mongodb) error {
+				return match(mongodb, config.Domain, config)
+			})
+		case tempconfig:
+			nextPageToken, err = s.tempConfigRepo.Iterate(ctx, options, func(config *entitie.Tempconfig) error {
+				return match(tempconfig, config.RestApiRoot, config)
+			})
+		case tsconfig:
+			nextPageToken, err = s.tsConfigRepo.Iterate(ctx, options, func(config *entitie.Tsconfig) error {
+				return match(tsconfig, config.Module, config)
+			})
+		case featureflag:
+			nextPageToken, err = s.featureFlagRepo.Iterate(ctx, options, func(config *entitie.Featureflag) error {
+				return match(featureflag, config.Key, config)
+			})
+		}
+		if err != nil {
+			return nil, statusError(err, configType, "")
+		}
+		if nextPageToken != "" {
+			response.NextPageToken = encodeSearchPageToken(configType, nextPageToken)
+			return response, nil
+		}
+	}
+	return response, nil
+}
+
+//encodeSearchPageToken returns the token of a SearchConfigs page starting at the page of configType given by the page token of its repository
+func encodeSearchPageToken(configType, pageToken string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(configType + " " + pageToken))
+}
+
+//decodeSearchPageToken returns the config type and the repository page token a SearchConfigs page starts at, the config type is empty for the first page
+func decodeSearchPageToken(token string, selected map[string]bool) (configType, pageToken string, err error) {
+	if token == "" {
+		return "", "", nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", "", invalidArgument("pageToken", "invalid page token")
+	}
+	parts := strings.SplitN(string(data), " ", 2)
+	if len(parts) != 2 || !selected[parts[0]] {
+		return "", "", invalidArgument("pageToken", "invalid page token")
+	}
+	return parts[0], parts[1], nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type labelledMongoDBConfigRepo struct {
+	mockMongoDBConfigRepo
+	configs []entitie.Mongodb
+}
+
+func (m *labelledMongoDBConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Mongodb) error) (string, error) {
+	return repository.IterateSlice(m.configs, options, fn)
+}
+
+type labelledTsConfigRepo struct {
+	mockTsConfigRepo
+	configs []entitie.Tsconfig
+}
+
+func (m *labelledTsConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Tsconfig) error) (string, error) {
+	return repository.IterateSlice(m.configs, options, fn)
+}
+
+func TestSearchConfigs(t *testing.T) {
+	mock := &mockConfigServer{}
+	mock.mongoDBConfigRepo = &labelledMongoDBConfigRepo{configs: []entitie.Mongodb{
+		{Domain: "asia", Host: "217.155.155.1", Port: 8081, Labels: entitie.Labels{"team": "search", "env": "prod"}, Annotations: entitie.Annotations{"owner": "search-team@example.com"}, Revision: 2,
+			Variants: entitie.ConfigVariants{{Name: "eu", Match: entitie.Labels{"region": "eu"}, Config: entitie.JSONMap{"host": "217.155.155.2"}}}},
+		{Domain: "orders", Host: "mongo-0", Port: 27017, Labels: entitie.Labels{"team": "orders", "env": "prod"}, Revision: 1},
+	}}
+	mock.tsConfigRepo = &labelledTsConfigRepo{configs: []entitie.Tsconfig{
+		{Module: "web", Target: "es2017", Labels: entitie.Labels{"team": "search"}, Revision: 4},
+	}}
+	mock.tempConfigRepo = &mockErrorTempConfigRepo{}
+
+	res, err := mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{LabelSelector: "team=search", ConfigTypes: []string{tsconfig, mongodb}})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []*pb.ConfigMatch{
+		{ConfigType: mongodb, ConfigName: "asia", Revision: 2,
+			Config: []byte(`{"domain":"asia","host":"217.155.155.1","port":8081,"labels":{"env":"prod","team":"search"},"annotations":{"owner":"search-team@example.com"},"revision":2}`)},
+		{ConfigType: tsconfig, ConfigName: "web", Revision: 4,
+			Config: []byte(`{"module":"web","target":"es2017","sourceMap":false,"excluding":0,"labels":{"team":"search"},"revision":4}`)},
+	}, res.Configs, "matches are ordered by type and name, without their variants")
+
+	res, err = mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{LabelSelector: "env=prod,team notin (search)", ConfigTypes: []string{mongodb, tsconfig}})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	if assert.Len(t, res.Configs, 1) {
+		assert.Equal(t, "orders", res.Configs[0].ConfigName)
+	}
+
+	_, err = mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{LabelSelector: "team==", ConfigTypes: []string{mongodb}})
+	assert.NoError(t, err, "an empty value is a label value")
+	_, err = mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{LabelSelector: "team name=search"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{ConfigTypes: []string{"xml"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{ConfigTypes: []string{tempconfig}})
+	assert.Equal(t, errors.New("error from database querying"), err)
+}
+
+func TestSearchConfigs_Paging(t *testing.T) {
+	mock := &mockConfigServer{}
+	mock.mongoDBConfigRepo = &labelledMongoDBConfigRepo{configs: []entitie.Mongodb{
+		{Domain: "orders", Labels: entitie.Labels{"team": "orders"}},
+		{Domain: "asia", Labels: entitie.Labels{"team": "search"}},
+		{Domain: "eu"},
+	}}
+	mock.tsConfigRepo = &labelledTsConfigRepo{configs: []entitie.Tsconfig{
+		{Module: "web", Labels: entitie.Labels{"team": "search"}},
+		{Module: "api", Labels: entitie.Labels{"team": "orders"}},
+	}}
+	search := func(pageSize int32, pageToken string) ([]string, string) {
+		res, err := mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{ConfigTypes: []string{mongodb, tsconfig}, PageSize: pageSize, PageToken: pageToken})
+		if !assert.NoError(t, err) {
+			return nil, ""
+		}
+		var names []string
+		for _, match := range res.Configs {
+			names = append(names, match.ConfigType+"/"+match.ConfigName)
+		}
+		return names, res.NextPageToken
+	}
+
+	names, token := search(2, "")
+	assert.Equal(t, []string{"mongodb/asia", "mongodb/eu"}, names)
+	names, token = search(2, token)
+	assert.Equal(t, []string{"mongodb/orders", "tsconfig/api"}, names, "a page continues with the next type")
+	names, token = search(2, token)
+	assert.Equal(t, []string{"tsconfig/web"}, names)
+	assert.Empty(t, token, "the last page has no next page")
+
+	names, token = search(3, "")
+	assert.Equal(t, []string{"mongodb/asia", "mongodb/eu", "mongodb/orders"}, names)
+	names, token = search(3, token)
+	assert.Equal(t, []string{"tsconfig/api", "tsconfig/web"}, names, "a page ending with a type starts the next one")
+	assert.Empty(t, token)
+
+	names, token = search(0, "")
+	assert.Len(t, names, 5, "searches without a page size get a default page")
+	assert.Empty(t, token)
+
+	_, err := mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{PageSize: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	for _, pageToken := range []string{"!", encodeSearchPageToken("xml", ""), encodeSearchPageToken(featureflag, "")} {
+		_, err = mock.SearchConfigs(context.Background(), &pb.SearchConfigsRequest{ConfigTypes: []string{mongodb, tsconfig}, PageToken: pageToken})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), pageToken)
+	}
+}
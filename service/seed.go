@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/database"
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"google.golang.org/grpc/status"
+)
+
+//fixturesDir is the directory of the embedded fixture sets
+const fixturesDir = "fixtures"
+
+//seedCommand loads a fixture set through the repositories in one transaction. Fixtures equal to the stored configs are left unchanged,
+//so seeding again changes nothing
+func seedCommand(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	set := flags.String("set", "demo", "embedded fixture set, see -list")
+	dir := flags.String("dir", "", "directory of JSON or YAML fixture files to load instead of an embedded set")
+	list := flags.Bool("list", false, "list the embedded fixture sets")
+	mode := flags.String("mode", importModeUpsert, "seed mode: create, upsert or replace, like the import modes")
+	dryRun := flags.Bool("dry-run", false, "report changes without writing them")
+	serviceSettings, err := loadSettings(flags, args)
+	if err != nil {
+		return err
+	}
+
+	sets, err := fixtureSets()
+	if err != nil {
+		return err
+	}
+	if *list {
+		fmt.Println(strings.Join(sets, "\n"))
+		return nil
+	}
+	var archive *entitie.Archive
+	if *dir != "" {
+		archive, err = loadFixtures(os.DirFS(*dir), ".")
+	} else {
+		if !slices.Contains(sets, *set) {
+			return fmt.Errorf("unknown fixture set %q, the embedded sets are %s", *set, strings.Join(sets, ", "))
+		}
+		archive, err = loadFixtures(database.Fixtures, path.Join(fixturesDir, *set))
+	}
+	if err != nil {
+		return err
+	}
+
+	dbConn, err := repository.InitPostgresDB(serviceSettings.Postgres)
+	if err != nil {
+		return err
+	}
+	defer dbConn.Close()
+	var changes []*pb.ConfigChange
+	err = repository.NewPostgresTransactor(dbConn).InTransaction(context.Background(), func(repos repository.ConfigRepos) error {
+		var err error
+		changes, err = importArchive(context.Background(), repos, archive, nil, *mode, *dryRun)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		fmt.Printf("%s\t%s\t%s\n", change.Action, change.ConfigType, change.ConfigName)
+	}
+	if *dryRun {
+		fmt.Println("dry run, nothing has been written")
+	}
+	return nil
+}
+
+//fixtureSets returns the names of the embedded fixture sets
+func fixtureSets() ([]string, error) {
+	entries, err := fs.ReadDir(database.Fixtures, fixturesDir)
+	if err != nil {
+		return nil, err
+	}
+	var sets []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			sets = append(sets, entry.Name())
+		}
+	}
+	return sets, nil
+}
+
+//loadFixtures merges the JSON, YAML and TOML archives of a directory of fsys into one archive.
+//Every config is validated by the rules of its type, all invalid and duplicate configs are reported with their files
+func loadFixtures(fsys fs.FS, dir string) (*entitie.Archive, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	archive := new(entitie.Archive)
+	var errs []error
+	files := make(map[string]string)
+	check := func(file, configType, configName string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s %q: %v", file, configType, configName, err))
+		}
+		key := configType + "/" + configName
+		if other, ok := files[key]; ok {
+			errs = append(errs, fmt.Errorf("%s: %s %q is already defined in %s", file, configType, configName, other))
+		}
+		files[key] = file
+	}
+	for _, entry := range entries {
+		switch path.Ext(entry.Name()) {
+		case ".json", ".yaml", ".yml", ".toml":
+		default:
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		fixtures, err := decodeArchive(data, archiveFormat("", entry.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", entry.Name(), status.Convert(err).Message()))
+			continue
+		}
+		for i := range fixtures.Mongodbs {
+			check(entry.Name(), mongodb, fixtures.Mongodbs[i].Domain, repository.ValidateMongodb(&fixtures.Mongodbs[i]))
+		}
+		for i := range fixtures.Tempconfigs {
+			check(entry.Name(), tempconfig, fixtures.Tempconfigs[i].RestApiRoot, repository.ValidateTempconfig(&fixtures.Tempconfigs[i]))
+		}
+		for i := range fixtures.Tsconfigs {
+			check(entry.Name(), tsconfig, fixtures.Tsconfigs[i].Module, repository.ValidateTsconfig(&fixtures.Tsconfigs[i]))
+		}
+		for i := range fixtures.Featureflags {
+			check(entry.Name(), featureflag, fixtures.Featureflags[i].Key, repository.ValidateFeatureflag(&fixtures.Featureflags[i]))
+		}
+		archive.Mongodbs = append(archive.Mongodbs, fixtures.Mongodbs...)
+		archive.Tempconfigs = append(archive.Tempconfigs, fixtures.Tempconfigs...)
+		archive.Tsconfigs = append(archive.Tsconfigs, fixtures.Tsconfigs...)
+		archive.Featureflags = append(archive.Featureflags, fixtures.Featureflags...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid fixtures:\n%v", err)
+	}
+	return archive, nil
+}
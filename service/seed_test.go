@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	"github.com/YAWAL/GetMeConf/database"
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedFixtureSets(t *testing.T) {
+	sets, err := fixtureSets()
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Equal(t, []string{"demo", "load-test"}, sets)
+	for _, set := range sets {
+		archive, err := loadFixtures(database.Fixtures, path.Join(fixturesDir, set))
+		if assert.NoError(t, err, set) {
+			assert.NotEmpty(t, archive.Mongodbs, set)
+			assert.NotEmpty(t, archive.Tempconfigs, set)
+			assert.NotEmpty(t, archive.Tsconfigs, set)
+		}
+	}
+}
+
+func TestLoadFixtures(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fixtures/mongodbs.yaml": {Data: []byte("mongodbs:\n- domain: first\n  host: localhost\n  port: \"8080\"\n")},
+		"fixtures/configs.json":  {Data: []byte(`{"tsconfigs": [{"module": "base", "target": "es2017"}]}`)},
+		"fixtures/README.md":     {Data: []byte("not a fixture")},
+	}
+	archive, err := loadFixtures(fsys, "fixtures")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &entitie.Archive{
+			Mongodbs:  []entitie.Mongodb{{Domain: "first", Host: "localhost", Port: 8080}},
+			Tsconfigs: []entitie.Tsconfig{{Module: "base", Target: "es2017"}},
+		}, archive)
+	}
+
+	fsys["fixtures/more.yaml"] = &fstest.MapFile{Data: []byte("mongodbs:\n- domain: first\n  host: remote\n  port: \"8080\"\ntempconfigs:\n- restApiRoot: /api\n  host: localhost\n")}
+	_, err = loadFixtures(fsys, "fixtures")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `more.yaml: mongodb "first" is already defined in mongodbs.yaml`)
+		assert.Contains(t, err.Error(), `more.yaml: tempconfig "/api": port: must not be empty`, "fixtures are validated by the rules of their type")
+	}
+
+	fsys["fixtures/more.yaml"] = &fstest.MapFile{Data: []byte("mongodbs: [")}
+	_, err = loadFixtures(fsys, "fixtures")
+	assert.Error(t, err)
+}
+
+func TestSeedIsIdempotent(t *testing.T) {
+	archive, err := loadFixtures(database.Fixtures, path.Join(fixturesDir, "demo"))
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	repos := repository.ConfigRepos{MongoDB: &mockSeededMongoDBConfigRepo{configs: archive.Mongodbs}, TempConfig: &mockTempConfigRepo{}, TsConfig: &mockTsConfigRepo{}, FeatureFlag: &mockFeatureFlagRepo{}}
+	changes, err := importArchive(context.Background(), repos, &entitie.Archive{Mongodbs: archive.Mongodbs}, nil, importModeUpsert, false)
+	if assert.NoError(t, err) {
+		for _, change := range changes {
+			assert.Equal(t, actionUnchanged, change.Action, change.ConfigName)
+		}
+	}
+}
+
+type mockSeededMongoDBConfigRepo struct {
+	mockMongoDBConfigRepo
+	configs []entitie.Mongodb
+}
+
+func (m *mockSeededMongoDBConfigRepo) FindAll(ctx context.Context) ([]entitie.Mongodb, error) {
+	return m.configs, nil
+}
@@ -2,39 +2,42 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"time"
 
-	"errors"
-
 	"os"
 
 	pb "github.com/YAWAL/GetMeConfAPI/api"
 
-	"strconv"
+	"strings"
 
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 
 	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/logging"
 	"github.com/YAWAL/GetMeConf/repository"
+	"github.com/jinzhu/gorm"
 	"github.com/patrickmn/go-cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
-)
-
-var (
-	defaultPort                 = "3000"
-	defaultCacheExpirationTime  = 5
-	defaultCacheCleanupInterval = 10
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
 )
 
 const (
-	mongodb    = "mongodb"
-	tempconfig = "tempconfig"
-	tsconfig   = "tsconfig"
+	mongodb     = "mongodb"
+	tempconfig  = "tempconfig"
+	tsconfig    = "tsconfig"
+	featureflag = "featureflag"
 )
 
 type configServer struct {
@@ -42,95 +45,121 @@ type configServer struct {
 	mongoDBConfigRepo repository.MongoDBConfigRepo
 	tempConfigRepo    repository.TempConfigRepo
 	tsConfigRepo      repository.TsConfigRepo
+	featureFlagRepo   repository.FeatureFlagRepo
+	transactor        repository.Transactor
 }
 
 //GetConfigByName returns one config in GetConfigResponce message
 func (s *configServer) GetConfigByName(ctx context.Context, nameRequest *pb.GetConfigByNameRequest) (*pb.GetConfigResponce, error) {
 
-	configResponse, found := s.configCache.Get(nameRequest.ConfigName)
+	cacheKey := nameRequest.ConfigType + "/" + nameRequest.ConfigName
+	if nameRequest.Format != "" {
+		cacheKey += "?format=" + nameRequest.Format
+	}
+	//configs are served without their variants, so clients never see the values of other clients. To clients with labels
+	//the most specific variant matching them is applied, its responses are cached apart from those of the config itself
+	var res entitie.ConfigInterface
+	var variant *entitie.ConfigVariant
+	if labels := clientLabels(ctx, nameRequest.Labels); len(labels) > 0 && hasVariants(nameRequest.ConfigType) {
+		var err error
+		if res, variant, err = s.selectVariant(ctx, nameRequest.ConfigType, nameRequest.ConfigName, labels); err != nil {
+			return nil, err
+		}
+		if variant != nil {
+			cacheKey += "#variant=" + variant.Name
+		}
+	}
+	_, cacheSpan := tracer.Start(ctx, "cache.Get")
+	configResponse, found := s.configCache.Get(cacheKey)
+	cacheSpan.SetAttributes(attribute.Bool("cache.hit", found))
+	cacheSpan.End()
 	if found {
+		cacheHits.Inc()
 		return configResponse.(*pb.GetConfigResponce), nil
 	}
+	cacheMisses.Inc()
 	var err error
-	var res entitie.ConfigInterface
-
-	switch nameRequest.ConfigType {
-	case mongodb:
-		res, err = s.mongoDBConfigRepo.Find(nameRequest.ConfigName)
-		if err != nil {
+	if res == nil {
+		if res, err = s.findConfig(ctx, nameRequest.ConfigType, nameRequest.ConfigName); err != nil {
 			return nil, err
 		}
-	case tempconfig:
-		res, err = s.tempConfigRepo.Find(nameRequest.ConfigName)
-		if err != nil {
+	}
+	if hasVariants(nameRequest.ConfigType) {
+		if res, err = entitie.ApplyVariant(res, variant); err != nil {
 			return nil, err
 		}
+	}
+	marshalCtx, marshalSpan := tracer.Start(ctx, "marshalConfig", trace.WithAttributes(attribute.String("config.format", nameRequest.Format)))
+	byteRes, err := s.marshalConfig(marshalCtx, res, nameRequest.Format)
+	endSpan(marshalSpan, err)
+	if err != nil {
+		return nil, err
+	}
+	response := &pb.GetConfigResponce{Config: byteRes, Revision: configRevision(res)}
+	if variant != nil {
+		response.Variant = variant.Name
+	}
+	s.configCache.Set(cacheKey, response, cache.DefaultExpiration)
+	return response, nil
+}
+
+//findConfig reads a config of any type from its repository, the repository call is traced in its own span
+func (s *configServer) findConfig(ctx context.Context, configType, configName string) (res entitie.ConfigInterface, err error) {
+	ctx, span := tracer.Start(ctx, "repository.Find", trace.WithAttributes(attribute.String("config.type", configType), attribute.String("config.name", configName)))
+	defer func() {
+		endSpan(span, err)
+	}()
+	switch configType {
+	case mongodb:
+		res, err = s.mongoDBConfigRepo.Find(ctx, configName)
+	case tempconfig:
+		res, err = s.tempConfigRepo.Find(ctx, configName)
 	case tsconfig:
-		res, err = s.tsConfigRepo.Find(nameRequest.ConfigName)
-		if err != nil {
-			return nil, err
-		}
+		res, err = s.tsConfigRepo.Find(ctx, configName)
+	case featureflag:
+		res, err = s.featureFlagRepo.Find(ctx, configName)
 	default:
-		log.Print("unexpected type")
-		return nil, errors.New("unexpected type")
+		return nil, unexpectedTypeError(configType)
 	}
-	byteRes, err := json.Marshal(res)
 	if err != nil {
-		return nil, err
+		return nil, statusError(err, configType, configName)
 	}
-	configResponse = &pb.GetConfigResponce{Config: byteRes}
-	s.configCache.Set(nameRequest.ConfigName, configResponse, cache.DefaultExpiration)
-	return configResponse.(*pb.GetConfigResponce), nil
+	return res, nil
 }
 
-//GetConfigByName streams configs as GetConfigResponce messages
+//GetConfigsByType streams a page of configs as GetConfigResponce messages while they are read from the database. The token of the next page, if there is one, is sent in the next-page-token trailer
 func (s *configServer) GetConfigsByType(typeRequest *pb.GetConfigsByTypeRequest, stream pb.ConfigService_GetConfigsByTypeServer) error {
+	ctx := stream.Context()
+	options, err := listOptions(typeRequest)
+	if err != nil {
+		return err
+	}
+	var pageToken string
 	switch typeRequest.ConfigType {
 	case mongodb:
-		res, err := s.mongoDBConfigRepo.FindAll()
-		if err != nil {
-			return err
-		}
-		for _, v := range res {
-			byteRes, err := json.Marshal(v)
-			if err != nil {
-				return err
-			}
-			if err = stream.Send(&pb.GetConfigResponce{Config: byteRes}); err != nil {
-				return err
-			}
-		}
+		pageToken, err = s.mongoDBConfigRepo.Iterate(ctx, options, func(config *entitie.Mongodb) error {
+			return sendConfig(stream, config)
+		})
 	case tempconfig:
-		res, err := s.tempConfigRepo.FindAll()
-		if err != nil {
-			return err
-		}
-		for _, v := range res {
-			byteRes, err := json.Marshal(v)
-			if err != nil {
-				return err
-			}
-			if err = stream.Send(&pb.GetConfigResponce{Config: byteRes}); err != nil {
-				return err
-			}
-		}
+		pageToken, err = s.tempConfigRepo.Iterate(ctx, options, func(config *entitie.Tempconfig) error {
+			return sendConfig(stream, config)
+		})
 	case tsconfig:
-		res, err := s.tsConfigRepo.FindAll()
-		if err != nil {
-			return err
-		}
-		for _, v := range res {
-			byteRes, err := json.Marshal(v)
-			if err != nil {
-				return err
-			}
-			if err = stream.Send(&pb.GetConfigResponce{Config: byteRes}); err != nil {
-				return err
-			}
-		}
+		pageToken, err = s.tsConfigRepo.Iterate(ctx, options, func(config *entitie.Tsconfig) error {
+			return sendConfig(stream, config)
+		})
+	case featureflag:
+		pageToken, err = s.featureFlagRepo.Iterate(ctx, options, func(config *entitie.Featureflag) error {
+			return sendConfig(stream, config)
+		})
 	default:
-		log.Print("unexpected type")
-		return errors.New("unexpected type")
+		return unexpectedTypeError(typeRequest.ConfigType)
+	}
+	if err != nil {
+		return statusError(err, typeRequest.ConfigType, "")
+	}
+	if pageToken != "" {
+		stream.SetTrailer(metadata.Pairs(nextPageTokenKey, pageToken))
 	}
 	return nil
 }
@@ -142,171 +171,279 @@ func (s *configServer) CreateConfig(ctx context.Context, config *pb.Config) (*pb
 		configStr := entitie.Mongodb{}
 		err := json.Unmarshal(config.Config, &configStr)
 		if err != nil {
-			log.Printf("unmarshal config err: %v", err)
-			return nil, err
+			return nil, invalidArgument("config", err.Error())
 		}
-		response, err := s.mongoDBConfigRepo.Save(&configStr)
+		response, err := s.mongoDBConfigRepo.Save(ctx, &configStr)
 		if err != nil {
-			return nil, err
+			return nil, statusError(err, mongodb, configStr.Domain)
 		}
+		countWrite(mongodb, actionCreate)
 		s.configCache.Flush()
-		return &pb.Responce{Status: response}, nil
+		return &pb.Responce{Status: response, Revision: configStr.Revision}, nil
 
 	case tempconfig:
-		configStr := entitie.Tempconfig{}
-		err := json.Unmarshal(config.Config, &configStr)
+		configStr, err := unmarshalTempconfig(config)
 		if err != nil {
-			log.Printf("unmarshal config err: %v", err)
 			return nil, err
 		}
-		response, err := s.tempConfigRepo.Save(&configStr)
+		response, err := s.tempConfigRepo.Save(ctx, configStr)
 		if err != nil {
-			return nil, err
+			return nil, statusError(err, tempconfig, configStr.RestApiRoot)
 		}
+		countWrite(tempconfig, actionCreate)
 		s.configCache.Flush()
-		return &pb.Responce{Status: response}, nil
+		return &pb.Responce{Status: response, Revision: configStr.Revision}, nil
 
 	case tsconfig:
-		configStr := entitie.Tsconfig{}
-		err := json.Unmarshal(config.Config, &configStr)
+		configStr, err := unmarshalTsconfig(config)
 		if err != nil {
-			log.Printf("unmarshal config err: %v", err)
 			return nil, err
 		}
-		response, err := s.tsConfigRepo.Save(&configStr)
+		response, err := s.tsConfigRepo.Save(ctx, configStr)
 		if err != nil {
-			return nil, err
+			return nil, statusError(err, tsconfig, configStr.Module)
 		}
+		countWrite(tsconfig, actionCreate)
 		s.configCache.Flush()
-		return &pb.Responce{Status: response}, nil
+		return &pb.Responce{Status: response, Revision: configStr.Revision}, nil
+
+	case featureflag:
+		configStr := entitie.Featureflag{}
+		err := json.Unmarshal(config.Config, &configStr)
+		if err != nil {
+			return nil, invalidArgument("config", err.Error())
+		}
+		response, err := s.featureFlagRepo.Save(ctx, &configStr)
+		if err != nil {
+			return nil, statusError(err, featureflag, configStr.Key)
+		}
+		countWrite(featureflag, actionCreate)
+		s.configCache.Flush()
+		return &pb.Responce{Status: response, Revision: configStr.Revision}, nil
 	default:
-		log.Print("unexpected type")
-		return nil, errors.New("unexpected type")
+		return nil, unexpectedTypeError(config.ConfigType)
 	}
 }
 
-//DeleteConfig removes config records from the database. If successful, returns the amount of deleted records in a status message of the response structure
+//DeleteConfig removes config records from the database if they have the expected revision, unless the request is unconditional.
+//If successful, returns the amount of deleted records in a status message of the response structure
 func (s *configServer) DeleteConfig(ctx context.Context, delConfigRequest *pb.DeleteConfigRequest) (*pb.Responce, error) {
+	revision, err := expectedRevision(delConfigRequest.ExpectedRevision, 0, delConfigRequest.Unconditional)
+	if err != nil {
+		return nil, err
+	}
 	switch delConfigRequest.ConfigType {
 	case mongodb:
-		response, err := s.mongoDBConfigRepo.Delete(delConfigRequest.ConfigName)
+		response, err := s.mongoDBConfigRepo.Delete(ctx, delConfigRequest.ConfigName, revision)
 		if err != nil {
-			return nil, err
+			return nil, statusError(err, delConfigRequest.ConfigType, delConfigRequest.ConfigName)
 		}
+		countWrite(delConfigRequest.ConfigType, actionDelete)
 		s.configCache.Flush()
 		return &pb.Responce{Status: response}, nil
 	case tempconfig:
-		response, err := s.tempConfigRepo.Delete(delConfigRequest.ConfigName)
+		response, err := s.tempConfigRepo.Delete(ctx, delConfigRequest.ConfigName, revision)
 		if err != nil {
-			return nil, err
+			return nil, statusError(err, delConfigRequest.ConfigType, delConfigRequest.ConfigName)
 		}
+		countWrite(delConfigRequest.ConfigType, actionDelete)
 		s.configCache.Flush()
 		return &pb.Responce{Status: response}, nil
 	case tsconfig:
-		response, err := s.tsConfigRepo.Delete(delConfigRequest.ConfigName)
+		response, err := s.tsConfigRepo.Delete(ctx, delConfigRequest.ConfigName, revision)
 		if err != nil {
-			return nil, err
+			return nil, statusError(err, delConfigRequest.ConfigType, delConfigRequest.ConfigName)
 		}
+		countWrite(delConfigRequest.ConfigType, actionDelete)
+		s.configCache.Flush()
+		return &pb.Responce{Status: response}, nil
+	case featureflag:
+		response, err := s.featureFlagRepo.Delete(ctx, delConfigRequest.ConfigName, revision)
+		if err != nil {
+			return nil, statusError(err, delConfigRequest.ConfigType, delConfigRequest.ConfigName)
+		}
+		countWrite(delConfigRequest.ConfigType, actionDelete)
 		s.configCache.Flush()
 		return &pb.Responce{Status: response}, nil
 	default:
-		log.Print("unexpected type")
-		return nil, errors.New("unexpected type")
+		return nil, unexpectedTypeError(delConfigRequest.ConfigType)
 	}
 }
 
-//UpdateConfig
+//UpdateConfig rewrites a config if it has the expected revision, taken from the request or the revision field of the config, unless the request is unconditional.
+//The new revision of the config is returned in the response
 func (s *configServer) UpdateConfig(ctx context.Context, config *pb.Config) (*pb.Responce, error) {
 	var status string
+	var revision int64
 	switch config.ConfigType {
 	case mongodb:
 		configStr := entitie.Mongodb{}
 		err := json.Unmarshal(config.Config, &configStr)
 		if err != nil {
-			log.Printf("unmarshal config err: %v", err)
-			return nil, err
+			return nil, invalidArgument("config", err.Error())
 		}
-		status, err = s.mongoDBConfigRepo.Update(&configStr)
+		configStr.Revision, err = expectedRevision(config.ExpectedRevision, configStr.Revision, config.Unconditional)
 		if err != nil {
 			return nil, err
 		}
+		status, err = s.mongoDBConfigRepo.Update(ctx, &configStr)
+		if err != nil {
+			return nil, statusError(err, mongodb, configStr.Domain)
+		}
+		revision = configStr.Revision
 	case tempconfig:
-		configStr := entitie.Tempconfig{}
-		err := json.Unmarshal(config.Config, &configStr)
+		configStr, err := unmarshalTempconfig(config)
 		if err != nil {
-			log.Printf("unmarshal config err: %v", err)
 			return nil, err
 		}
-		status, err = s.tempConfigRepo.Update(&configStr)
+		configStr.Revision, err = expectedRevision(config.ExpectedRevision, configStr.Revision, config.Unconditional)
 		if err != nil {
 			return nil, err
 		}
+		status, err = s.tempConfigRepo.Update(ctx, configStr)
+		if err != nil {
+			return nil, statusError(err, tempconfig, configStr.RestApiRoot)
+		}
+		revision = configStr.Revision
 	case tsconfig:
-		configStr := entitie.Tsconfig{}
-		err := json.Unmarshal(config.Config, &configStr)
+		configStr, err := unmarshalTsconfig(config)
 		if err != nil {
-			log.Printf("unmarshal config err: %v", err)
 			return nil, err
 		}
-		status, err = s.tsConfigRepo.Update(&configStr)
+		configStr.Revision, err = expectedRevision(config.ExpectedRevision, configStr.Revision, config.Unconditional)
 		if err != nil {
 			return nil, err
 		}
+		status, err = s.tsConfigRepo.Update(ctx, configStr)
+		if err != nil {
+			return nil, statusError(err, tsconfig, configStr.Module)
+		}
+		revision = configStr.Revision
+	case featureflag:
+		configStr := entitie.Featureflag{}
+		err := json.Unmarshal(config.Config, &configStr)
+		if err != nil {
+			return nil, invalidArgument("config", err.Error())
+		}
+		configStr.Revision, err = expectedRevision(config.ExpectedRevision, configStr.Revision, config.Unconditional)
+		if err != nil {
+			return nil, err
+		}
+		status, err = s.featureFlagRepo.Update(ctx, &configStr)
+		if err != nil {
+			return nil, statusError(err, featureflag, configStr.Key)
+		}
+		revision = configStr.Revision
 	default:
-		log.Print("unexpected type")
-		return nil, errors.New("unexpected type")
+		return nil, unexpectedTypeError(config.ConfigType)
 	}
+	countWrite(config.ConfigType, actionUpdate)
 	s.configCache.Flush()
-	return &pb.Responce{Status: status}, nil
+	return &pb.Responce{Status: status, Revision: revision}, nil
 }
 
 func main() {
+	logging.Init(os.Stderr)
 
-	port := os.Getenv("SERVICE_PORT")
-	if port == "" {
-		log.Println("error during reading env. variable, default value is used")
-		port = defaultPort
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			logging.Fatal("command failed", "command", os.Args[1], "error", err)
+		}
+		return
 	}
-	cacheExpirationTime, err := strconv.Atoi(os.Getenv("CACHE_EXPIRATION_TIME"))
+
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	serviceSettings, err := loadSettings(flags, os.Args[1:])
 	if err != nil {
-		log.Printf("error during reading env. variable: %v, default value is used", err)
-		cacheExpirationTime = defaultCacheExpirationTime
+		logging.Fatal("failed to load settings", "error", err)
 	}
-	cacheCleanupInterval, err := strconv.Atoi(os.Getenv("CACHE_CLEANUP_INTERVAL"))
+	server := serviceSettings.Server
+	requestTimeout := time.Duration(server.RequestTimeoutSeconds) * time.Second
+	streamTimeout := time.Duration(server.StreamTimeoutSeconds) * time.Second
+	shutdownTracing, err := initTracing(context.Background(), server.TracesExporter, os.Stdout)
 	if err != nil {
-		log.Printf("error during reading env. variable: %v, default value is used", err)
-		cacheCleanupInterval = defaultCacheCleanupInterval
+		logging.Fatal("failed to init tracing", "error", err)
 	}
 
-	dbConn, err := repository.InitPostgresDB()
-	if err != nil {
-		log.Fatalf("failed to init postgres db: %v", err)
+	repos := newSnapshotRepos()
+	if server.SnapshotFile != "" {
+		if err := repos.load(server.SnapshotFile); err != nil {
+			logging.Fatal("failed to load snapshot", "error", err)
+		}
+	}
+	var dbConn atomic.Pointer[gorm.DB]
+	connectDB := func() error {
+		db, err := repository.InitPostgresDB(serviceSettings.Postgres)
+		if err != nil {
+			return err
+		}
+		repos.connect(repository.ConfigRepos{
+			MongoDB:     &repository.MongoDBConfigRepoImpl{DB: db},
+			TempConfig:  &repository.TempConfigRepoImpl{DB: db},
+			TsConfig:    &repository.TsConfigRepoImpl{DB: db},
+			FeatureFlag: &repository.FeatureFlagRepoImpl{DB: db},
+		}, &repository.PostgresTransactor{DB: db})
+		prometheus.MustRegister(newDBStatsCollector(db.DB()))
+		dbConn.Store(db)
+		return nil
+	}
+	if server.DegradedStartup {
+		//the server starts at once, it is not ready and serves configs of the snapshot until the database is connected
+		slog.Warn("starting before the database is connected")
+		go connectUntilConnected(connectDB, serviceSettings.Postgres.Backoff)
+	} else if err := connectDB(); err != nil {
+		logging.Fatal("failed to init postgres db", "error", err)
 	}
-	mongoDBRepo := repository.MongoDBConfigRepoImpl{DB: dbConn}
-	tsConfigRepo := repository.TsConfigRepoImpl{DB: dbConn}
-	tempConfigRepo := repository.TempConfigRepoImpl{DB: dbConn}
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", server.Port))
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		logging.Fatal("failed to listen", "error", err)
 	}
 
-	log.Printf("server started at :%s", port)
+	slog.Info("server started", "port", server.Port)
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(chainUnaryInterceptors(loggingUnaryInterceptor, tracingUnaryInterceptor, metricsUnaryInterceptor, contextUnaryInterceptor(requestTimeout))),
+		grpc.StreamInterceptor(chainStreamInterceptors(loggingStreamInterceptor, tracingStreamInterceptor, metricsStreamInterceptor, contextStreamInterceptor(streamTimeout))),
+	)
 
-	configCache := cache.New(time.Duration(cacheExpirationTime)*time.Minute, time.Duration(cacheCleanupInterval)*time.Minute)
+	configCache := cache.New(time.Duration(serviceSettings.Cache.ExpirationMinutes)*time.Minute, time.Duration(serviceSettings.Cache.CleanupIntervalMinutes)*time.Minute)
+	prometheus.MustRegister(
+		cacheSizeGauge(configCache),
+		newConfigCountCollector(repository.ConfigRepos{MongoDB: repos.mongoDB, TempConfig: repos.tempConfig, TsConfig: repos.tsConfig, FeatureFlag: repos.featureFlag}, requestTimeout),
+	)
+	serveMetrics(fmt.Sprintf(":%s", server.MetricsPort))
 
-	pb.RegisterConfigServiceServer(grpcServer, &configServer{configCache: configCache, mongoDBConfigRepo: &mongoDBRepo, tsConfigRepo: &tsConfigRepo, tempConfigRepo: &tempConfigRepo})
+	pb.RegisterConfigServiceServer(grpcServer, &configServer{configCache: configCache, mongoDBConfigRepo: repos.mongoDB, tsConfigRepo: repos.tsConfig, tempConfigRepo: repos.tempConfig, featureFlagRepo: repos.featureFlag, transactor: repos.transactor})
+	pb.RegisterAdminServiceServer(grpcServer, &adminServer{configCache: configCache, repos: repository.ConfigRepos{MongoDB: repos.mongoDB, TempConfig: repos.tempConfig, TsConfig: repos.tsConfig, FeatureFlag: repos.featureFlag}})
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	checker := newHealthChecker(healthServer, func(ctx context.Context) error {
+		db := dbConn.Load()
+		if db == nil {
+			return errDatabaseNotConnected
+		}
+		return repository.CheckPostgres(ctx, db)
+	}, time.Duration(server.HealthCheckIntervalSeconds)*time.Second, serviceSettings.Postgres.Backoff)
+	stopHealthChecks := make(chan struct{})
+	go checker.run(stopHealthChecks)
 
 	go func() {
-		log.Fatal(grpcServer.Serve(lis))
+		logging.Fatal("failed to serve", "error", grpcServer.Serve(lis))
 	}()
 
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 	<-signalChan
 
-	log.Println("shotdown signal received, exiting")
+	slog.Info("shutdown signal received, exiting")
+	close(stopHealthChecks)
+	checker.shutdown()
+	//load balancers notice the not serving status before the server stops accepting requests
+	time.Sleep(time.Duration(server.ShutdownDelaySeconds) * time.Second)
 	grpcServer.GracefulStop()
+	if err := shutdownTracing(context.Background()); err != nil {
+		slog.Error("error during flushing spans", "error", err)
+	}
 }
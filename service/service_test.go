@@ -12,146 +12,257 @@ import (
 	"errors"
 
 	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
 	"github.com/patrickmn/go-cache"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type mockMongoDBConfigRepo struct {
 }
 
-func (m *mockMongoDBConfigRepo) Find(configName string) (*entitie.Mongodb, error) {
-	return &entitie.Mongodb{Domain: "testName", Mongodb: true, Host: "testHost", Port: "testPort"}, nil
+func (m *mockMongoDBConfigRepo) Find(ctx context.Context, configName string) (*entitie.Mongodb, error) {
+	return &entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080}, nil
 }
 
-func (m *mockMongoDBConfigRepo) FindAll() ([]entitie.Mongodb, error) {
-	return []entitie.Mongodb{{Domain: "testName", Mongodb: true, Host: "testHost", Port: "testPort"}}, nil
+func (m *mockMongoDBConfigRepo) FindAll(ctx context.Context) ([]entitie.Mongodb, error) {
+	return []entitie.Mongodb{{Domain: "testName", Host: "testHost", Port: 8080}}, nil
 }
 
-func (m *mockMongoDBConfigRepo) Update(config *entitie.Mongodb) (string, error) {
+func (m *mockMongoDBConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Mongodb) error) (string, error) {
+	return "", fn(&entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080})
+}
+
+func (m *mockMongoDBConfigRepo) Count(ctx context.Context) (int, error) {
+	return 1, nil
+}
+
+func (m *mockMongoDBConfigRepo) Update(ctx context.Context, config *entitie.Mongodb) (string, error) {
+	return "OK", nil
+}
+
+func (m *mockMongoDBConfigRepo) Patch(ctx context.Context, config *entitie.Mongodb, fields []string) (string, error) {
 	return "OK", nil
 }
 
-func (m *mockMongoDBConfigRepo) Save(config *entitie.Mongodb) (string, error) {
+func (m *mockMongoDBConfigRepo) Save(ctx context.Context, config *entitie.Mongodb) (string, error) {
 	return "OK", nil
 }
 
-func (m *mockMongoDBConfigRepo) Delete(configName string) (string, error) {
+func (m *mockMongoDBConfigRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
 	return "OK", nil
 }
 
 type mockErrorMongoDBConfigRepo struct {
 }
 
-func (m *mockErrorMongoDBConfigRepo) Find(configName string) (*entitie.Mongodb, error) {
+func (m *mockErrorMongoDBConfigRepo) Find(ctx context.Context, configName string) (*entitie.Mongodb, error) {
 	return nil, errors.New("error from database querying")
 }
 
-func (m *mockErrorMongoDBConfigRepo) FindAll() ([]entitie.Mongodb, error) {
+func (m *mockErrorMongoDBConfigRepo) FindAll(ctx context.Context) ([]entitie.Mongodb, error) {
 	return nil, errors.New("error from database querying")
 }
 
-func (m *mockErrorMongoDBConfigRepo) Update(config *entitie.Mongodb) (string, error) {
+func (m *mockErrorMongoDBConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Mongodb) error) (string, error) {
 	return "", errors.New("error from database querying")
 }
 
-func (m *mockErrorMongoDBConfigRepo) Save(config *entitie.Mongodb) (string, error) {
+func (m *mockErrorMongoDBConfigRepo) Count(ctx context.Context) (int, error) {
+	return 0, errors.New("error from database querying")
+}
+
+func (m *mockErrorMongoDBConfigRepo) Update(ctx context.Context, config *entitie.Mongodb) (string, error) {
 	return "", errors.New("error from database querying")
 }
-func (m *mockErrorMongoDBConfigRepo) Delete(configName string) (string, error) {
+
+func (m *mockErrorMongoDBConfigRepo) Patch(ctx context.Context, config *entitie.Mongodb, fields []string) (string, error) {
+	return "", errors.New("error from database querying")
+}
+
+func (m *mockErrorMongoDBConfigRepo) Save(ctx context.Context, config *entitie.Mongodb) (string, error) {
+	return "", errors.New("error from database querying")
+}
+func (m *mockErrorMongoDBConfigRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
 	return "", errors.New("error from database querying")
 }
 
 type mockTsConfigRepo struct {
 }
 
-func (m *mockTsConfigRepo) Find(configName string) (*entitie.Tsconfig, error) {
+func (m *mockTsConfigRepo) Find(ctx context.Context, configName string) (*entitie.Tsconfig, error) {
 	return &entitie.Tsconfig{Module: "testModule", Target: "testTarget", SourceMap: true, Excluding: 1}, nil
 }
 
-func (m *mockTsConfigRepo) FindAll() ([]entitie.Tsconfig, error) {
+func (m *mockTsConfigRepo) FindAll(ctx context.Context) ([]entitie.Tsconfig, error) {
 	return []entitie.Tsconfig{{Module: "testModule", Target: "testTarget", SourceMap: true, Excluding: 1}}, nil
 }
 
-func (m *mockTsConfigRepo) Update(config *entitie.Tsconfig) (string, error) {
+func (m *mockTsConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Tsconfig) error) (string, error) {
+	return "", fn(&entitie.Tsconfig{Module: "testModule", Target: "testTarget", SourceMap: true, Excluding: 1})
+}
+
+func (m *mockTsConfigRepo) Count(ctx context.Context) (int, error) {
+	return 1, nil
+}
+
+func (m *mockTsConfigRepo) Update(ctx context.Context, config *entitie.Tsconfig) (string, error) {
 	return "OK", nil
 }
 
-func (m *mockTsConfigRepo) Save(config *entitie.Tsconfig) (string, error) {
+func (m *mockTsConfigRepo) Patch(ctx context.Context, config *entitie.Tsconfig, fields []string) (string, error) {
 	return "OK", nil
 }
 
-func (m *mockTsConfigRepo) Delete(configName string) (string, error) {
+func (m *mockTsConfigRepo) Save(ctx context.Context, config *entitie.Tsconfig) (string, error) {
+	return "OK", nil
+}
+
+func (m *mockTsConfigRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
 	return "OK", nil
 }
 
 type mockErrorTsConfigRepo struct {
 }
 
-func (m *mockErrorTsConfigRepo) Find(configName string) (*entitie.Tsconfig, error) {
+func (m *mockErrorTsConfigRepo) Find(ctx context.Context, configName string) (*entitie.Tsconfig, error) {
 	return nil, errors.New("error from database querying")
 }
 
-func (m *mockErrorTsConfigRepo) FindAll() ([]entitie.Tsconfig, error) {
+func (m *mockErrorTsConfigRepo) FindAll(ctx context.Context) ([]entitie.Tsconfig, error) {
 	return nil, errors.New("error from database querying")
 }
 
-func (m *mockErrorTsConfigRepo) Update(config *entitie.Tsconfig) (string, error) {
+func (m *mockErrorTsConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Tsconfig) error) (string, error) {
 	return "", errors.New("error from database querying")
 }
 
-func (m *mockErrorTsConfigRepo) Save(config *entitie.Tsconfig) (string, error) {
+func (m *mockErrorTsConfigRepo) Count(ctx context.Context) (int, error) {
+	return 0, errors.New("error from database querying")
+}
+
+func (m *mockErrorTsConfigRepo) Update(ctx context.Context, config *entitie.Tsconfig) (string, error) {
 	return "", errors.New("error from database querying")
 }
-func (m *mockErrorTsConfigRepo) Delete(configName string) (string, error) {
+
+func (m *mockErrorTsConfigRepo) Patch(ctx context.Context, config *entitie.Tsconfig, fields []string) (string, error) {
+	return "", errors.New("error from database querying")
+}
+
+func (m *mockErrorTsConfigRepo) Save(ctx context.Context, config *entitie.Tsconfig) (string, error) {
+	return "", errors.New("error from database querying")
+}
+func (m *mockErrorTsConfigRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
 	return "", errors.New("error from database querying")
 }
 
 type mockTempConfigRepo struct {
 }
 
-func (m *mockTempConfigRepo) Find(configName string) (*entitie.Tempconfig, error) {
-	return &entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}, nil
+func (m *mockTempConfigRepo) Find(ctx context.Context, configName string) (*entitie.Tempconfig, error) {
+	return &entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}, nil
+}
+
+func (m *mockTempConfigRepo) FindAll(ctx context.Context) ([]entitie.Tempconfig, error) {
+	return []entitie.Tempconfig{{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}}, nil
+}
+
+func (m *mockTempConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Tempconfig) error) (string, error) {
+	return "", fn(&entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true})
 }
 
-func (m *mockTempConfigRepo) FindAll() ([]entitie.Tempconfig, error) {
-	return []entitie.Tempconfig{{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}}, nil
+func (m *mockTempConfigRepo) Count(ctx context.Context) (int, error) {
+	return 1, nil
 }
 
-func (m *mockTempConfigRepo) Update(config *entitie.Tempconfig) (string, error) {
+func (m *mockTempConfigRepo) Update(ctx context.Context, config *entitie.Tempconfig) (string, error) {
 	return "OK", nil
 }
 
-func (m *mockTempConfigRepo) Save(config *entitie.Tempconfig) (string, error) {
+func (m *mockTempConfigRepo) Patch(ctx context.Context, config *entitie.Tempconfig, fields []string) (string, error) {
 	return "OK", nil
 }
 
-func (m *mockTempConfigRepo) Delete(configName string) (string, error) {
+func (m *mockTempConfigRepo) Save(ctx context.Context, config *entitie.Tempconfig) (string, error) {
+	return "OK", nil
+}
+
+func (m *mockTempConfigRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
 	return "OK", nil
 }
 
 type mockErrorTempConfigRepo struct {
 }
 
-func (m *mockErrorTempConfigRepo) Find(configName string) (*entitie.Tempconfig, error) {
+func (m *mockErrorTempConfigRepo) Find(ctx context.Context, configName string) (*entitie.Tempconfig, error) {
 	return nil, errors.New("error from database querying")
 }
 
-func (m *mockErrorTempConfigRepo) FindAll() ([]entitie.Tempconfig, error) {
+func (m *mockErrorTempConfigRepo) FindAll(ctx context.Context) ([]entitie.Tempconfig, error) {
 	return nil, errors.New("error from database querying")
 }
 
-func (m *mockErrorTempConfigRepo) Update(config *entitie.Tempconfig) (string, error) {
+func (m *mockErrorTempConfigRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Tempconfig) error) (string, error) {
 	return "", errors.New("error from database querying")
 }
 
-func (m *mockErrorTempConfigRepo) Save(config *entitie.Tempconfig) (string, error) {
+func (m *mockErrorTempConfigRepo) Count(ctx context.Context) (int, error) {
+	return 0, errors.New("error from database querying")
+}
+
+func (m *mockErrorTempConfigRepo) Update(ctx context.Context, config *entitie.Tempconfig) (string, error) {
+	return "", errors.New("error from database querying")
+}
+
+func (m *mockErrorTempConfigRepo) Patch(ctx context.Context, config *entitie.Tempconfig, fields []string) (string, error) {
+	return "", errors.New("error from database querying")
+}
+
+func (m *mockErrorTempConfigRepo) Save(ctx context.Context, config *entitie.Tempconfig) (string, error) {
 	return "", errors.New("error from database querying")
 }
-func (m *mockErrorTempConfigRepo) Delete(configName string) (string, error) {
+func (m *mockErrorTempConfigRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
 	return "", errors.New("error from database querying")
 }
 
+type mockFeatureFlagRepo struct {
+}
+
+func (m *mockFeatureFlagRepo) Find(ctx context.Context, configName string) (*entitie.Featureflag, error) {
+	return &entitie.Featureflag{Key: "testFlag", Enabled: true, Variants: entitie.JSONMap{"on": true, "off": false}, OffVariant: "off", DefaultVariant: "on"}, nil
+}
+
+func (m *mockFeatureFlagRepo) FindAll(ctx context.Context) ([]entitie.Featureflag, error) {
+	return []entitie.Featureflag{{Key: "testFlag", Enabled: true, Variants: entitie.JSONMap{"on": true, "off": false}, OffVariant: "off", DefaultVariant: "on"}}, nil
+}
+
+func (m *mockFeatureFlagRepo) Iterate(ctx context.Context, options repository.ListOptions, fn func(config *entitie.Featureflag) error) (string, error) {
+	return "", fn(&entitie.Featureflag{Key: "testFlag", Enabled: true, Variants: entitie.JSONMap{"on": true, "off": false}, OffVariant: "off", DefaultVariant: "on"})
+}
+
+func (m *mockFeatureFlagRepo) Count(ctx context.Context) (int, error) {
+	return 1, nil
+}
+
+func (m *mockFeatureFlagRepo) Update(ctx context.Context, config *entitie.Featureflag) (string, error) {
+	return "OK", nil
+}
+
+func (m *mockFeatureFlagRepo) Patch(ctx context.Context, config *entitie.Featureflag, fields []string) (string, error) {
+	return "OK", nil
+}
+
+func (m *mockFeatureFlagRepo) Save(ctx context.Context, config *entitie.Featureflag) (string, error) {
+	return "OK", nil
+}
+
+func (m *mockFeatureFlagRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
+	return "OK", nil
+}
+
 func TestGetConfigByName(t *testing.T) {
 
 	configCache := cache.New(5*time.Minute, 10*time.Minute)
@@ -166,7 +277,7 @@ func TestGetConfigByName(t *testing.T) {
 		t.Error("error during unit testing: ", err)
 	}
 	var expectedConfig []byte
-	expectedConfig, err = json.Marshal(entitie.Mongodb{Domain: "testName", Mongodb: true, Host: "testHost", Port: "testPort"})
+	expectedConfig, err = json.Marshal(entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080})
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -186,7 +297,7 @@ func TestGetConfigByName(t *testing.T) {
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
-	expectedConfig, err = json.Marshal(entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true})
+	expectedConfig, err = json.Marshal(entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true})
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -212,13 +323,13 @@ func TestGetConfigByName(t *testing.T) {
 	}
 	_, err = mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigType: "unexpectedConfigType", ConfigName: "testNameTemp"})
 	if assert.Error(t, err) {
-		assert.Equal(t, errors.New("unexpected type"), err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
 	}
 }
 
 func TestGetConfigByName_FromCache(t *testing.T) {
 	testName := "testName"
-	testConf := entitie.Mongodb{Domain: testName, Mongodb: true, Host: "testHost", Port: "testPort"}
+	testConf := entitie.Mongodb{Domain: testName, Host: "testHost", Port: 8080}
 	configCache := cache.New(5*time.Minute, 10*time.Minute)
 	mock := &mockConfigServer{}
 	mock.configCache = configCache
@@ -228,13 +339,13 @@ func TestGetConfigByName_FromCache(t *testing.T) {
 		t.Error("error during unit testing: ", err)
 	}
 	configResponse := &pb.GetConfigResponce{Config: byteRes}
-	mock.configCache.Set(testName, configResponse, 5*time.Minute)
+	mock.configCache.Set(mongodb+"/"+testName, configResponse, 5*time.Minute)
 	res, err := mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigType: "mongodb", ConfigName: "testName"})
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
 	var expectedConfig []byte
-	expectedConfig, err = json.Marshal(entitie.Mongodb{Domain: "testName", Mongodb: true, Host: "testHost", Port: "testPort"})
+	expectedConfig, err = json.Marshal(entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080})
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -259,7 +370,7 @@ func TestGetConfigsByType(t *testing.T) {
 	}
 	err = mock.GetConfigsByType(&pb.GetConfigsByTypeRequest{ConfigType: "unexpectedConfigType"}, mock)
 	if assert.Error(t, err) {
-		assert.Equal(t, errors.New("unexpected type"), err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
 	}
 
 	expectedError := errors.New("error from database querying")
@@ -286,7 +397,7 @@ func TestGetConfigsByType(t *testing.T) {
 	mock.tempConfigRepo = &mockErrorTempConfigRepo{}
 	err = mock.GetConfigsByType(&pb.GetConfigsByTypeRequest{ConfigType: "unexpectedType"}, mock)
 	if assert.Error(t, err) {
-		assert.Equal(t, errors.New("unexpected type"), err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
 	}
 
 }
@@ -295,6 +406,25 @@ type mockConfigServer struct {
 	configServer
 	grpc.ServerStream
 	Results []*pb.GetConfigResponce
+	Header  metadata.MD
+	Trailer metadata.MD
+	ctx     context.Context
+}
+
+func (mcs *mockConfigServer) Context() context.Context {
+	if mcs.ctx == nil {
+		return context.Background()
+	}
+	return mcs.ctx
+}
+
+func (mcs *mockConfigServer) SetHeader(md metadata.MD) error {
+	mcs.Header = metadata.Join(mcs.Header, md)
+	return nil
+}
+
+func (mcs *mockConfigServer) SetTrailer(md metadata.MD) {
+	mcs.Trailer = metadata.Join(mcs.Trailer, md)
 }
 
 func (mcs *mockConfigServer) Send(response *pb.GetConfigResponce) error {
@@ -311,7 +441,7 @@ func TestCreateConfig(t *testing.T) {
 	mock.tsConfigRepo = &mockTsConfigRepo{}
 	mock.tempConfigRepo = &mockTempConfigRepo{}
 
-	testConfMongo := entitie.Mongodb{Domain: "testName", Mongodb: true, Host: "testHost", Port: "testPort"}
+	testConfMongo := entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080}
 	byteRes, err := json.Marshal(testConfMongo)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
@@ -336,7 +466,7 @@ func TestCreateConfig(t *testing.T) {
 	}
 	assert.Equal(t, expectedResponse, res)
 
-	testConfTemp := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
+	testConfTemp := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
 	byteRes, err = json.Marshal(testConfTemp)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
@@ -370,7 +500,7 @@ func TestCreateConfig(t *testing.T) {
 	resultingErr = nil
 	_, resultingErr = mock.CreateConfig(context.Background(), &pb.Config{ConfigType: "unexpectedType", Config: byteRes})
 	if assert.Error(t, resultingErr) {
-		assert.Equal(t, errors.New("unexpected type"), resultingErr)
+		assert.Equal(t, codes.InvalidArgument, status.Code(resultingErr))
 	}
 }
 
@@ -383,21 +513,21 @@ func TestDeleteConfig(t *testing.T) {
 	mock.tsConfigRepo = &mockTsConfigRepo{}
 	mock.tempConfigRepo = &mockTempConfigRepo{}
 
-	res, err := mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "mongodb", ConfigName: "testName"})
+	res, err := mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "mongodb", ConfigName: "testName", Unconditional: true})
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
 	expectedResponse := &pb.Responce{Status: "OK"}
 	assert.Equal(t, expectedResponse, res)
 
-	res, err = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "tsconfig", ConfigName: "testName"})
+	res, err = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "tsconfig", ConfigName: "testName", Unconditional: true})
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
 
 	assert.Equal(t, expectedResponse, res)
 
-	res, err = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "tempconfig", ConfigName: "testName"})
+	res, err = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "tempconfig", ConfigName: "testName", Unconditional: true})
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
@@ -408,24 +538,24 @@ func TestDeleteConfig(t *testing.T) {
 	mock.tsConfigRepo = &mockErrorTsConfigRepo{}
 	mock.tempConfigRepo = &mockErrorTempConfigRepo{}
 	expectedError := errors.New("error from database querying")
-	_, resultingErr := mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "mongodb", ConfigName: "errorTestName"})
+	_, resultingErr := mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "mongodb", ConfigName: "errorTestName", Unconditional: true})
 	if assert.Error(t, resultingErr) {
 		assert.Equal(t, expectedError, resultingErr)
 	}
 	resultingErr = nil
-	_, resultingErr = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "tsconfig", ConfigName: "errorTestName"})
+	_, resultingErr = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "tsconfig", ConfigName: "errorTestName", Unconditional: true})
 	if assert.Error(t, resultingErr) {
 		assert.Equal(t, expectedError, resultingErr)
 	}
 	resultingErr = nil
-	_, resultingErr = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "tempconfig", ConfigName: "errorTestName"})
+	_, resultingErr = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "tempconfig", ConfigName: "errorTestName", Unconditional: true})
 	if assert.Error(t, resultingErr) {
 		assert.Equal(t, expectedError, resultingErr)
 	}
 	resultingErr = nil
-	_, resultingErr = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "unexpectedType", ConfigName: "errorTestName"})
+	_, resultingErr = mock.DeleteConfig(context.Background(), &pb.DeleteConfigRequest{ConfigType: "unexpectedType", ConfigName: "errorTestName", Unconditional: true})
 	if assert.Error(t, resultingErr) {
-		assert.Equal(t, errors.New("unexpected type"), resultingErr)
+		assert.Equal(t, codes.InvalidArgument, status.Code(resultingErr))
 	}
 }
 
@@ -438,7 +568,7 @@ func TestUpdateConfig(t *testing.T) {
 	mock.tsConfigRepo = &mockTsConfigRepo{}
 	mock.tempConfigRepo = &mockTempConfigRepo{}
 
-	testConfMongo := entitie.Mongodb{Domain: "testName", Mongodb: true, Host: "testHost", Port: "testPort"}
+	testConfMongo := entitie.Mongodb{Domain: "testName", Host: "testHost", Port: 8080}
 	byteResMongo, err := json.Marshal(testConfMongo)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
@@ -448,41 +578,41 @@ func TestUpdateConfig(t *testing.T) {
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
-	testConfTemp := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: "testPort", Remoting: "testRemoting", LegasyExplorer: true}
+	testConfTemp := entitie.Tempconfig{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}
 	byteResTemp, err := json.Marshal(testConfTemp)
 	if err != nil {
 		t.Error("error during unit testing: ", err)
 	}
 
-	resp, err := mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "mongodb", Config: byteResMongo})
+	resp, err := mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "mongodb", Config: byteResMongo, Unconditional: true})
 	assert.Equal(t, &pb.Responce{Status: "OK"}, resp)
-	resp, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tsconfig", Config: byteResTs})
+	resp, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tsconfig", Config: byteResTs, Unconditional: true})
 	assert.Equal(t, &pb.Responce{Status: "OK"}, resp)
-	resp, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tempconfig", Config: byteResTemp})
+	resp, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tempconfig", Config: byteResTemp, Unconditional: true})
 	assert.Equal(t, &pb.Responce{Status: "OK"}, resp)
-	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "unexpectedConfigType"})
+	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "unexpectedConfigType", Unconditional: true})
 	if assert.Error(t, err) {
-		assert.Equal(t, errors.New("unexpected type"), err)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
 	}
 
 	expectedError := errors.New("error from database querying")
 	mock.mongoDBConfigRepo = &mockErrorMongoDBConfigRepo{}
 	err = nil
-	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "mongodb", Config: byteResMongo})
+	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "mongodb", Config: byteResMongo, Unconditional: true})
 	if assert.Error(t, err) {
 		assert.Equal(t, expectedError, err)
 	}
 
 	err = nil
 	mock.tsConfigRepo = &mockErrorTsConfigRepo{}
-	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tsconfig", Config: byteResTs})
+	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tsconfig", Config: byteResTs, Unconditional: true})
 	if assert.Error(t, err) {
 		assert.Equal(t, expectedError, err)
 	}
 
 	err = nil
 	mock.tempConfigRepo = &mockErrorTempConfigRepo{}
-	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tempconfig", Config: byteResTemp})
+	_, err = mock.UpdateConfig(context.Background(), &pb.Config{ConfigType: "tempconfig", Config: byteResTemp, Unconditional: true})
 	if assert.Error(t, err) {
 		assert.Equal(t, expectedError, err)
 	}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	exporterNone   = "none"
+	exporterOTLP   = "otlp"
+	exporterStdout = "stdout"
+)
+
+var tracer = otel.Tracer("github.com/YAWAL/GetMeConf/service")
+
+//newSpanExporter returns the exporter of the given name. The OTLP exporter sends spans to a collector over gRPC and is configured by the standard
+//OTEL_EXPORTER_OTLP_* env. variables, localhost:4317 by default. The stdout exporter writes spans to w
+func newSpanExporter(ctx context.Context, name string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch name {
+	case exporterOTLP:
+		return otlptracegrpc.New(ctx)
+	case exporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(w))
+	default:
+		return nil, fmt.Errorf("unexpected traces exporter %q", name)
+	}
+}
+
+//initTracing installs the global tracer provider exporting spans with the named exporter and the W3C trace context propagator.
+//The returned function flushes remaining spans, with no exporter spans are not recorded at all
+func initTracing(ctx context.Context, exporterName string, w io.Writer) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporterName == "" || exporterName == exporterNone {
+		return func(ctx context.Context) error { return nil }, nil
+	}
+	exporter, err := newSpanExporter(ctx, exporterName, w)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName("getmeconf"))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+//metadataCarrier reads and writes trace context propagated in gRPC metadata
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c)[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c)[key] = []string{value}
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+//startServerSpan starts the span of an RPC as a child of the trace context sent by the client, if any
+func startServerSpan(ctx context.Context, method string) (context.Context, trace.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+	}
+	attributes := []attribute.KeyValue{semconv.RPCSystemGRPC}
+	if parts := strings.Split(strings.TrimPrefix(method, "/"), "/"); len(parts) == 2 {
+		attributes = append(attributes, semconv.RPCService(parts[0]), semconv.RPCMethod(parts[1]))
+	}
+	return tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+}
+
+//endSpan records err as the status of the span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+	span.End()
+}
+
+//tracingUnaryInterceptor traces every RPC, spans of the cache, the repositories and the serialisation are its children
+func tracingUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, span := startServerSpan(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(status.Code(err))))
+	endSpan(span, err)
+	return resp, err
+}
+
+//tracingStreamInterceptor is tracingUnaryInterceptor for streaming RPCs
+func tracingStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, span := startServerSpan(stream.Context(), info.FullMethod)
+	err := handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(status.Code(err))))
+	endSpan(span, err)
+	return err
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTracingUnaryInterceptor(t *testing.T) {
+	if _, err := initTracing(context.Background(), exporterNone, nil); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(cache.NoExpiration, 0)
+	mock.mongoDBConfigRepo = &mockMongoDBConfigRepo{}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/api.ConfigService/GetConfigByName"}
+	_, err := tracingUnaryInterceptor(ctx, &pb.GetConfigByNameRequest{ConfigName: "testName", ConfigType: "mongodb"}, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return mock.GetConfigByName(ctx, req.(*pb.GetConfigByNameRequest))
+		})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+
+	spans := exporter.GetSpans()
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID().String(), "the incoming trace is continued")
+	}
+	assert.Equal(t, []string{"cache.Get", "repository.Find", "marshalConfig", info.FullMethod}, names)
+	server := spans[len(spans)-1]
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	for _, span := range spans[:len(spans)-1] {
+		assert.Equal(t, server.SpanContext.SpanID(), span.Parent.SpanID(), span.Name)
+	}
+}
+
+func TestNewSpanExporter(t *testing.T) {
+	var buf bytes.Buffer
+	exporter, err := newSpanExporter(context.Background(), exporterStdout, &buf)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer("test").Start(context.Background(), "testSpan")
+	span.End()
+	assert.NoError(t, provider.Shutdown(context.Background()))
+	assert.Contains(t, buf.String(), "testSpan")
+
+	_, err = newSpanExporter(context.Background(), "zipkin", &buf)
+	assert.Error(t, err)
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+	formatTOML = "toml"
+)
+
+const (
+	importModeCreate  = "create"
+	importModeUpsert  = "upsert"
+	importModeReplace = "replace"
+)
+
+const (
+	actionCreate    = "create"
+	actionUpdate    = "update"
+	actionDelete    = "delete"
+	actionUnchanged = "unchanged"
+)
+
+var configTypes = []string{mongodb, tempconfig, tsconfig, featureflag}
+
+//ExportConfigs returns all configs of the requested types (of every type if none is given) as a single JSON, YAML or TOML archive
+func (s *configServer) ExportConfigs(ctx context.Context, exportRequest *pb.ExportConfigsRequest) (*pb.ExportConfigsResponce, error) {
+	archive, err := exportArchive(ctx, s.repos(), exportRequest.ConfigTypes)
+	if err != nil {
+		return nil, err
+	}
+	data, err := encodeArchive(archive, exportRequest.Format)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ExportConfigsResponce{Archive: data}, nil
+}
+
+//ImportConfigs applies an archive created by ExportConfigs within a single transaction. In a dry run nothing is written, but the changes which would be made are reported
+func (s *configServer) ImportConfigs(ctx context.Context, importRequest *pb.ImportConfigsRequest) (*pb.ImportConfigsResponce, error) {
+	archive, err := decodeArchive(importRequest.Archive, importRequest.Format)
+	if err != nil {
+		return nil, err
+	}
+	var changes []*pb.ConfigChange
+	err = s.transactor.InTransaction(ctx, func(repos repository.ConfigRepos) error {
+		var err error
+		changes, err = importArchive(ctx, repos, archive, importRequest.ConfigTypes, importRequest.Mode, importRequest.DryRun)
+		return err
+	})
+	if err != nil {
+		return nil, statusError(err, "", "")
+	}
+	if !importRequest.DryRun {
+		for _, change := range changes {
+			if change.Action != actionUnchanged {
+				countWrite(change.ConfigType, change.Action)
+			}
+		}
+		s.configCache.Flush()
+	}
+	return &pb.ImportConfigsResponce{Changes: changes, DryRun: importRequest.DryRun}, nil
+}
+
+func (s *configServer) repos() repository.ConfigRepos {
+	return repository.ConfigRepos{
+		MongoDB:     s.mongoDBConfigRepo,
+		TempConfig:  s.tempConfigRepo,
+		TsConfig:    s.tsConfigRepo,
+		FeatureFlag: s.featureFlagRepo,
+	}
+}
+
+//selectConfigTypes validates requested config types, an empty selection means all types
+func selectConfigTypes(requested []string) (map[string]bool, error) {
+	if len(requested) == 0 {
+		requested = configTypes
+	}
+	selected := make(map[string]bool, len(requested))
+	for _, configType := range requested {
+		switch configType {
+		case mongodb, tempconfig, tsconfig, featureflag:
+			selected[configType] = true
+		default:
+			return nil, unexpectedTypeError(configType)
+		}
+	}
+	return selected, nil
+}
+
+func exportArchive(ctx context.Context, repos repository.ConfigRepos, requestedTypes []string) (*entitie.Archive, error) {
+	selected, err := selectConfigTypes(requestedTypes)
+	if err != nil {
+		return nil, err
+	}
+	archive := new(entitie.Archive)
+	if selected[mongodb] {
+		if archive.Mongodbs, err = repos.MongoDB.FindAll(ctx); err != nil {
+			return nil, statusError(err, mongodb, "")
+		}
+	}
+	if selected[tempconfig] {
+		if archive.Tempconfigs, err = repos.TempConfig.FindAll(ctx); err != nil {
+			return nil, statusError(err, tempconfig, "")
+		}
+	}
+	if selected[tsconfig] {
+		if archive.Tsconfigs, err = repos.TsConfig.FindAll(ctx); err != nil {
+			return nil, statusError(err, tsconfig, "")
+		}
+	}
+	if selected[featureflag] {
+		if archive.Featureflags, err = repos.FeatureFlag.FindAll(ctx); err != nil {
+			return nil, statusError(err, featureflag, "")
+		}
+	}
+	return archive, nil
+}
+
+func encodeArchive(archive *entitie.Archive, format string) ([]byte, error) {
+	switch format {
+	case formatJSON, "":
+		return json.MarshalIndent(archive, "", "  ")
+	case formatYAML:
+		return yaml.Marshal(archive)
+	case formatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(archive); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, invalidArgument("format", "unexpected archive format "+format)
+	}
+}
+
+func decodeArchive(data []byte, format string) (*entitie.Archive, error) {
+	archive := new(entitie.Archive)
+	var err error
+	switch format {
+	case formatJSON, "":
+		err = json.Unmarshal(data, archive)
+	case formatYAML:
+		err = yaml.Unmarshal(data, archive)
+	case formatTOML:
+		err = toml.Unmarshal(data, archive)
+	default:
+		return nil, invalidArgument("format", "unexpected archive format "+format)
+	}
+	if err != nil {
+		return nil, invalidArgument("archive", err.Error())
+	}
+	return archive, nil
+}
+
+//importArchive writes configs of the selected types from the archive using given repositories, revisions in the archive are ignored.
+//In create mode existing configs must be equal to the imported ones, upsert mode updates them and replace mode additionally deletes configs missing in the archive
+func importArchive(ctx context.Context, repos repository.ConfigRepos, archive *entitie.Archive, requestedTypes []string, mode string, dryRun bool) ([]*pb.ConfigChange, error) {
+	switch mode {
+	case "":
+		mode = importModeCreate
+	case importModeCreate, importModeUpsert, importModeReplace:
+	default:
+		return nil, invalidArgument("mode", "unexpected import mode "+mode)
+	}
+	selected, err := selectConfigTypes(requestedTypes)
+	if err != nil {
+		return nil, err
+	}
+	var changes, typeChanges []*pb.ConfigChange
+	if selected[mongodb] {
+		if typeChanges, err = importConfigs(ctx, repos.MongoDB, mongodb, func(config *entitie.Mongodb) string { return config.Domain }, archive.Mongodbs, mode, dryRun); err != nil {
+			return nil, err
+		}
+		changes = append(changes, typeChanges...)
+	}
+	if selected[tempconfig] {
+		if typeChanges, err = importConfigs(ctx, repos.TempConfig, tempconfig, func(config *entitie.Tempconfig) string { return config.RestApiRoot }, archive.Tempconfigs, mode, dryRun); err != nil {
+			return nil, err
+		}
+		changes = append(changes, typeChanges...)
+	}
+	if selected[tsconfig] {
+		if typeChanges, err = importConfigs(ctx, repos.TsConfig, tsconfig, func(config *entitie.Tsconfig) string { return config.Module }, archive.Tsconfigs, mode, dryRun); err != nil {
+			return nil, err
+		}
+		changes = append(changes, typeChanges...)
+	}
+	if selected[featureflag] {
+		if typeChanges, err = importConfigs(ctx, repos.FeatureFlag, featureflag, func(config *entitie.Featureflag) string { return config.Key }, archive.Featureflags, mode, dryRun); err != nil {
+			return nil, err
+		}
+		changes = append(changes, typeChanges...)
+	}
+	return changes, nil
+}
+
+//importAction decides what should be done with an imported config
+func importAction(configType, configName string, persisted, equal bool, mode string) (string, error) {
+	switch {
+	case !persisted:
+		return actionCreate, nil
+	case equal:
+		return actionUnchanged, nil
+	case mode == importModeCreate:
+		return "", resourceError(codes.AlreadyExists, repository.ErrAlreadyExists, configType, configName)
+	default:
+		return actionUpdate, nil
+	}
+}
+
+//importConfigs imports the configs of one type, named by name, using repo
+func importConfigs[T any](ctx context.Context, repo configRepo[T], configType string, name func(config *T) string, configs []T, mode string, dryRun bool) ([]*pb.ConfigChange, error) {
+	existing, err := repo.FindAll(ctx)
+	if err != nil {
+		return nil, statusError(err, configType, "")
+	}
+	persisted := make(map[string]T, len(existing))
+	for i := range existing {
+		persisted[name(&existing[i])] = existing[i]
+	}
+	var changes []*pb.ConfigChange
+	imported := make(map[string]bool, len(configs))
+	for i := range configs {
+		config := &configs[i]
+		configName := name(config)
+		if imported[configName] {
+			return nil, invalidArgument("archive", fmt.Sprintf("duplicate %s config %q", configType, configName))
+		}
+		imported[configName] = true
+		old, found := persisted[configName]
+		keepIdentity(config, old)
+		action, err := importAction(configType, configName, found, reflect.DeepEqual(old, *config), mode)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, &pb.ConfigChange{ConfigType: configType, ConfigName: configName, Action: action})
+		if dryRun {
+			continue
+		}
+		switch action {
+		case actionCreate:
+			_, err = repo.Save(ctx, config)
+		case actionUpdate:
+			_, err = repo.Update(ctx, config)
+		}
+		if err != nil {
+			return nil, statusError(err, configType, configName)
+		}
+	}
+	if mode != importModeReplace {
+		return changes, nil
+	}
+	for i := range existing {
+		configName := name(&existing[i])
+		if imported[configName] {
+			continue
+		}
+		changes = append(changes, &pb.ConfigChange{ConfigType: configType, ConfigName: configName, Action: actionDelete})
+		if dryRun {
+			continue
+		}
+		if _, err = repo.Delete(ctx, configName, configRevision(&existing[i])); err != nil {
+			return nil, statusError(err, configType, configName)
+		}
+	}
+	return changes, nil
+}
+
+//keepIdentity gives an imported config the surrogate id and the revision of the persisted one, which the archive does not hold
+func keepIdentity[T any](config *T, old T) {
+	to, from := reflect.ValueOf(config).Elem(), reflect.ValueOf(old)
+	for _, field := range []string{"ID", "Revision"} {
+		to.FieldByName(field).Set(from.FieldByName(field))
+	}
+}
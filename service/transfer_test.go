@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/YAWAL/GetMeConf/repository"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type recordingMongoDBConfigRepo struct {
+	mockMongoDBConfigRepo
+	saved   []string
+	updated []string
+	deleted []string
+}
+
+func (m *recordingMongoDBConfigRepo) Save(ctx context.Context, config *entitie.Mongodb) (string, error) {
+	m.saved = append(m.saved, config.Domain)
+	return "OK", nil
+}
+
+func (m *recordingMongoDBConfigRepo) Update(ctx context.Context, config *entitie.Mongodb) (string, error) {
+	m.updated = append(m.updated, config.Domain)
+	return "OK", nil
+}
+
+func (m *recordingMongoDBConfigRepo) Delete(ctx context.Context, configName string, expectedRevision int64) (string, error) {
+	m.deleted = append(m.deleted, configName)
+	return "OK", nil
+}
+
+type mockTransactor struct {
+	repos repository.ConfigRepos
+}
+
+func (m *mockTransactor) InTransaction(ctx context.Context, fn func(repos repository.ConfigRepos) error) error {
+	return fn(m.repos)
+}
+
+func TestEncodeDecodeArchive(t *testing.T) {
+	archive := &entitie.Archive{
+		Mongodbs:    []entitie.Mongodb{{Domain: "testName", Host: "testHost", Port: 8080}},
+		Tempconfigs: []entitie.Tempconfig{{RestApiRoot: "testApiRoot", Host: "testHost", Port: 8080, Remoting: entitie.LoopbackRemoting{Context: true}, LegacyExplorer: true}},
+		Tsconfigs: []entitie.Tsconfig{{Module: "testModule", Target: "testTarget", SourceMap: true, Excluding: 1,
+			CompilerOptions: entitie.JSONMap{"target": "testTarget", "paths": map[string]interface{}{"app": "src/app"}}, Include: entitie.StringList{"src"}}},
+		Featureflags: []entitie.Featureflag{{Key: "testFlag", Enabled: true, Variants: entitie.JSONMap{"on": true, "off": false}, OffVariant: "off", DefaultVariant: "off",
+			Rollout: entitie.FlagRollout{{Variant: "on", Percent: 50}, {Variant: "off", Percent: 50}},
+			Rules:   entitie.FlagRules{{Conditions: []entitie.FlagCondition{{Attribute: "country", Operator: entitie.FlagOperatorIn, Values: entitie.StringList{"UA"}}}, Variant: "on"}}}},
+	}
+	for _, format := range []string{formatJSON, formatYAML, formatTOML} {
+		data, err := encodeArchive(archive, format)
+		if err != nil {
+			t.Error("error during unit testing: ", err)
+		}
+		decoded, err := decodeArchive(data, format)
+		if err != nil {
+			t.Error("error during unit testing: ", err)
+		}
+		assert.Equal(t, archive, decoded, format)
+	}
+
+	_, err := encodeArchive(archive, "xml")
+	assert.Error(t, err)
+	_, err = decodeArchive([]byte("{"), formatJSON)
+	assert.Error(t, err)
+}
+
+func TestExportConfigs(t *testing.T) {
+	mock := &mockConfigServer{}
+	mock.mongoDBConfigRepo = &mockMongoDBConfigRepo{}
+	mock.tsConfigRepo = &mockTsConfigRepo{}
+	mock.tempConfigRepo = &mockTempConfigRepo{}
+	mock.featureFlagRepo = &mockFeatureFlagRepo{}
+
+	res, err := mock.ExportConfigs(context.Background(), &pb.ExportConfigsRequest{ConfigTypes: []string{"mongodb"}, Format: "json"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	archive, err := decodeArchive(res.Archive, formatJSON)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, &entitie.Archive{Mongodbs: []entitie.Mongodb{{Domain: "testName", Host: "testHost", Port: 8080}}}, archive)
+
+	res, err = mock.ExportConfigs(context.Background(), &pb.ExportConfigsRequest{Format: "yaml"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	archive, err = decodeArchive(res.Archive, formatYAML)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, 1, len(archive.Mongodbs))
+	assert.Equal(t, 1, len(archive.Tempconfigs))
+	assert.Equal(t, 1, len(archive.Tsconfigs))
+	assert.Equal(t, 1, len(archive.Featureflags))
+
+	_, err = mock.ExportConfigs(context.Background(), &pb.ExportConfigsRequest{ConfigTypes: []string{"unexpectedConfigType"}})
+	if assert.Error(t, err) {
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+
+	mock.tsConfigRepo = &mockErrorTsConfigRepo{}
+	_, err = mock.ExportConfigs(context.Background(), &pb.ExportConfigsRequest{})
+	if assert.Error(t, err) {
+		assert.Equal(t, errors.New("error from database querying"), err)
+	}
+}
+
+func TestImportConfigs(t *testing.T) {
+	archive, err := encodeArchive(&entitie.Archive{Mongodbs: []entitie.Mongodb{
+		{Domain: "testName", Host: "testHost", Port: 8080},
+		{Domain: "newName", Host: "newHost", Port: 9090},
+	}}, formatJSON)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	changedArchive, err := encodeArchive(&entitie.Archive{Mongodbs: []entitie.Mongodb{
+		{Domain: "testName", Host: "changedHost", Port: 8080},
+	}}, formatJSON)
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+
+	repo := &recordingMongoDBConfigRepo{}
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(5*time.Minute, 10*time.Minute)
+	mock.transactor = &mockTransactor{repos: repository.ConfigRepos{MongoDB: repo}}
+
+	res, err := mock.ImportConfigs(context.Background(), &pb.ImportConfigsRequest{Archive: archive, ConfigTypes: []string{"mongodb"}})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []*pb.ConfigChange{
+		{ConfigType: "mongodb", ConfigName: "testName", Action: "unchanged"},
+		{ConfigType: "mongodb", ConfigName: "newName", Action: "create"},
+	}, res.Changes)
+	assert.Equal(t, []string{"newName"}, repo.saved)
+
+	_, err = mock.ImportConfigs(context.Background(), &pb.ImportConfigsRequest{Archive: changedArchive, ConfigTypes: []string{"mongodb"}, Mode: "create"})
+	assert.Error(t, err)
+
+	repo = &recordingMongoDBConfigRepo{}
+	mock.transactor = &mockTransactor{repos: repository.ConfigRepos{MongoDB: repo}}
+	res, err = mock.ImportConfigs(context.Background(), &pb.ImportConfigsRequest{Archive: changedArchive, ConfigTypes: []string{"mongodb"}, Mode: "upsert", DryRun: true})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []*pb.ConfigChange{{ConfigType: "mongodb", ConfigName: "testName", Action: "update"}}, res.Changes)
+	assert.True(t, res.DryRun)
+	assert.Empty(t, repo.updated)
+
+	res, err = mock.ImportConfigs(context.Background(), &pb.ImportConfigsRequest{Archive: []byte("{}"), ConfigTypes: []string{"mongodb"}, Mode: "replace"})
+	if err != nil {
+		t.Error("error during unit testing: ", err)
+	}
+	assert.Equal(t, []*pb.ConfigChange{{ConfigType: "mongodb", ConfigName: "testName", Action: "delete"}}, res.Changes)
+	assert.Equal(t, []string{"testName"}, repo.deleted)
+
+	_, err = mock.ImportConfigs(context.Background(), &pb.ImportConfigsRequest{Archive: archive, Mode: "merge"})
+	assert.Error(t, err)
+
+	mock.transactor = &mockTransactor{repos: repository.ConfigRepos{MongoDB: &mockErrorMongoDBConfigRepo{}}}
+	_, err = mock.ImportConfigs(context.Background(), &pb.ImportConfigsRequest{Archive: archive, ConfigTypes: []string{"mongodb"}})
+	if assert.Error(t, err) {
+		assert.Equal(t, errors.New("error from database querying"), err)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	"github.com/patrickmn/go-cache"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+//labelHeaderPrefix starts the metadata keys of client labels, x-label-region: eu is the label region=eu
+const labelHeaderPrefix = "x-label-"
+
+//variantTypes are the config types which can hold variants
+var variantTypes = []string{mongodb, tempconfig, tsconfig}
+
+//clientLabels returns the labels of the client, sent as x-label- metadata or in the request. Labels of the request override metadata
+func clientLabels(ctx context.Context, requestLabels map[string]string) map[string]string {
+	labels := make(map[string]string)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			if strings.HasPrefix(key, labelHeaderPrefix) && len(key) > len(labelHeaderPrefix) && len(values) > 0 {
+				labels[strings.TrimPrefix(key, labelHeaderPrefix)] = values[0]
+			}
+		}
+	}
+	for key, value := range requestLabels {
+		labels[key] = value
+	}
+	return labels
+}
+
+//selectVariant returns the variant of a config served to a client with labels, or nil when none matches. The variants are cached
+//apart from the responses, so the config is only read when they are not cached; it is returned then, otherwise nil
+func (s *configServer) selectVariant(ctx context.Context, configType, configName string, labels map[string]string) (entitie.ConfigInterface, *entitie.ConfigVariant, error) {
+	variantsKey := configType + "/" + configName + "#variants"
+	if variants, found := s.configCache.Get(variantsKey); found {
+		return nil, variants.(entitie.ConfigVariants).Select(labels), nil
+	}
+	res, err := s.findConfig(ctx, configType, configName)
+	if err != nil {
+		return nil, nil, err
+	}
+	variants := configVariants(res)
+	s.configCache.Set(variantsKey, variants, cache.DefaultExpiration)
+	return res, variants.Select(labels), nil
+}
+
+//hasVariants reports whether configs of configType can hold variants
+func hasVariants(configType string) bool {
+	return slices.Contains(variantTypes, configType)
+}
+
+func configVariants(config entitie.ConfigInterface) entitie.ConfigVariants {
+	switch c := config.(type) {
+	case *entitie.Mongodb:
+		return c.Variants
+	case *entitie.Tempconfig:
+		return c.Variants
+	case *entitie.Tsconfig:
+		return c.Variants
+	default:
+		return nil
+	}
+}
+
+//withoutVariants returns a copy of config without its variants, configs are listed to clients as they are served to
+//clients matching no variant. The copy leaves configs held in memory, like those of the snapshot, unchanged
+func withoutVariants(config entitie.ConfigInterface) entitie.ConfigInterface {
+	switch c := config.(type) {
+	case *entitie.Mongodb:
+		stripped := *c
+		stripped.Variants = nil
+		return &stripped
+	case *entitie.Tempconfig:
+		stripped := *c
+		stripped.Variants = nil
+		return &stripped
+	case *entitie.Tsconfig:
+		stripped := *c
+		stripped.Variants = nil
+		return &stripped
+	default:
+		return config
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YAWAL/GetMeConf/entitie"
+	pb "github.com/YAWAL/GetMeConfAPI/api"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+type variantMongoDBConfigRepo struct {
+	mockMongoDBConfigRepo
+	finds int
+}
+
+func (m *variantMongoDBConfigRepo) Find(ctx context.Context, configName string) (*entitie.Mongodb, error) {
+	m.finds++
+	return &entitie.Mongodb{Domain: configName, Host: "mongo-0", Port: 27017, Revision: 2, Variants: entitie.ConfigVariants{
+		{Name: "eu", Match: entitie.Labels{"region": "eu"}, Config: entitie.JSONMap{"host": "mongo-eu"}},
+		{Name: "eu-canary", Match: entitie.Labels{"region": "eu", "version": "2"}, Config: entitie.JSONMap{"host": "mongo-eu-canary"}},
+	}}, nil
+}
+
+func TestClientLabels(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-label-region", "eu", "x-label-version", "1", "x-label-", "none", "x-request-id", "1"))
+	assert.Equal(t, map[string]string{"region": "eu", "version": "2", "host": "web-1"}, clientLabels(ctx, map[string]string{"version": "2", "host": "web-1"}),
+		"labels of the request override metadata")
+	assert.Empty(t, clientLabels(context.Background(), nil))
+}
+
+func TestGetConfigByNameVariants(t *testing.T) {
+	repo := &variantMongoDBConfigRepo{}
+	mock := &mockConfigServer{}
+	mock.configCache = cache.New(5*time.Minute, 10*time.Minute)
+	mock.mongoDBConfigRepo = repo
+	get := func(ctx context.Context, labels map[string]string) *pb.GetConfigResponce {
+		res, err := mock.GetConfigByName(ctx, &pb.GetConfigByNameRequest{ConfigType: mongodb, ConfigName: "orders", Labels: labels})
+		if err != nil {
+			t.Fatal("error during unit testing: ", err)
+		}
+		return res
+	}
+
+	res := get(context.Background(), map[string]string{"region": "eu", "version": "2"})
+	assert.Equal(t, &pb.GetConfigResponce{Config: []byte(`{"domain":"orders","host":"mongo-eu-canary","port":27017,"revision":2}`), Revision: 2, Variant: "eu-canary"}, res,
+		"the most specific variant is served")
+	res = get(metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-label-region", "eu")), nil)
+	assert.Equal(t, &pb.GetConfigResponce{Config: []byte(`{"domain":"orders","host":"mongo-eu","port":27017,"revision":2}`), Revision: 2, Variant: "eu"}, res,
+		"a cached variant is not served to clients of another variant")
+	res = get(context.Background(), map[string]string{"region": "us"})
+	assert.Equal(t, &pb.GetConfigResponce{Config: []byte(`{"domain":"orders","host":"mongo-0","port":27017,"revision":2}`), Revision: 2}, res,
+		"clients matching no variant are served the config without variants")
+	assert.Equal(t, 3, repo.finds)
+
+	assert.Equal(t, "eu", get(context.Background(), map[string]string{"region": "eu", "host": "web-1"}).Variant)
+	assert.Equal(t, 3, repo.finds, "responses of a variant are cached for every client it is selected for")
+
+	res = get(context.Background(), nil)
+	assert.Equal(t, &pb.GetConfigResponce{Config: []byte(`{"domain":"orders","host":"mongo-0","port":27017,"revision":2}`), Revision: 2}, res,
+		"clients without labels are served the config without variants")
+	assert.Equal(t, 3, repo.finds, "clients without labels share the response of clients matching no variant")
+
+	mock.tsConfigRepo = &mockTsConfigRepo{}
+	res, err := mock.GetConfigByName(context.Background(), &pb.GetConfigByNameRequest{ConfigType: tsconfig, ConfigName: "orders"})
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Contains(t, string(res.Config), `"module":"testModule"`, "configs of different types with the same name are cached apart")
+}
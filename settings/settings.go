@@ -0,0 +1,247 @@
+//Package settings loads the settings of the service from a YAML file, env. variables and command line flags, each overriding the previous ones
+package settings
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/YAWAL/GetMeConf/repository"
+	"gopkg.in/yaml.v2"
+)
+
+//fileEnv and fileFlag name the settings file, no file is read if neither is given
+const (
+	fileEnv  = "CONFIG_FILE"
+	fileFlag = "config"
+)
+
+//maskedValue replaces secrets in printed settings
+const maskedValue = "********"
+
+//Settings are all settings of the service. The tags of a field name its key in the settings file, its env. variable and its flag
+type Settings struct {
+	Server   Server                    `yaml:"server"`
+	Cache    Cache                     `yaml:"cache"`
+	Postgres repository.PostgresConfig `yaml:"postgres"`
+}
+
+//Server contains the settings of the gRPC server and of its observability
+type Server struct {
+	Port                       string `yaml:"port" env:"SERVICE_PORT" flag:"port" usage:"port of the gRPC server"`
+	MetricsPort                string `yaml:"metricsPort" env:"METRICS_PORT" flag:"metrics-port" usage:"port of the Prometheus metrics endpoint"`
+	RequestTimeoutSeconds      int    `yaml:"requestTimeoutSeconds" env:"REQUEST_TIMEOUT_SECONDS" flag:"request-timeout" usage:"deadline of unary requests without one, 0 disables it"`
+	StreamTimeoutSeconds       int    `yaml:"streamTimeoutSeconds" env:"STREAM_TIMEOUT_SECONDS" flag:"stream-timeout" usage:"deadline of streams without one, 0 disables it"`
+	HealthCheckIntervalSeconds int    `yaml:"healthCheckIntervalSeconds" env:"HEALTH_CHECK_INTERVAL_SECONDS" flag:"health-check-interval" usage:"interval of database health checks"`
+	ShutdownDelaySeconds       int    `yaml:"shutdownDelaySeconds" env:"SHUTDOWN_DELAY_SECONDS" flag:"shutdown-delay" usage:"time for load balancers to drain the server before it stops"`
+	LogLevel                   string `yaml:"logLevel" env:"LOG_LEVEL" flag:"log-level" usage:"level of logged messages: debug, info, warn or error"`
+	TracesExporter             string `yaml:"tracesExporter" env:"TRACES_EXPORTER" flag:"traces-exporter" usage:"exporter of trace spans: none, otlp or stdout"`
+	DegradedStartup            bool   `yaml:"degradedStartup" env:"DEGRADED_STARTUP" flag:"degraded-startup" usage:"serve before the database is connected, reading configs from the snapshot"`
+	SnapshotFile               string `yaml:"snapshotFile" env:"SNAPSHOT_FILE" flag:"snapshot-file" usage:"archive written by the export command, its configs are served while the database is unreachable"`
+}
+
+//Cache contains the settings of the cache of config responses
+type Cache struct {
+	ExpirationMinutes      int `yaml:"expirationMinutes" env:"CACHE_EXPIRATION_TIME" flag:"cache-expiration" usage:"minutes responses are cached, 0 is forever"`
+	CleanupIntervalMinutes int `yaml:"cleanupIntervalMinutes" env:"CACHE_CLEANUP_INTERVAL" flag:"cache-cleanup-interval" usage:"interval of removing expired responses, 0 disables it"`
+}
+
+//Defaults returns the settings used for what is not configured
+func Defaults() Settings {
+	return Settings{
+		Server: Server{
+			Port:                       "3000",
+			MetricsPort:                "9090",
+			RequestTimeoutSeconds:      30,
+			HealthCheckIntervalSeconds: 10,
+			ShutdownDelaySeconds:       5,
+			LogLevel:                   "info",
+			TracesExporter:             "none",
+		},
+		Cache: Cache{
+			ExpirationMinutes:      5,
+			CleanupIntervalMinutes: 10,
+		},
+		Postgres: repository.DefaultPostgresConfig(),
+	}
+}
+
+//Validate returns the errors of all invalid settings
+func (s Settings) Validate() error {
+	var errs []error
+	for _, port := range []struct{ key, value string }{{"server.port", s.Server.Port}, {"server.metricsPort", s.Server.MetricsPort}} {
+		if number, err := strconv.Atoi(port.value); err != nil || number < 1 || number > 65535 {
+			errs = append(errs, fmt.Errorf("%s: %q is not a port number", port.key, port.value))
+		}
+	}
+	if s.Server.RequestTimeoutSeconds < 0 {
+		errs = append(errs, errors.New("server.requestTimeoutSeconds: must not be negative"))
+	}
+	if s.Server.StreamTimeoutSeconds < 0 {
+		errs = append(errs, errors.New("server.streamTimeoutSeconds: must not be negative"))
+	}
+	if s.Server.HealthCheckIntervalSeconds <= 0 {
+		errs = append(errs, errors.New("server.healthCheckIntervalSeconds: must be positive"))
+	}
+	if s.Server.ShutdownDelaySeconds < 0 {
+		errs = append(errs, errors.New("server.shutdownDelaySeconds: must not be negative"))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s.Server.LogLevel)); err != nil {
+		errs = append(errs, fmt.Errorf("server.logLevel: %q is not a log level", s.Server.LogLevel))
+	}
+	switch s.Server.TracesExporter {
+	case "none", "otlp", "stdout":
+	default:
+		errs = append(errs, fmt.Errorf("server.tracesExporter: %q is neither none, otlp nor stdout", s.Server.TracesExporter))
+	}
+	if s.Cache.ExpirationMinutes < 0 {
+		errs = append(errs, errors.New("cache.expirationMinutes: must not be negative"))
+	}
+	if s.Cache.CleanupIntervalMinutes < 0 {
+		errs = append(errs, errors.New("cache.cleanupIntervalMinutes: must not be negative"))
+	}
+	if err := s.Postgres.Validate(); err != nil {
+		postgresErrs := []error{err}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			postgresErrs = joined.Unwrap()
+		}
+		for _, postgresErr := range postgresErrs {
+			errs = append(errs, fmt.Errorf("postgres.%w", postgresErr))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+//Loader registers the flags of all settings in a flag set and loads the settings after the flags are parsed
+type Loader struct {
+	flags *flag.FlagSet
+	file  *string
+}
+
+//NewLoader registers the flags of all settings and the flag of the settings file
+func NewLoader(flags *flag.FlagSet) *Loader {
+	l := &Loader{flags: flags, file: flags.String(fileFlag, "", "YAML settings file, overridden by env. variables and flags (env. "+fileEnv+")")}
+	defaults := Defaults()
+	for _, f := range fields(&defaults) {
+		usage := fmt.Sprintf("%s (env. %s, default %v)", f.usage, f.env, f.value.Interface())
+		if f.value.Kind() == reflect.Bool {
+			flags.Bool(f.flag, false, usage)
+		} else {
+			flags.String(f.flag, "", usage)
+		}
+	}
+	return l
+}
+
+//Load returns the default settings overridden by the settings file, env. variables and parsed flags, in this order. Invalid settings are errors
+func (l *Loader) Load() (*Settings, error) {
+	s := Defaults()
+	file := os.Getenv(fileEnv)
+	if *l.file != "" {
+		file = *l.file
+	}
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err = yaml.UnmarshalStrict(data, &s); err != nil {
+			return nil, fmt.Errorf("settings file %s: %v", file, err)
+		}
+	}
+	fs := fields(&s)
+	for _, f := range fs {
+		if value, ok := os.LookupEnv(f.env); ok && value != "" {
+			if err := f.set(value); err != nil {
+				return nil, fmt.Errorf("env. variable %s: %v", f.env, err)
+			}
+		}
+	}
+	var flagErr error
+	l.flags.Visit(func(set *flag.Flag) {
+		for _, f := range fs {
+			if f.flag == set.Name && flagErr == nil {
+				if err := f.set(set.Value.String()); err != nil {
+					flagErr = fmt.Errorf("flag -%s: %v", f.flag, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid settings:\n%v", err)
+	}
+	return &s, nil
+}
+
+//Print returns the settings as YAML with secrets masked
+func (s Settings) Print() ([]byte, error) {
+	masked := s
+	for _, f := range fields(&masked) {
+		if f.secret && f.value.String() != "" {
+			f.value.SetString(maskedValue)
+		}
+	}
+	return yaml.Marshal(masked)
+}
+
+//field is a single setting found by its tags
+type field struct {
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+func (f field) set(value string) error {
+	switch f.value.Kind() {
+	case reflect.Int:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		f.value.SetInt(int64(number))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is neither true nor false", value)
+		}
+		f.value.SetBool(b)
+	default:
+		f.value.SetString(value)
+	}
+	return nil
+}
+
+//fields returns the settings of s, nested structs are walked
+func fields(s *Settings) []field {
+	var fs []field
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			structField := v.Type().Field(i)
+			if structField.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+				continue
+			}
+			fs = append(fs, field{
+				env:    structField.Tag.Get("env"),
+				flag:   structField.Tag.Get("flag"),
+				usage:  structField.Tag.Get("usage"),
+				secret: structField.Tag.Get("secret") == "true",
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(s).Elem())
+	return fs
+}
@@ -0,0 +1,105 @@
+package settings
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func load(t *testing.T, args ...string) (*Settings, error) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader := NewLoader(flags)
+	if err := flags.Parse(args); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	return loader.Load()
+}
+
+func writeFile(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "settings.yaml")
+	if err := ioutil.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	return file
+}
+
+func TestLoad(t *testing.T) {
+	_, err := load(t)
+	if assert.Error(t, err, "there are no default credentials") {
+		assert.Contains(t, err.Error(), "postgres.user")
+		assert.Contains(t, err.Error(), "postgres.password")
+	}
+
+	t.Setenv("PDB_USER", "envUser")
+	t.Setenv("PDB_PASSWORD", "envPassword")
+	s, err := load(t)
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	expected := Defaults()
+	expected.Postgres.User, expected.Postgres.Password = "envUser", "envPassword"
+	assert.Equal(t, expected, *s)
+
+	file := writeFile(t, "server:\n  port: \"4000\"\n  metricsPort: \"9100\"\n  requestTimeoutSeconds: 10\npostgres:\n  host: fileHost\n")
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("METRICS_PORT", "9200")
+	t.Setenv("REQUEST_TIMEOUT_SECONDS", "20")
+	s, err = load(t, "-request-timeout", "60", "-db-password", "flagPassword")
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Equal(t, "4000", s.Server.Port, "the file overrides defaults")
+	assert.Equal(t, "fileHost", s.Postgres.Host)
+	assert.Equal(t, "9200", s.Server.MetricsPort, "env. variables override the file")
+	assert.Equal(t, 60, s.Server.RequestTimeoutSeconds, "flags override env. variables")
+	assert.Equal(t, "flagPassword", s.Postgres.Password)
+	assert.Equal(t, 10, s.Cache.CleanupIntervalMinutes, "defaults are kept")
+
+	s, err = load(t, "-degraded-startup")
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.True(t, s.Server.DegradedStartup, "boolean flags need no value")
+	t.Setenv("DEGRADED_STARTUP", "yes")
+	_, err = load(t)
+	assert.Error(t, err)
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Setenv("CACHE_EXPIRATION_TIME", "five")
+	_, err := load(t)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "CACHE_EXPIRATION_TIME")
+	}
+
+	t.Setenv("CACHE_EXPIRATION_TIME", "")
+	_, err = load(t, "-db-user", "user", "-db-password", "password", "-db-password-file", "/run/secrets/password")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "only one source")
+	}
+
+	_, err = load(t, "-port", "http", "-health-check-interval", "0", "-db-port", "70000", "-log-level", "verbose")
+	if assert.Error(t, err) {
+		for _, expected := range []string{"server.port", "server.healthCheckIntervalSeconds", "postgres.port", "server.logLevel"} {
+			assert.Contains(t, err.Error(), expected, "all invalid settings are reported")
+		}
+	}
+
+	_, err = load(t, "-config", writeFile(t, "server:\n  prot: \"4000\"\n"))
+	assert.Error(t, err, "unknown keys in the file are errors")
+}
+
+func TestPrint(t *testing.T) {
+	s := Defaults()
+	s.Postgres.User, s.Postgres.Password = "user", "s3cr3t"
+	data, err := s.Print()
+	if err != nil {
+		t.Fatal("error during unit testing: ", err)
+	}
+	assert.Contains(t, string(data), maskedValue)
+	assert.NotContains(t, string(data), s.Postgres.Password)
+	assert.NotEqual(t, maskedValue, s.Postgres.Password, "the printed settings are not changed")
+}